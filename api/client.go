@@ -3,11 +3,12 @@ package api
 import (
 	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"time"
 )
@@ -15,6 +16,12 @@ import (
 type IClient interface {
 	Login(context.Context) error
 	GetGateway(context.Context) (GatewayResponse, error)
+	// GetClients fails with errors.ErrUnsupported if the gateway cannot list its clients
+	GetClients(context.Context) (ClientsResponse, error)
+	// GetCellTelemetry fails with errors.ErrUnsupported if the gateway has no cell telemetry
+	GetCellTelemetry(context.Context) (CellTelemetryResponse, error)
+	// Reboot fails with errors.ErrUnsupported if the gateway cannot be rebooted
+	Reboot(context.Context) error
 }
 
 // ClientConfig holds configuration for the API client
@@ -23,6 +30,12 @@ type ClientConfig struct {
 	Username string
 	Password string
 	Logger   *log.Logger
+	// OnLogin is called after every successful login, if set
+	OnLogin func()
+	// Recorder archives every raw gateway response, if set
+	Recorder *Recorder
+	// Driver names the API the gateway speaks, DriverAuto or empty probes for it on first use
+	Driver string
 }
 
 // Client handles communication with the gateway API
@@ -30,24 +43,29 @@ type Client struct {
 	config     ClientConfig
 	httpClient *http.Client
 	auth       *authToken
+	// driver is the API of the gateway, nil until it is detected
+	driver driver
 }
 
 // NewClient creates a new API client with the provided base URL
 func NewClient(baseURL string, logger *log.Logger) *Client {
+	return NewClientWithConfig(NewClientConfig(baseURL, logger), nil)
+}
+
+// NewClientConfig creates a client configuration using the credentials from the environment
+func NewClientConfig(baseURL string, logger *log.Logger) ClientConfig {
 	username := os.Getenv("GATEWAY_USERNAME")
 	password := os.Getenv("GATEWAY_PASSWORD")
 	if username == "" || password == "" {
 		log.Fatal("GATEWAY_USERNAME and GATEWAY_PASSWORD must be set")
 	}
 
-	config := ClientConfig{
+	return ClientConfig{
 		BaseURL:  baseURL,
 		Username: username,
 		Password: password,
 		Logger:   logger,
 	}
-
-	return NewClientWithConfig(config, nil)
 }
 
 // NewClientWithConfig creates a new API client with the provided configuration and HTTP client
@@ -62,55 +80,121 @@ func NewClientWithConfig(config ClientConfig, httpClient *http.Client) *Client {
 
 	config.Logger.Printf("Gateway URL: %s", config.BaseURL)
 
+	driver, err := lookupDriver(config.Driver)
+	if err != nil {
+		config.Logger.Printf("Probing for the gateway API: %v", err)
+	}
+
 	return &Client{
 		config:     config,
 		httpClient: httpClient,
+		driver:     driver,
 	}
 }
 
-// Login authenticates with the API and stores the auth token
+// Login authenticates with the API and stores the auth token, detecting the gateway API first if needed
 func (c *Client) Login(ctx context.Context) error {
-	loginBody, err := json.Marshal(struct {
-		Username string `json:"username"`
-		Password string `json:"password"`
-	}{
-		Username: c.config.Username,
-		Password: c.config.Password,
-	})
+	driver, err := c.detect(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to marshal login body: %w", err)
+		return err
 	}
 
-	respBody, err := c.post(ctx, "auth/login", bytes.NewBuffer(loginBody), nil)
+	auth, err := driver.login(ctx, c)
 	if err != nil {
 		return fmt.Errorf("failed to login: %w", err)
 	}
 
-	var authResponse authResponse
-	err = json.Unmarshal(respBody, &authResponse)
-	if err != nil {
-		return fmt.Errorf("unexpected login response: %w", err)
+	// APIs without tokens never log in
+	if auth == nil {
+		return nil
 	}
 
-	c.auth = &authResponse.Auth
+	c.auth = auth
+	if c.config.OnLogin != nil {
+		c.config.OnLogin()
+	}
 	return nil
 }
 
-// GetGateway retrieves gateway information from the API
+// GetGateway retrieves gateway information from the API, detecting the gateway API first if needed
 func (c *Client) GetGateway(ctx context.Context) (GatewayResponse, error) {
-	var gateway GatewayResponse
+	driver, err := c.detect(ctx)
+	if err != nil {
+		return GatewayResponse{}, fmt.Errorf("failed to get gateway info: %w", err)
+	}
+
+	start := time.Now()
+	body, gateway, err := driver.getGateway(ctx, c)
+
+	if body != nil && c.config.Recorder != nil {
+		err := c.config.Recorder.Record(start, time.Since(start), body)
+		if err != nil {
+			c.config.Logger.Printf("Failed to record gateway response: %v", err)
+		}
+	}
+
+	return gateway, err
+}
+
+// GetClients lists the devices connected to the gateway, detecting the gateway API first if needed
+func (c *Client) GetClients(ctx context.Context) (ClientsResponse, error) {
+	driver, err := c.detect(ctx)
+	if err != nil {
+		return ClientsResponse{}, fmt.Errorf("failed to get clients: %w", err)
+	}
+
+	return driver.getClients(ctx, c)
+}
+
+// GetCellTelemetry fetches the radio channels of the serving cells, detecting the gateway API first if needed
+func (c *Client) GetCellTelemetry(ctx context.Context) (CellTelemetryResponse, error) {
+	driver, err := c.detect(ctx)
+	if err != nil {
+		return CellTelemetryResponse{}, fmt.Errorf("failed to get cell telemetry: %w", err)
+	}
+
+	return driver.getCellTelemetry(ctx, c)
+}
+
+// GetSIM fetches the identity of the SIM card and modem, detecting the gateway API first if needed
+func (c *Client) GetSIM(ctx context.Context) (SIMResponse, error) {
+	driver, err := c.detect(ctx)
+	if err != nil {
+		return SIMResponse{}, fmt.Errorf("failed to get SIM telemetry: %w", err)
+	}
+
+	return driver.getSIM(ctx, c)
+}
+
+// Reboot restarts the gateway, which drops the connection for a few minutes
+func (c *Client) Reboot(ctx context.Context) error {
+	driver, err := c.detect(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to reboot: %w", err)
+	}
+
+	return driver.reboot(ctx, c)
+}
 
-	body, err := c.get(ctx, "gateway/?get=all")
+// GetAccessPoints reads the Wi-Fi configuration of the gateway, detecting the gateway API first if needed
+func (c *Client) GetAccessPoints(ctx context.Context) (AccessPoints, error) {
+	driver, err := c.detect(ctx)
 	if err != nil {
-		return gateway, fmt.Errorf("failed to get gateway info: %w", err)
+		return AccessPoints{}, fmt.Errorf("failed to get Wi-Fi configuration: %w", err)
 	}
 
-	err = json.Unmarshal(body, &gateway)
+	return driver.getAccessPoints(ctx, c)
+}
+
+// SetAccessPoints replaces the Wi-Fi configuration of the gateway, which restarts its radios. Pass a configuration
+// read with GetAccessPoints so the settings that are not modeled are kept.
+func (c *Client) SetAccessPoints(ctx context.Context, accessPoints AccessPoints) error {
+	driver, err := c.detect(ctx)
 	if err != nil {
-		return gateway, fmt.Errorf("failed to unmarshal gateway response: %w", err)
+		return fmt.Errorf("failed to set Wi-Fi configuration: %w", err)
 	}
 
-	return gateway, nil
+	return driver.setAccessPoints(ctx, c, accessPoints)
 }
 
 // ensureAuthenticated makes sure the client has a valid auth token
@@ -121,12 +205,47 @@ func (c *Client) ensureAuthenticated(ctx context.Context) error {
 	return nil
 }
 
-// get performs an HTTP GET request to the API
+// get performs an HTTP GET request to the API, logging in again once if the auth token is rejected
 func (c *Client) get(ctx context.Context, endpoint string) ([]byte, error) {
 	if err := c.ensureAuthenticated(ctx); err != nil {
 		return nil, err
 	}
 
+	return c.retryAuth(ctx, func() ([]byte, error) {
+		return c.getAuthenticated(ctx, endpoint)
+	})
+}
+
+// postAuthenticated performs an HTTP POST request of a JSON body with the auth token, logging in again once if
+// the token is rejected
+func (c *Client) postAuthenticated(ctx context.Context, endpoint string, body []byte) ([]byte, error) {
+	if err := c.ensureAuthenticated(ctx); err != nil {
+		return nil, err
+	}
+
+	return c.retryAuth(ctx, func() ([]byte, error) {
+		return c.post(ctx, endpoint, bytes.NewReader(body), map[string]string{"Authorization": "Bearer " + c.auth.Token})
+	})
+}
+
+// retryAuth runs an authenticated request, logging in again and repeating it once if the auth token is rejected
+func (c *Client) retryAuth(ctx context.Context, request func() ([]byte, error)) ([]byte, error) {
+	body, err := request()
+	if errors.Is(err, ErrAuth) {
+		// The gateway forgets its tokens when it restarts, long before they expire
+		c.config.Logger.Println("Auth token rejected, logging in again")
+		c.auth = nil
+		if err := c.Login(ctx); err != nil {
+			return nil, err
+		}
+		body, err = request()
+	}
+
+	return body, err
+}
+
+// getAuthenticated performs an HTTP GET request with the current auth token
+func (c *Client) getAuthenticated(ctx context.Context, endpoint string) ([]byte, error) {
 	c.config.Logger.Printf("GET %s", endpoint)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(endpoint), nil)
@@ -141,6 +260,20 @@ func (c *Client) get(ctx context.Context, endpoint string) ([]byte, error) {
 	return c.doRequest(req)
 }
 
+// getPublic performs an HTTP GET request to a URL that needs no auth token
+func (c *Client) getPublic(ctx context.Context, url string) ([]byte, error) {
+	c.config.Logger.Printf("GET %s", url)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	return c.doRequest(req)
+}
+
 // post performs an HTTP POST request to the API
 func (c *Client) post(ctx context.Context, endpoint string, body io.Reader, headers map[string]string) ([]byte, error) {
 	c.config.Logger.Printf("POST %s", endpoint)
@@ -166,7 +299,7 @@ func (c *Client) post(ctx context.Context, endpoint string, body io.Reader, head
 func (c *Client) doRequest(req *http.Request) ([]byte, error) {
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("%w: request failed: %w", ErrNetwork, err)
 	}
 
 	return readResponse(resp)
@@ -177,17 +310,26 @@ func (c *Client) url(endpoint string) string {
 	return fmt.Sprintf("%s/%s", c.config.BaseURL, endpoint)
 }
 
+// rootURL returns the URL of an endpoint at the root of the gateway, ignoring the path of the configured URL
+func (c *Client) rootURL(endpoint string) string {
+	u, err := url.Parse(c.config.BaseURL)
+	if err != nil {
+		return c.url(endpoint)
+	}
+	return fmt.Sprintf("%s://%s/%s", u.Scheme, u.Host, endpoint)
+}
+
 // readResponse reads and validates the HTTP response
 func readResponse(resp *http.Response) ([]byte, error) {
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("%w: failed to read response body: %w", ErrNetwork, err)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, body)
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	return body, nil
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -94,8 +95,8 @@ func TestLogin(t *testing.T) {
 	t.Run("Invalid Password", func(t *testing.T) {
 		client := setupCustomClient(t, srv.URL, "testuser", "invalidpassword")
 		err := client.Login(context.Background())
-		if err == nil {
-			t.Error("Expected error for invalid password")
+		if !errors.Is(err, ErrAuth) {
+			t.Errorf("Expected auth error for invalid password, got %v", err)
 		}
 	})
 }
@@ -125,4 +126,79 @@ func TestGetGateway(t *testing.T) {
 		}
 	})
 
+	// Expect a token the gateway no longer accepts to be replaced by logging in again.
+	t.Run("Rejected Token Login Again", func(t *testing.T) {
+		client := setupClient(t, srv.URL)
+		client.auth = &authToken{
+			Token:      "staletoken",
+			Expiration: time.Now().Add(15 * time.Minute).Unix(),
+		}
+		_, err := client.GetGateway(context.Background())
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		}
+		if client.auth.Token != "testtoken" {
+			t.Errorf("Expected auth token to be 'testtoken', got '%s'", client.auth.Token)
+		}
+	})
+
+	t.Run("Invalid Password", func(t *testing.T) {
+		client := setupCustomClient(t, srv.URL, "testuser", "invalidpassword")
+		_, err := client.GetGateway(context.Background())
+		if !errors.Is(err, ErrAuth) {
+			t.Errorf("Expected auth error, got %v", err)
+		}
+	})
+}
+
+func TestGetGatewayErrors(t *testing.T) {
+	respond := func(status int, body string) *httptest.Server {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/auth/login" {
+				json.NewEncoder(w).Encode(authResponse{Auth: authToken{Token: "testtoken", Expiration: time.Now().Add(time.Hour).Unix()}})
+				return
+			}
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}))
+		t.Cleanup(srv.Close)
+		return srv
+	}
+
+	unreachable := respond(http.StatusOK, "")
+	unreachable.Close()
+
+	tests := []struct {
+		name     string
+		url      string
+		expected error
+	}{
+		{"Network", unreachable.URL, ErrNetwork},
+		{"Status", respond(http.StatusServiceUnavailable, "busy").URL, ErrStatus},
+		{"Decode", respond(http.StatusOK, "{").URL, ErrDecode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := setupClient(t, tt.url)
+			_, err := client.GetGateway(context.Background())
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+			for _, other := range []error{ErrNetwork, ErrAuth, ErrStatus, ErrDecode} {
+				if other != tt.expected && errors.Is(err, other) {
+					t.Errorf("Expected error not to match %v, got %v", other, err)
+				}
+			}
+		})
+	}
+
+	t.Run("Status Code", func(t *testing.T) {
+		srv := respond(http.StatusServiceUnavailable, "busy")
+		_, err := setupClient(t, srv.URL).GetGateway(context.Background())
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("Expected status 503, got %v", err)
+		}
+	})
 }
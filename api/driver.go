@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"fmt"
+)
+
+// Driver names, set in ClientConfig.Driver
+const (
+	// DriverAuto probes the gateway for each known API the first time it is used
+	DriverAuto = "auto"
+	// DriverTMI speaks the TMI/v1 API of the Sercomm and Arcadyan gateways
+	DriverTMI = "tmi"
+	// DriverNokia speaks the web app API of the Nokia 5G21 gateway
+	DriverNokia = "nokia"
+)
+
+// driver speaks the API of one family of gateways and maps its responses onto GatewayResponse
+type driver interface {
+	name() string
+	// probe reports whether the gateway speaks this API, without logging in
+	probe(ctx context.Context, c *Client) (bool, error)
+	// login authenticates with the gateway, returning nil if the API does not use tokens
+	login(ctx context.Context, c *Client) (*authToken, error)
+	// getGateway fetches the status of the gateway, returning the body to record along with its mapping
+	getGateway(ctx context.Context, c *Client) ([]byte, GatewayResponse, error)
+	// getClients lists the devices connected to the gateway, failing with errors.ErrUnsupported if the API cannot
+	getClients(ctx context.Context, c *Client) (ClientsResponse, error)
+	// getCellTelemetry and getSIM fetch the extended telemetry, failing with errors.ErrUnsupported if the API cannot
+	getCellTelemetry(ctx context.Context, c *Client) (CellTelemetryResponse, error)
+	getSIM(ctx context.Context, c *Client) (SIMResponse, error)
+	// reboot, getAccessPoints and setAccessPoints administer the gateway, failing with errors.ErrUnsupported if the API cannot
+	reboot(ctx context.Context, c *Client) error
+	getAccessPoints(ctx context.Context, c *Client) (AccessPoints, error)
+	setAccessPoints(ctx context.Context, c *Client, accessPoints AccessPoints) error
+}
+
+// drivers are probed in order. The TMI probe accepts any gateway that has its endpoint, so it goes last.
+var drivers = []driver{nokiaDriver{}, tmiDriver{}}
+
+// IsDriver reports whether name is DriverAuto or the name of a known driver
+func IsDriver(name string) bool {
+	_, err := lookupDriver(name)
+	return err == nil
+}
+
+// lookupDriver returns the driver with the given name, or nil to probe for it
+func lookupDriver(name string) (driver, error) {
+	if name == "" || name == DriverAuto {
+		return nil, nil
+	}
+	for _, d := range drivers {
+		if d.name() == name {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown gateway driver %q", name)
+}
+
+// detect returns the driver of the gateway, probing each known API in turn the first time if none is configured
+func (c *Client) detect(ctx context.Context) (driver, error) {
+	if c.driver != nil {
+		return c.driver, nil
+	}
+
+	for _, d := range drivers {
+		ok, err := d.probe(ctx, c)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect gateway API: %w", err)
+		}
+		if ok {
+			c.config.Logger.Printf("Detected %s gateway API", d.name())
+			c.driver = d
+			return d, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: no known gateway API found at %s", ErrStatus, c.config.BaseURL)
+}
@@ -0,0 +1,329 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixtureServer serves recorded responses of one gateway variant from testdata by path and query, or by path alone,
+// anything else is not found
+func fixtureServer(t *testing.T, fixtures map[string]string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/TMI/v1/auth/login" && fixtures[r.URL.Path] != "" {
+			json.NewEncoder(w).Encode(authResponse{Auth: authToken{Token: "testtoken", Expiration: time.Now().Add(time.Hour).Unix()}})
+			return
+		}
+		name, ok := fixtures[r.URL.Path+"?"+r.URL.RawQuery]
+		if !ok {
+			name, ok = fixtures[r.URL.Path]
+		}
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Path != "/TMI/v1/auth/login" && strings.HasPrefix(r.URL.Path, "/TMI/v1/") && r.Header.Get("Authorization") != "Bearer testtoken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Errorf("Failed to read fixture: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func tmiFixtures(gateway string) map[string]string {
+	return map[string]string{"/TMI/v1/auth/login": "login", "/TMI/v1/gateway/": gateway}
+}
+
+func nokiaFixtures() map[string]string {
+	return map[string]string{
+		"/fastmile_radio_status_web_app.cgi":        "nokia_radio_status.json",
+		"/dashboard_device_info_status_web_app.cgi": "nokia_device_info.json",
+	}
+}
+
+func setupDriverClient(url, driver string) *Client {
+	return NewClientWithConfig(ClientConfig{
+		BaseURL:  url + "/TMI/v1",
+		Username: "testuser",
+		Password: "testpassword",
+		Logger:   log.New(io.Discard, "", 0),
+		Driver:   driver,
+	}, nil)
+}
+
+func TestDrivers(t *testing.T) {
+	tests := []struct {
+		name     string
+		fixtures map[string]string
+		driver   string
+		check    func(t *testing.T, gateway GatewayResponse)
+	}{
+		{"Sercomm", tmiFixtures("sercomm_gateway.json"), DriverTMI, func(t *testing.T, gateway GatewayResponse) {
+			if gateway.Device.Model != "TMO-G4SE" || gateway.Signal.FiveG.GNBID != 1234567 || len(gateway.Signal.FiveG.Bands) != 2 {
+				t.Errorf("Unexpected Sercomm response: %+v", gateway)
+			}
+		}},
+		{"Arcadyan", tmiFixtures("arcadyan_gateway.json"), DriverTMI, func(t *testing.T, gateway GatewayResponse) {
+			if gateway.Device.Manufacturer != "Arcadyan" || gateway.Signal.FourG.ENBID != 124802 || gateway.Time.UpTime != 3600 {
+				t.Errorf("Unexpected Arcadyan response: %+v", gateway)
+			}
+		}},
+		{"Nokia", nokiaFixtures(), DriverNokia, func(t *testing.T, gateway GatewayResponse) {
+			device := gateway.Device
+			if device.Manufacturer != "Nokia" || device.Model != "5G21" || device.Serial != "ALCLB1234567" || gateway.Time.UpTime != 172800 {
+				t.Errorf("Unexpected Nokia device: %+v %+v", device, gateway.Time)
+			}
+			fourG := gateway.Signal.FourG
+			if fourG.ENBID != 310463 || fourG.Cid != 12 || fourG.Rsrp != -101 || fourG.Sinr != 4 || len(fourG.Bands) != 2 || fourG.Bands[0] != "b66" {
+				t.Errorf("Unexpected Nokia 4G signal: %+v", fourG)
+			}
+			fiveG := gateway.Signal.FiveG
+			if fiveG.GNBID != 1234567 || fiveG.Cid != 101 || fiveG.Rsrq != -11 || len(fiveG.Bands) != 1 || fiveG.Bands[0] != "n41" {
+				t.Errorf("Unexpected Nokia 5G signal: %+v", fiveG)
+			}
+			generic := gateway.Signal.Generic
+			if generic.Apn != "fbb.home" || !generic.HasIPv6 || generic.Registration != "registered" {
+				t.Errorf("Unexpected Nokia generic block: %+v", generic)
+			}
+			if time.Since(time.Unix(int64(gateway.Time.LocalTime), 0)) > time.Minute {
+				t.Errorf("Expected the poller clock as the gateway time, got %d", gateway.Time.LocalTime)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, driver := range []string{DriverAuto, tt.driver} {
+				client := setupDriverClient(fixtureServer(t, tt.fixtures).URL, driver)
+				gateway, err := client.GetGateway(context.Background())
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if client.driver.name() != tt.driver {
+					t.Errorf("Expected the %s driver, got %s", tt.driver, client.driver.name())
+				}
+				tt.check(t, gateway)
+			}
+		})
+	}
+}
+
+func TestGetClients(t *testing.T) {
+	t.Run("TMI", func(t *testing.T) {
+		fixtures := tmiFixtures("sercomm_gateway.json")
+		fixtures["/TMI/v1/gateway/?get=clients"] = "sercomm_clients.json"
+		clients, err := setupDriverClient(fixtureServer(t, fixtures).URL, DriverAuto).GetClients(context.Background())
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if len(clients.Clients) != 3 || len(clients.Clients["5.0ghz"]) != 2 {
+			t.Fatalf("Unexpected clients: %+v", clients)
+		}
+		laptop := clients.Clients["5.0ghz"][0]
+		if !laptop.Connected || laptop.MAC != "1C:2B:3A:4D:5E:6F" || laptop.Signal != -52 || len(laptop.IPv6) != 2 {
+			t.Errorf("Unexpected laptop: %+v", laptop)
+		}
+	})
+
+	t.Run("Nokia Unsupported", func(t *testing.T) {
+		_, err := setupDriverClient(fixtureServer(t, nokiaFixtures()).URL, DriverAuto).GetClients(context.Background())
+		if !errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("Expected errors.ErrUnsupported, got %v", err)
+		}
+	})
+}
+
+func TestGetTelemetry(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("TMI", func(t *testing.T) {
+		fixtures := tmiFixtures("sercomm_gateway.json")
+		fixtures["/TMI/v1/network/telemetry/?get=cell"] = "sercomm_cell_telemetry.json"
+		fixtures["/TMI/v1/network/telemetry/?get=sim"] = "sercomm_sim.json"
+		client := setupDriverClient(fixtureServer(t, fixtures).URL, DriverAuto)
+
+		telemetry, err := client.GetCellTelemetry(ctx)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		fourG, fiveG := telemetry.Cell.FourG, telemetry.Cell.FiveG
+		if fourG.Pci != 218 || fourG.Arfcn() != 66786 || fourG.Bandwidth != "20M" || fourG.Tac != "11801" || fourG.Cqi != 9 {
+			t.Errorf("Unexpected 4G telemetry: %+v", fourG)
+		}
+		if fiveG.Pci != 403 || fiveG.Arfcn() != 520110 || fiveG.Bandwidth != "100M" || fiveG.Plmn != "310260" {
+			t.Errorf("Unexpected 5G telemetry: %+v", fiveG)
+		}
+
+		sim, err := client.GetSIM(ctx)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if sim.SIM.ICCID != "8901260123456789012" || sim.SIM.IMEI != "356789101234567" || !sim.SIM.Status {
+			t.Errorf("Unexpected SIM: %+v", sim.SIM)
+		}
+	})
+
+	t.Run("Older Firmware Unsupported", func(t *testing.T) {
+		client := setupDriverClient(fixtureServer(t, tmiFixtures("sercomm_gateway.json")).URL, DriverAuto)
+		_, err := client.GetCellTelemetry(ctx)
+		if !errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("Expected errors.ErrUnsupported, got %v", err)
+		}
+	})
+
+	t.Run("Nokia Unsupported", func(t *testing.T) {
+		client := setupDriverClient(fixtureServer(t, nokiaFixtures()).URL, DriverAuto)
+		_, err := client.GetCellTelemetry(ctx)
+		if !errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("Expected errors.ErrUnsupported, got %v", err)
+		}
+		_, err = client.GetSIM(ctx)
+		if !errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("Expected errors.ErrUnsupported, got %v", err)
+		}
+	})
+}
+
+func TestFlexInt(t *testing.T) {
+	tests := []struct {
+		json     string
+		expected FlexInt
+		ok       bool
+	}{
+		{`218`, 218, true},
+		{`"218"`, 218, true},
+		{`""`, 0, true},
+		{`null`, 0, true},
+		{`"20M"`, 0, false},
+	}
+
+	for _, tt := range tests {
+		var n FlexInt
+		err := json.Unmarshal([]byte(tt.json), &n)
+		if (err == nil) != tt.ok || n != tt.expected {
+			t.Errorf("Expected %s to decode to %d (ok %t), got %d (%v)", tt.json, tt.expected, tt.ok, n, err)
+		}
+	}
+}
+
+func TestDriverDetection(t *testing.T) {
+	t.Run("Unknown API", func(t *testing.T) {
+		client := setupDriverClient(fixtureServer(t, nil).URL, DriverAuto)
+		_, err := client.GetGateway(context.Background())
+		if !errors.Is(err, ErrStatus) {
+			t.Errorf("Expected ErrStatus, got %v", err)
+		}
+		if client.driver != nil {
+			t.Errorf("Expected no driver, got %s", client.driver.name())
+		}
+	})
+
+	t.Run("Unreachable", func(t *testing.T) {
+		srv := fixtureServer(t, nil)
+		srv.Close()
+		_, err := setupDriverClient(srv.URL, DriverAuto).GetGateway(context.Background())
+		if !errors.Is(err, ErrNetwork) {
+			t.Errorf("Expected ErrNetwork, got %v", err)
+		}
+	})
+
+	t.Run("Nokia Needs No Login", func(t *testing.T) {
+		logins := 0
+		client := setupDriverClient(fixtureServer(t, nokiaFixtures()).URL, DriverAuto)
+		client.config.OnLogin = func() { logins++ }
+		err := client.Login(context.Background())
+		if err != nil || logins != 0 || client.auth != nil {
+			t.Errorf("Expected no login, got %d logins (%v)", logins, err)
+		}
+	})
+
+	t.Run("Nokia Recording Replays", func(t *testing.T) {
+		dir := t.TempDir()
+		recorder, err := NewRecorder(dir, 0)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		client := setupDriverClient(fixtureServer(t, nokiaFixtures()).URL, DriverNokia)
+		client.config.Recorder = recorder
+		expected, err := client.GetGateway(context.Background())
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		recorder.Close()
+
+		replay, err := NewReplayClient(dir)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		defer replay.Close()
+		gateway, err := replay.GetGateway(context.Background())
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if gateway.Device.Serial != expected.Device.Serial || gateway.Signal.FiveG.GNBID != expected.Signal.FiveG.GNBID {
+			t.Errorf("Expected the raw responses to be mapped again, got %+v", gateway)
+		}
+
+		// The raw bodies are archived, not the mapping
+		archive, err := os.ReadFile(filepath.Join(dir, recordingFile))
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		var recording struct {
+			Body nokiaRecording `json:"body"`
+		}
+		err = json.Unmarshal(archive, &recording)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if !strings.Contains(string(recording.Body.Radio), "cell_5G_stats_cfg") || !strings.Contains(string(recording.Body.Device), "device_app_status") {
+			t.Errorf("Expected the raw status responses to be recorded, got %s", archive)
+		}
+	})
+
+	t.Run("Mapped Nokia Recording Replays", func(t *testing.T) {
+		// Archives written before the raw bodies were recorded hold the mapped response
+		path := filepath.Join(t.TempDir(), recordingFile)
+		err := os.WriteFile(path, []byte(`{"time":"2025-04-01T12:00:00Z","latencyMs":5,"body":{"device":{"serial":"NOKIA1"}}}`+"\n"), 0o644)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		replay, err := NewReplayClient(path)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		defer replay.Close()
+		gateway, err := replay.GetGateway(context.Background())
+		if err != nil || gateway.Device.Serial != "NOKIA1" {
+			t.Errorf("Expected the mapped response to be replayed as is, got %+v (%v)", gateway, err)
+		}
+	})
+
+	t.Run("Unknown Driver Probes", func(t *testing.T) {
+		if IsDriver("zte") || !IsDriver(DriverNokia) || !IsDriver("") {
+			t.Error("Unexpected IsDriver result")
+		}
+		client := setupDriverClient(fixtureServer(t, nokiaFixtures()).URL, "zte")
+		_, err := client.GetGateway(context.Background())
+		if err != nil || client.driver.name() != DriverNokia {
+			t.Errorf("Expected the Nokia API to be detected, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrNetwork, ErrAuth, ErrStatus and ErrDecode classify the errors returned by Client, match them with errors.Is
+var (
+	// ErrNetwork means the gateway could not be reached or the connection failed mid-request
+	ErrNetwork = errors.New("network error")
+	// ErrAuth means the gateway rejected the credentials or the auth token
+	ErrAuth = errors.New("authentication failed")
+	// ErrStatus means the gateway responded with an unexpected HTTP status
+	ErrStatus = errors.New("unexpected status")
+	// ErrDecode means the gateway response could not be decoded
+	ErrDecode = errors.New("invalid response")
+)
+
+// StatusError is returned for a non-2xx response, it matches ErrAuth for 401 and 403 and ErrStatus otherwise
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// Is matches the class of the status
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrAuth:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrStatus:
+		return e.StatusCode != http.StatusUnauthorized && e.StatusCode != http.StatusForbidden
+	}
+	return false
+}
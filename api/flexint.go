@@ -0,0 +1,38 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// FlexInt decodes an integer the gateway sends either as a JSON number or as a string, an empty string is 0.
+// Firmware versions disagree on which one the telemetry endpoints use.
+type FlexInt int
+
+func (n *FlexInt) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		err := json.Unmarshal(data, &s)
+		if err != nil {
+			return err
+		}
+		if s == "" {
+			*n = 0
+			return nil
+		}
+		data = []byte(s)
+	}
+
+	value, err := strconv.Atoi(string(data))
+	if err != nil {
+		return fmt.Errorf("invalid integer %s: %w", data, err)
+	}
+	*n = FlexInt(value)
+	return nil
+}
@@ -60,6 +60,66 @@ type GatewayResponse struct {
 	Time   Time   `json:"time"`
 }
 
+// ConnectedClient is a device on the LAN or Wi-Fi of the gateway
+type ConnectedClient struct {
+	Connected bool     `json:"connected"`
+	IPv4      string   `json:"ipv4"`
+	IPv6      []string `json:"ipv6"`
+	MAC       string   `json:"mac"`
+	Name      string   `json:"name"`
+	Signal    int      `json:"signal"` // Only set for Wi-Fi clients
+}
+
+// ClientsResponse lists the clients of the gateway by connection, "2.4ghz", "5.0ghz" or "ethernet"
+type ClientsResponse struct {
+	Clients map[string][]ConnectedClient `json:"clients"`
+}
+
+// CellTelemetry is the radio channel of the primary carrier of one generation
+type CellTelemetry struct {
+	Bandwidth string  `json:"bandwidth"` // "20M", "100M", etc.
+	Cqi       FlexInt `json:"cqi"`
+	Ecgi      string  `json:"ecgi"`    // Cell global identity, the NCGI for 5G
+	Earfcn    FlexInt `json:"earfcn"`  // Only set for 4G
+	NRArfcn   FlexInt `json:"nrarfcn"` // Only set for 5G
+	Mcc       string  `json:"mcc"`
+	Mnc       string  `json:"mnc"`
+	Pci       FlexInt `json:"pci"`
+	Plmn      string  `json:"plmn"`
+	Tac       string  `json:"tac"`
+	Status    bool    `json:"status"`
+}
+
+// Arfcn is the EARFCN of a 4G carrier or the NR-ARFCN of a 5G carrier
+func (t CellTelemetry) Arfcn() int {
+	if t.NRArfcn != 0 {
+		return int(t.NRArfcn)
+	}
+	return int(t.Earfcn)
+}
+
+// CellTelemetryResponse is the response of network/telemetry/?get=cell
+type CellTelemetryResponse struct {
+	Cell struct {
+		FourG CellTelemetry `json:"4g"`
+		FiveG CellTelemetry `json:"5g"`
+	} `json:"cell"`
+}
+
+// SIM identifies the SIM card and modem of the gateway
+type SIM struct {
+	ICCID  string `json:"iccId"`
+	IMEI   string `json:"imei"`
+	IMSI   string `json:"imsi"`
+	MSISDN string `json:"msisdn"`
+	Status bool   `json:"status"`
+}
+
+// SIMResponse is the response of network/telemetry/?get=sim
+type SIMResponse struct {
+	SIM SIM `json:"sim"`
+}
+
 type authToken struct {
 	Expiration       int64  `json:"expiration"`
 	RefreshCountLeft int8   `json:"refreshCountLeft"`
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Web app endpoints of the Nokia 5G21, served from the root of the gateway without a login
+const (
+	nokiaRadioEndpoint  = "fastmile_radio_status_web_app.cgi"
+	nokiaDeviceEndpoint = "dashboard_device_info_status_web_app.cgi"
+)
+
+// nokiaConnected is the connection status the web app shows as connected to the network
+const nokiaConnected = 7
+
+// nokiaGNBIDBits is the length of the gNB ID in the NR cell identity, T-Mobile uses 24 bits leaving 12 for the cell
+const nokiaGNBIDBits = 24
+
+// nokiaDriver speaks the web app API of the Nokia 5G21. The radio and device status it needs are readable without
+// a login, at the root of the gateway whatever the path of the configured URL.
+type nokiaDriver struct{}
+
+// nokiaCell is one carrier of the radio status, the first of each generation is the primary carrier
+type nokiaCell struct {
+	Stat struct {
+		Band string `json:"Band"`
+		Rsrp int    `json:"RSRPCurrent"`
+		Rsrq int    `json:"RSRQCurrent"`
+		Rssi int    `json:"RSSICurrent"`
+		Snr  int    `json:"SNRCurrent"`
+		// CellID is the 28 bit E-UTRAN cell identity of 4G carriers and the 36 bit NR cell identity of 5G carriers
+		CellID int64 `json:"CellID"`
+	} `json:"stat"`
+}
+
+// nokiaRadioStatus is the part of the radio status response mapped onto GatewayResponse
+type nokiaRadioStatus struct {
+	LTE []nokiaCell `json:"cell_LTE_stats_cfg"`
+	NR  []nokiaCell `json:"cell_5G_stats_cfg"`
+	APN []struct {
+		APN  string `json:"APN"`
+		IPv6 string `json:"X_ALU_COM_IPAddressV6"`
+	} `json:"apn_cfg"`
+	ConnectionStatus []struct {
+		ConnectionStatus int `json:"ConnectionStatus"`
+	} `json:"connection_status"`
+}
+
+// nokiaDeviceInfo is the part of the device status response mapped onto GatewayResponse
+type nokiaDeviceInfo struct {
+	Status []struct {
+		Description     string `json:"Description"`
+		HardwareVersion string `json:"HardwareVersion"`
+		MACAddress      string `json:"MACAddress"`
+		Manufacturer    string `json:"Manufacturer"`
+		ManufacturerOUI string `json:"ManufacturerOUI"`
+		ModelName       string `json:"ModelName"`
+		SerialNumber    string `json:"SerialNumber"`
+		SoftwareVersion string `json:"SoftwareVersion"`
+		UpTime          int    `json:"UpTime"`
+	} `json:"device_app_status"`
+}
+
+func (nokiaDriver) name() string {
+	return DriverNokia
+}
+
+// probe accepts a gateway whose radio status has 4G or 5G cell statistics
+func (nokiaDriver) probe(ctx context.Context, c *Client) (bool, error) {
+	body, err := c.getPublic(ctx, c.rootURL(nokiaRadioEndpoint))
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var status map[string]json.RawMessage
+	if json.Unmarshal(body, &status) != nil {
+		return false, nil
+	}
+	_, lte := status["cell_LTE_stats_cfg"]
+	_, nr := status["cell_5G_stats_cfg"]
+	return lte || nr, nil
+}
+
+// login does nothing, the status endpoints need no token
+func (nokiaDriver) login(ctx context.Context, c *Client) (*authToken, error) {
+	return nil, nil
+}
+
+// getGateway fetches the radio and device status and maps them onto GatewayResponse. As the status is spread over
+// two responses, both raw bodies are recorded together, so a replay maps them again with the mapping of its build.
+func (nokiaDriver) getGateway(ctx context.Context, c *Client) ([]byte, GatewayResponse, error) {
+	var gateway GatewayResponse
+
+	radio, err := c.getPublic(ctx, c.rootURL(nokiaRadioEndpoint))
+	if err != nil {
+		return nil, gateway, fmt.Errorf("failed to get radio status: %w", err)
+	}
+
+	device, err := c.getPublic(ctx, c.rootURL(nokiaDeviceEndpoint))
+	if err != nil {
+		return nil, gateway, fmt.Errorf("failed to get device status: %w", err)
+	}
+
+	body, err := json.Marshal(nokiaRecording{Radio: rawJSON(radio), Device: rawJSON(device)})
+	if err != nil {
+		return nil, gateway, fmt.Errorf("failed to marshal recording: %w", err)
+	}
+
+	gateway, err = decodeNokia(radio, device, time.Now())
+	return body, gateway, err
+}
+
+// getClients is not supported, the web app lists clients only after a login
+func (nokiaDriver) getClients(ctx context.Context, c *Client) (ClientsResponse, error) {
+	return ClientsResponse{}, fmt.Errorf("%w: the nokia driver does not list clients", errors.ErrUnsupported)
+}
+
+// getCellTelemetry is not supported, the channels are not mapped from the radio status
+func (nokiaDriver) getCellTelemetry(ctx context.Context, c *Client) (CellTelemetryResponse, error) {
+	return CellTelemetryResponse{}, fmt.Errorf("%w: the nokia driver does not fetch cell telemetry", errors.ErrUnsupported)
+}
+
+// getSIM is not supported, the web app shows the SIM only after a login
+func (nokiaDriver) getSIM(ctx context.Context, c *Client) (SIMResponse, error) {
+	return SIMResponse{}, fmt.Errorf("%w: the nokia driver does not fetch SIM telemetry", errors.ErrUnsupported)
+}
+
+// reboot is not supported, the web app needs a login to reboot
+func (nokiaDriver) reboot(ctx context.Context, c *Client) error {
+	return fmt.Errorf("%w: the nokia driver cannot reboot the gateway", errors.ErrUnsupported)
+}
+
+// getAccessPoints is not supported, the web app needs a login to show the Wi-Fi configuration
+func (nokiaDriver) getAccessPoints(ctx context.Context, c *Client) (AccessPoints, error) {
+	return AccessPoints{}, fmt.Errorf("%w: the nokia driver cannot read the Wi-Fi configuration", errors.ErrUnsupported)
+}
+
+// setAccessPoints is not supported, the web app needs a login to change the Wi-Fi configuration
+func (nokiaDriver) setAccessPoints(ctx context.Context, c *Client, accessPoints AccessPoints) error {
+	return fmt.Errorf("%w: the nokia driver cannot change the Wi-Fi configuration", errors.ErrUnsupported)
+}
+
+// nokiaRecording is the body recorded for one poll of a Nokia gateway, holding its raw radio and device status
+type nokiaRecording struct {
+	Radio  json.RawMessage `json:"nokia_radio_status"`
+	Device json.RawMessage `json:"nokia_device_info"`
+}
+
+// rawJSON returns a body as is if it is JSON, or as a JSON string otherwise, so nothing the gateway sends is lost
+func rawJSON(body []byte) json.RawMessage {
+	if json.Valid(body) {
+		return body
+	}
+	raw, _ := json.Marshal(string(body))
+	return raw
+}
+
+// decodeNokia decodes the radio and device status responses and maps them onto GatewayResponse, taken at now
+func decodeNokia(radioBody, deviceBody []byte, now time.Time) (GatewayResponse, error) {
+	var radio nokiaRadioStatus
+	err := json.Unmarshal(radioBody, &radio)
+	if err != nil {
+		return GatewayResponse{}, fmt.Errorf("%w: failed to unmarshal radio status: %w", ErrDecode, err)
+	}
+
+	var device nokiaDeviceInfo
+	err = json.Unmarshal(deviceBody, &device)
+	if err != nil {
+		return GatewayResponse{}, fmt.Errorf("%w: failed to unmarshal device status: %w", ErrDecode, err)
+	}
+
+	return mapNokia(radio, device, now), nil
+}
+
+// mapNokia builds a GatewayResponse from the Nokia status responses. The gateway reports no clock, bars or roaming
+// state, so the time is the poller clock at now and bars and roaming are left unset.
+func mapNokia(radio nokiaRadioStatus, device nokiaDeviceInfo, now time.Time) GatewayResponse {
+	var gateway GatewayResponse
+
+	if len(device.Status) > 0 {
+		d := device.Status[0]
+		gateway.Device = Device{
+			FriendlyName:    d.Description,
+			HardwareVersion: d.HardwareVersion,
+			IsEnabled:       true,
+			MacID:           d.MACAddress,
+			Manufacturer:    d.Manufacturer,
+			ManufacturerOUI: d.ManufacturerOUI,
+			Model:           d.ModelName,
+			Name:            d.Description,
+			Role:            "gateway",
+			Serial:          d.SerialNumber,
+			SoftwareVersion: d.SoftwareVersion,
+		}
+		gateway.Time.UpTime = d.UpTime
+	}
+	gateway.Time.LocalTime = int(now.Unix())
+	gateway.Time.LocalTimeZone = now.Location().String()
+
+	gateway.Signal.FourG = mapNokiaCells(radio.LTE, func(id int64) (int, int, int) {
+		return int(id >> 8), 0, int(id & 0xff)
+	})
+	gateway.Signal.FiveG = mapNokiaCells(radio.NR, func(id int64) (int, int, int) {
+		return 0, int(id >> (36 - nokiaGNBIDBits)), int(id & (1<<(36-nokiaGNBIDBits) - 1))
+	})
+
+	if len(radio.APN) > 0 {
+		gateway.Signal.Generic.Apn = radio.APN[0].APN
+		gateway.Signal.Generic.HasIPv6 = radio.APN[0].IPv6 != ""
+	}
+	if len(radio.ConnectionStatus) > 0 {
+		status := radio.ConnectionStatus[0].ConnectionStatus
+		gateway.Signal.Generic.Registration = "registered"
+		if status != nokiaConnected {
+			gateway.Signal.Generic.Registration = fmt.Sprintf("connection status %d", status)
+		}
+	}
+
+	return gateway
+}
+
+// mapNokiaCells maps the carriers of one generation, splitting the cell identity of the primary carrier
+// into its eNB ID, gNB ID and cell ID
+func mapNokiaCells(cells []nokiaCell, split func(id int64) (enbid, gnbid, cid int)) SignalStats {
+	var stats SignalStats
+	if len(cells) == 0 {
+		return stats
+	}
+
+	primary := cells[0].Stat
+	stats.ENBID, stats.GNBID, stats.Cid = split(primary.CellID)
+	stats.Rsrp = primary.Rsrp
+	stats.Rsrq = primary.Rsrq
+	stats.Rssi = primary.Rssi
+	stats.Sinr = primary.Snr
+
+	// The web app names 4G bands B66 where the TMI/v1 API names them b66
+	for _, cell := range cells {
+		if cell.Stat.Band != "" {
+			stats.Bands = append(stats.Bands, strings.ToLower(cell.Stat.Band))
+		}
+	}
+
+	return stats
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// recordingFile is the name of the archive file currently being written
+	recordingFile = "gateway.jsonl"
+	// rotatedFormat names archive files once they are rotated, they sort in the order they were written
+	rotatedFormat = "gateway-20060102T150405.000.jsonl"
+)
+
+// Recording is one raw gateway response as written to the archive
+type Recording struct {
+	Time      time.Time       `json:"time"`
+	LatencyMs int64           `json:"latencyMs"`
+	Body      json.RawMessage `json:"body"`
+}
+
+// Recorder appends raw gateway responses to a JSONL archive, rotating the file when it grows past a size limit
+type Recorder struct {
+	dir      string
+	maxBytes int64
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewRecorder creates a Recorder writing to dir, rotating after maxBytes. A maxBytes of 0 never rotates.
+func NewRecorder(dir string, maxBytes int64) (*Recorder, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+
+	r := &Recorder{
+		dir:      dir,
+		maxBytes: maxBytes,
+	}
+
+	err = r.open()
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Record appends a raw response body to the archive
+func (r *Recorder) Record(at time.Time, latency time.Duration, body []byte) error {
+	// Keep bodies that are not JSON, as a string, so nothing the gateway sends is lost
+	raw := json.RawMessage(body)
+	if !json.Valid(body) {
+		raw, _ = json.Marshal(string(body))
+	}
+
+	line, err := json.Marshal(Recording{
+		Time:      at,
+		LatencyMs: latency.Milliseconds(),
+		Body:      raw,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal recording: %w", err)
+	}
+	line = append(line, '\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.maxBytes > 0 && r.size > 0 && r.size+int64(len(line)) > r.maxBytes {
+		err = r.rotate()
+		if err != nil {
+			return err
+		}
+	}
+
+	n, err := r.file.Write(line)
+	r.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the archive file
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// open opens the current archive file for appending
+func (r *Recorder) open() error {
+	file, err := os.OpenFile(filepath.Join(r.dir, recordingFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open recording file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat recording file: %w", err)
+	}
+
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// rotate renames the current archive file and starts a new one
+func (r *Recorder) rotate() error {
+	err := r.file.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close recording file: %w", err)
+	}
+
+	rotated, err := r.reserve(time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	err = os.Rename(filepath.Join(r.dir, recordingFile), rotated)
+	if err != nil {
+		return fmt.Errorf("failed to rotate recording file: %w", err)
+	}
+
+	return r.open()
+}
+
+// reserve creates an empty file under the first rotated name from at that is not taken yet. Rotations within the
+// same millisecond move on to the following milliseconds, so no archive file is overwritten and they keep their order.
+func (r *Recorder) reserve(at time.Time) (string, error) {
+	for {
+		name := filepath.Join(r.dir, at.Format(rotatedFormat))
+		file, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if os.IsExist(err) {
+			at = at.Add(time.Millisecond)
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to create rotated recording file: %w", err)
+		}
+		return name, file.Close()
+	}
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordReplay(t *testing.T) {
+	srv := setupServer(time.Now().Add(15 * time.Minute))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	recorder, err := NewRecorder(dir, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer recorder.Close()
+
+	client := setupClient(t, srv.URL)
+	client.config.Recorder = recorder
+
+	for range 3 {
+		_, err := client.GetGateway(context.Background())
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+
+	replay, err := NewReplayClient(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer replay.Close()
+
+	for i := range 3 {
+		_, err := replay.GetGateway(context.Background())
+		if err != nil {
+			t.Fatalf("Unexpected error replaying recording %d: %s", i, err)
+		}
+	}
+
+	_, err = replay.GetGateway(context.Background())
+	if !errors.Is(err, ErrReplayDone) {
+		t.Errorf("Expected ErrReplayDone, got %v", err)
+	}
+}
+
+func TestRecorderRotation(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := NewRecorder(dir, 100)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer recorder.Close()
+
+	// Rotated file names have millisecond resolution, so back to back rotations share a millisecond
+	var bodies []string
+	for i := range 5 {
+		bodies = append(bodies, fmt.Sprintf(`{"time":{"upTime":%d}}`, i+1))
+	}
+	for _, body := range bodies {
+		err := recorder.Record(time.Now(), time.Millisecond, []byte(body))
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+
+	rotated, _ := filepath.Glob(filepath.Join(dir, "gateway-*.jsonl"))
+	if len(rotated) != 4 {
+		t.Errorf("Expected 4 rotated files, got %d", len(rotated))
+	}
+
+	replay, err := NewReplayClient(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer replay.Close()
+
+	for i := range bodies {
+		gateway, err := replay.GetGateway(context.Background())
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if gateway.Time.UpTime != i+1 {
+			t.Errorf("Expected recordings in the order written, got uptime %d at %d", gateway.Time.UpTime, i)
+		}
+	}
+}
+
+func TestRecorderInvalidBody(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := NewRecorder(dir, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer recorder.Close()
+
+	err = recorder.Record(time.Now(), time.Millisecond, []byte("<html>not json</html>"))
+	if err != nil {
+		t.Fatalf("Expected a body that is not JSON to be recorded, got %s", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, recordingFile))
+	if len(data) == 0 {
+		t.Fatal("Expected recording to be written")
+	}
+
+	replay, err := NewReplayClient(filepath.Join(dir, recordingFile))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer replay.Close()
+
+	_, err = replay.GetGateway(context.Background())
+	if !errors.Is(err, ErrDecode) {
+		t.Errorf("Expected unmarshal error, got %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ErrReplayDone is returned by ReplayClient.GetGateway once every recording has been replayed
+var ErrReplayDone = errors.New("replay done")
+
+// ReplayClient implements IClient by reading back the archive written by a Recorder
+type ReplayClient struct {
+	files   []string
+	file    *os.File
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewReplayClient creates a client replaying a single archive file, or every archive file in a directory
+// in the order they were written
+func NewReplayClient(path string) (*ReplayClient, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replay: %w", err)
+	}
+
+	if !info.IsDir() {
+		return &ReplayClient{files: []string{path}}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(path, "gateway-*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	current := filepath.Join(path, recordingFile)
+	if _, err := os.Stat(current); err == nil {
+		files = append(files, current)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no recordings found in %s", path)
+	}
+
+	return &ReplayClient{files: files}, nil
+}
+
+// Login does nothing, recordings do not need authentication
+func (c *ReplayClient) Login(ctx context.Context) error {
+	return nil
+}
+
+// GetGateway returns the next recorded gateway response, or ErrReplayDone after the last one. A recording that cannot
+// be decoded fails with ErrDecode, the following call moves on to the next one.
+func (c *ReplayClient) GetGateway(ctx context.Context) (GatewayResponse, error) {
+	recording, err := c.next()
+	if err != nil {
+		return GatewayResponse{}, err
+	}
+
+	gateway, err := recording.Gateway()
+	if err != nil {
+		return gateway, fmt.Errorf("%w: failed to unmarshal recording %s:%d: %w", ErrDecode, c.file.Name(), c.line, err)
+	}
+
+	return gateway, nil
+}
+
+// Gateway maps the recorded body onto GatewayResponse. Nokia recordings hold the raw status responses, which are
+// mapped again with the recording time as the poller clock, any other body is a gateway response as is.
+func (r Recording) Gateway() (GatewayResponse, error) {
+	var nokia nokiaRecording
+	if json.Unmarshal(r.Body, &nokia) == nil && nokia.Radio != nil {
+		return decodeNokia(nokia.Radio, nokia.Device, r.Time)
+	}
+
+	var gateway GatewayResponse
+	err := json.Unmarshal(r.Body, &gateway)
+	return gateway, err
+}
+
+// GetClients is not supported, recordings only hold gateway responses
+func (c *ReplayClient) GetClients(ctx context.Context) (ClientsResponse, error) {
+	return ClientsResponse{}, fmt.Errorf("%w: recordings do not hold clients", errors.ErrUnsupported)
+}
+
+// GetCellTelemetry is not supported, recordings only hold gateway responses
+func (c *ReplayClient) GetCellTelemetry(ctx context.Context) (CellTelemetryResponse, error) {
+	return CellTelemetryResponse{}, fmt.Errorf("%w: recordings do not hold cell telemetry", errors.ErrUnsupported)
+}
+
+// Reboot is not supported, there is no gateway behind a recording
+func (c *ReplayClient) Reboot(ctx context.Context) error {
+	return fmt.Errorf("%w: recordings cannot be rebooted", errors.ErrUnsupported)
+}
+
+// Close closes the archive file being replayed
+func (c *ReplayClient) Close() error {
+	if c.scanner == nil {
+		return nil
+	}
+	return c.file.Close()
+}
+
+// next reads the next recording, moving on to the next archive file at the end of each file
+func (c *ReplayClient) next() (Recording, error) {
+	var recording Recording
+
+	for {
+		if c.scanner == nil {
+			if len(c.files) == 0 {
+				return recording, ErrReplayDone
+			}
+
+			file, err := os.Open(c.files[0])
+			if err != nil {
+				return recording, fmt.Errorf("failed to open recording: %w", err)
+			}
+			c.files = c.files[1:]
+			c.file = file
+			c.line = 0
+			c.scanner = bufio.NewScanner(file)
+			c.scanner.Buffer(nil, 1024*1024)
+		}
+
+		if c.scanner.Scan() {
+			c.line++
+			err := json.Unmarshal(c.scanner.Bytes(), &recording)
+			if err != nil {
+				return recording, fmt.Errorf("%w: failed to read recording %s:%d: %w", ErrDecode, c.file.Name(), c.line, err)
+			}
+			return recording, nil
+		}
+
+		err := c.scanner.Err()
+		c.file.Close()
+		c.scanner = nil
+		if err != nil {
+			return recording, fmt.Errorf("failed to read recording %s: %w", c.file.Name(), err)
+		}
+	}
+}
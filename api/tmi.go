@@ -0,0 +1,187 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const (
+	// tmiGatewayEndpoint returns the device, signal and time blocks of the gateway in a single response
+	tmiGatewayEndpoint = "gateway/?get=all"
+	// tmiClientsEndpoint lists the LAN and Wi-Fi clients of the gateway
+	tmiClientsEndpoint = "gateway/?get=clients"
+	// tmiCellEndpoint and tmiSIMEndpoint return the extended telemetry, which older firmware does not have
+	tmiCellEndpoint = "network/telemetry/?get=cell"
+	tmiSIMEndpoint  = "network/telemetry/?get=sim"
+	// tmiRebootEndpoint restarts the gateway when posted to
+	tmiRebootEndpoint = "gateway/reset?set=reboot"
+	// tmiGetAPEndpoint and tmiSetAPEndpoint read and replace the Wi-Fi configuration
+	tmiGetAPEndpoint = "network/configuration/v2?get=ap"
+	tmiSetAPEndpoint = "network/configuration/v2?set=ap"
+)
+
+// tmiDriver speaks the TMI/v1 API served under the configured URL by the Sercomm TMO-G4SE and TMO-G4AR and the
+// Arcadyan KVD21 and TMO-G5AR. Their gateway responses share one shape, which GatewayResponse decodes as is.
+type tmiDriver struct{}
+
+func (tmiDriver) name() string {
+	return DriverTMI
+}
+
+// probe accepts any gateway that has the gateway endpoint, whether or not it needs a token for it
+func (tmiDriver) probe(ctx context.Context, c *Client) (bool, error) {
+	_, err := c.getPublic(ctx, c.url(tmiGatewayEndpoint))
+	var statusErr *StatusError
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, &statusErr):
+		return statusErr.StatusCode != http.StatusNotFound, nil
+	default:
+		return false, err
+	}
+}
+
+// login posts the credentials to auth/login for a bearer token
+func (tmiDriver) login(ctx context.Context, c *Client) (*authToken, error) {
+	loginBody, err := json.Marshal(struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}{
+		Username: c.config.Username,
+		Password: c.config.Password,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal login body: %w", err)
+	}
+
+	respBody, err := c.post(ctx, "auth/login", bytes.NewBuffer(loginBody), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var authResponse authResponse
+	err = json.Unmarshal(respBody, &authResponse)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unexpected login response: %w", ErrDecode, err)
+	}
+
+	return &authResponse.Auth, nil
+}
+
+// getGateway fetches the status of the gateway, recording the raw response
+func (tmiDriver) getGateway(ctx context.Context, c *Client) ([]byte, GatewayResponse, error) {
+	var gateway GatewayResponse
+
+	body, err := c.get(ctx, tmiGatewayEndpoint)
+	if err != nil {
+		return nil, gateway, fmt.Errorf("failed to get gateway info: %w", err)
+	}
+
+	err = json.Unmarshal(body, &gateway)
+	if err != nil {
+		return body, gateway, fmt.Errorf("%w: failed to unmarshal gateway response: %w", ErrDecode, err)
+	}
+
+	return body, gateway, nil
+}
+
+// getClients lists the devices connected to the gateway
+func (tmiDriver) getClients(ctx context.Context, c *Client) (ClientsResponse, error) {
+	var clients ClientsResponse
+
+	body, err := c.get(ctx, tmiClientsEndpoint)
+	if err != nil {
+		return clients, fmt.Errorf("failed to get clients: %w", err)
+	}
+
+	err = json.Unmarshal(body, &clients)
+	if err != nil {
+		return clients, fmt.Errorf("%w: failed to unmarshal clients response: %w", ErrDecode, err)
+	}
+
+	return clients, nil
+}
+
+// getCellTelemetry fetches the radio channels of the serving cells
+func (tmiDriver) getCellTelemetry(ctx context.Context, c *Client) (CellTelemetryResponse, error) {
+	var telemetry CellTelemetryResponse
+	err := getTelemetry(ctx, c, tmiCellEndpoint, &telemetry)
+	if err != nil {
+		return telemetry, fmt.Errorf("failed to get cell telemetry: %w", err)
+	}
+	return telemetry, nil
+}
+
+// getSIM fetches the identity of the SIM card and modem
+func (tmiDriver) getSIM(ctx context.Context, c *Client) (SIMResponse, error) {
+	var sim SIMResponse
+	err := getTelemetry(ctx, c, tmiSIMEndpoint, &sim)
+	if err != nil {
+		return sim, fmt.Errorf("failed to get SIM telemetry: %w", err)
+	}
+	return sim, nil
+}
+
+// getTelemetry decodes a telemetry endpoint into v. Firmware without the endpoint answers 404,
+// which is reported as errors.ErrUnsupported.
+func getTelemetry(ctx context.Context, c *Client, endpoint string, v any) error {
+	body, err := c.get(ctx, endpoint)
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %w", errors.ErrUnsupported, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(body, v)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrDecode, err)
+	}
+	return nil
+}
+
+// reboot posts to the reset endpoint
+func (tmiDriver) reboot(ctx context.Context, c *Client) error {
+	_, err := c.postAuthenticated(ctx, tmiRebootEndpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to reboot: %w", err)
+	}
+	return nil
+}
+
+// getAccessPoints fetches the Wi-Fi configuration
+func (tmiDriver) getAccessPoints(ctx context.Context, c *Client) (AccessPoints, error) {
+	var accessPoints AccessPoints
+
+	body, err := c.get(ctx, tmiGetAPEndpoint)
+	if err != nil {
+		return accessPoints, fmt.Errorf("failed to get Wi-Fi configuration: %w", err)
+	}
+
+	err = json.Unmarshal(body, &accessPoints)
+	if err != nil {
+		return accessPoints, fmt.Errorf("%w: failed to unmarshal Wi-Fi configuration: %w", ErrDecode, err)
+	}
+
+	return accessPoints, nil
+}
+
+// setAccessPoints posts the whole Wi-Fi configuration
+func (tmiDriver) setAccessPoints(ctx context.Context, c *Client, accessPoints AccessPoints) error {
+	body, err := json.Marshal(accessPoints)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Wi-Fi configuration: %w", err)
+	}
+
+	_, err = c.postAuthenticated(ctx, tmiSetAPEndpoint, body)
+	if err != nil {
+		return fmt.Errorf("failed to set Wi-Fi configuration: %w", err)
+	}
+	return nil
+}
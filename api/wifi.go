@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Wi-Fi bands, the keys of the radios in AccessPoints
+const (
+	Band24GHz = "2.4ghz"
+	Band5GHz  = "5.0ghz"
+)
+
+// Radio is the configuration of one Wi-Fi radio
+type Radio struct {
+	AirtimeFairness   bool   `json:"airtimeFairness"`
+	Channel           string `json:"channel"`          // "Auto" or a channel number
+	ChannelBandwidth  string `json:"channelBandwidth"` // "Auto", "20MHz", etc.
+	IsMUMIMOEnabled   bool   `json:"isMUMIMOEnabled"`
+	IsRadioEnabled    bool   `json:"isRadioEnabled"`
+	IsWMMEnabled      bool   `json:"isWMMEnabled"`
+	MaxClients        int    `json:"maxClients"`
+	Mode              string `json:"mode"`
+	TransmissionPower string `json:"transmissionPower"` // "100%", etc.
+}
+
+// SSID is one network broadcast by the radios it is enabled on
+type SSID struct {
+	TwoFourGHz         bool   `json:"2.4ghzSsid"`
+	FiveGHz            bool   `json:"5.0ghzSsid"`
+	EncryptionMode     string `json:"encryptionMode"`    // "AES"
+	EncryptionVersion  string `json:"encryptionVersion"` // "WPA2/WPA3", etc.
+	Guest              bool   `json:"guest"`
+	IsBroadcastEnabled bool   `json:"isBroadcastEnabled"`
+	Name               string `json:"ssidName"`
+	Key                string `json:"wpaKey"`
+}
+
+// AccessPoints is the Wi-Fi configuration of the gateway. The gateway replaces its whole configuration on update, so
+// the fields it sends that are not modeled here are kept from the response they were read from.
+type AccessPoints struct {
+	TwoFourGHz   Radio `json:"2.4ghz"`
+	FiveGHz      Radio `json:"5.0ghz"`
+	BandSteering struct {
+		IsEnabled bool `json:"isEnabled"`
+	} `json:"bandSteering"`
+	SSIDs []SSID `json:"ssids"`
+
+	// raw is the response the configuration was decoded from
+	raw json.RawMessage
+}
+
+// accessPoints has the fields of AccessPoints without its JSON methods
+type accessPoints AccessPoints
+
+// Radio returns the radio of the given band, or nil for an unknown band
+func (a *AccessPoints) Radio(band string) *Radio {
+	switch band {
+	case Band24GHz:
+		return &a.TwoFourGHz
+	case Band5GHz:
+		return &a.FiveGHz
+	default:
+		return nil
+	}
+}
+
+// SSID returns the SSID with the given name, or nil if there is none
+func (a *AccessPoints) SSID(name string) *SSID {
+	for i := range a.SSIDs {
+		if a.SSIDs[i].Name == name {
+			return &a.SSIDs[i]
+		}
+	}
+	return nil
+}
+
+func (a *AccessPoints) UnmarshalJSON(data []byte) error {
+	err := json.Unmarshal(data, (*accessPoints)(a))
+	if err != nil {
+		return err
+	}
+	a.raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// MarshalJSON overlays the modeled fields on the response the configuration was read from, if any
+func (a AccessPoints) MarshalJSON() ([]byte, error) {
+	modeled, err := json.Marshal(accessPoints(a))
+	if err != nil || a.raw == nil {
+		return modeled, err
+	}
+
+	var base, overlay any
+	err = json.Unmarshal(a.raw, &base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the original configuration: %w", err)
+	}
+	err = json.Unmarshal(modeled, &overlay)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergeJSON(base, overlay))
+}
+
+// mergeJSON overlays decoded JSON values, merging objects by key and arrays by index. The overlay decides the
+// length of arrays and wins for every other value.
+func mergeJSON(base, overlay any) any {
+	switch overlay := overlay.(type) {
+	case map[string]any:
+		merged, ok := base.(map[string]any)
+		if !ok {
+			return overlay
+		}
+		for key, value := range overlay {
+			merged[key] = mergeJSON(merged[key], value)
+		}
+		return merged
+	case []any:
+		original, _ := base.([]any)
+		for i := range overlay {
+			if i < len(original) {
+				overlay[i] = mergeJSON(original[i], overlay[i])
+			}
+		}
+		return overlay
+	default:
+		return overlay
+	}
+}
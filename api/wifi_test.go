@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAccessPoints(t *testing.T) {
+	body, err := os.ReadFile(filepath.Join("testdata", "sercomm_access_points.json"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var accessPoints AccessPoints
+	err = json.Unmarshal(body, &accessPoints)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if accessPoints.Radio(Band5GHz).ChannelBandwidth != "80MHz" || accessPoints.SSID("Home") == nil || accessPoints.Radio("6ghz") != nil {
+		t.Fatalf("Unexpected access points: %+v", accessPoints)
+	}
+
+	accessPoints.Radio(Band24GHz).IsRadioEnabled = false
+	accessPoints.SSID("Home").Key = "batterystaple"
+
+	t.Run("Keeps Unmodeled Fields", func(t *testing.T) {
+		encoded, err := json.Marshal(accessPoints)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		var decoded map[string]any
+		err = json.Unmarshal(encoded, &decoded)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		fiveG := decoded["5.0ghz"].(map[string]any)
+		ssid := decoded["ssids"].([]any)[0].(map[string]any)
+		if fiveG["dfsChannels"] != true || ssid["pmf"] != "capable" || decoded["schedule"] == nil {
+			t.Errorf("Expected the unmodeled fields to be kept, got %s", encoded)
+		}
+		if decoded["2.4ghz"].(map[string]any)["isRadioEnabled"] != false || ssid["wpaKey"] != "batterystaple" {
+			t.Errorf("Expected the changes to be applied, got %s", encoded)
+		}
+	})
+
+	t.Run("Built Without A Response", func(t *testing.T) {
+		encoded, err := json.Marshal(AccessPoints{SSIDs: []SSID{{Name: "New"}}})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		var decoded AccessPoints
+		err = json.Unmarshal(encoded, &decoded)
+		if err != nil || decoded.SSIDs[0].Name != "New" {
+			t.Errorf("Unexpected round trip: %s (%v)", encoded, err)
+		}
+	})
+}
+
+func TestAdminUnsupported(t *testing.T) {
+	ctx := context.Background()
+	client := setupDriverClient(fixtureServer(t, nokiaFixtures()).URL, DriverAuto)
+
+	err := client.Reboot(ctx)
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Expected errors.ErrUnsupported, got %v", err)
+	}
+	_, err = client.GetAccessPoints(ctx)
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Expected errors.ErrUnsupported, got %v", err)
+	}
+	err = client.SetAccessPoints(ctx, AccessPoints{})
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Expected errors.ErrUnsupported, got %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package backoff
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy grows the delay between retries exponentially up to a maximum, randomizing part of each delay
+// so that clients failing together do not retry in lockstep
+type Policy struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	// Jitter is the fraction of each delay that is randomized, from 0 to 1
+	Jitter float64
+}
+
+// Delay returns the delay before retry n, counting from 1
+func (p Policy) Delay(n int) time.Duration {
+	d := float64(p.Initial) * math.Pow(p.Multiplier, float64(max(n-1, 0)))
+	d = min(d, float64(p.Max))
+
+	// Jitter only shortens the delay, so Max is never exceeded
+	d -= d * p.Jitter * rand.Float64()
+	return time.Duration(d)
+}
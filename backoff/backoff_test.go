@@ -0,0 +1,33 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDelay(t *testing.T) {
+	t.Run("Exponential", func(t *testing.T) {
+		p := Policy{Initial: time.Second, Max: 10 * time.Second, Multiplier: 2}
+		expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+		for i, e := range expected {
+			if d := p.Delay(i + 1); d != e {
+				t.Errorf("Expected retry %d to wait %s, got %s", i+1, e, d)
+			}
+		}
+	})
+
+	t.Run("Jitter", func(t *testing.T) {
+		p := Policy{Initial: time.Second, Max: time.Minute, Multiplier: 2, Jitter: 0.5}
+		seen := make(map[time.Duration]bool)
+		for range 100 {
+			d := p.Delay(3)
+			if d < 2*time.Second || d > 4*time.Second {
+				t.Fatalf("Expected delay between 2s and 4s, got %s", d)
+			}
+			seen[d] = true
+		}
+		if len(seen) < 2 {
+			t.Errorf("Expected jittered delays to vary")
+		}
+	})
+}
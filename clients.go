@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"local/tmo/api"
+	"local/tmo/db"
+	"local/tmo/storage"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// pollClients records the devices connected to the gateway when its clients poll is due. A failure is logged and
+// retried on the next gateway poll rather than failing it, the clients only add context to the signal.
+func (p *GatewayPoller) pollClients(ctx context.Context, at time.Time) {
+	if p.config.clientsPollDuration() <= 0 || p.clientsUnsupported || time.Since(p.clientsPolled) < p.config.clientsPollDuration() {
+		return
+	}
+
+	clients, err := p.apiClient.GetClients(ctx)
+	if errors.Is(err, errors.ErrUnsupported) {
+		p.logger.Printf("Not polling clients: %v", err)
+		p.clientsUnsupported = true
+		return
+	}
+	if err != nil {
+		p.logger.Printf("Failed to get clients: %v", err)
+		return
+	}
+
+	err = p.saveClients(ctx, at, clients)
+	if err != nil {
+		p.logger.Printf("Failed to save clients: %v", err)
+		return
+	}
+	p.clientsPolled = time.Now()
+
+	if p.metrics != nil {
+		p.metrics.ObserveClients(p.config.Label, clients)
+	}
+}
+
+// saveClients saves the clients listed at the given time to the database in a single transaction
+func (p *GatewayPoller) saveClients(ctx context.Context, at time.Time, clients api.ClientsResponse) error {
+	tx, err := p.store.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, connection := range slices.Sorted(maps.Keys(clients.Clients)) {
+		for _, client := range clients.Clients[connection] {
+			if client.MAC == "" {
+				continue
+			}
+			err = p.loadClient(ctx, tx, at, connection, client)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// loadClient inserts or updates the client device and inserts an observation of it into the database
+func (p *GatewayPoller) loadClient(ctx context.Context, queries storage.Tx, at time.Time, connection string, client api.ConnectedClient) error {
+	mac := strings.ToLower(client.MAC)
+
+	device, err := queries.GetClientDevice(ctx, db.GetClientDeviceParams{Label: p.config.Label, Mac: mac})
+	switch {
+	case err == sql.ErrNoRows:
+		device, err = queries.CreateClientDevice(ctx, db.CreateClientDeviceParams{
+			Label:     p.config.Label,
+			Mac:       mac,
+			Name:      client.Name,
+			FirstSeen: at,
+			LastSeen:  at,
+		})
+		if err != nil {
+			return fmt.Errorf("error creating client device: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("error getting client device: %w", err)
+	default:
+		// Keep the last name the gateway knew, it forgets the names of some clients when they disconnect
+		name := device.Name
+		if client.Name != "" {
+			name = client.Name
+		}
+		device, err = queries.UpdateClientDevice(ctx, db.UpdateClientDeviceParams{ID: device.ID, Name: name, LastSeen: latest(device.LastSeen, at)})
+		if err != nil {
+			return fmt.Errorf("error updating client device: %w", err)
+		}
+	}
+
+	_, err = queries.CreateClientObservation(ctx, db.CreateClientObservationParams{
+		Clientdeviceid: device.ID,
+		Label:          p.config.Label,
+		CreatedAt:      at,
+		Connection:     connection,
+		Connected:      client.Connected,
+		Ipv4:           client.IPv4,
+		Ipv6:           strings.Join(client.IPv6, ","),
+		Signal:         int64(client.Signal),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating client observation: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"local/tmo/db"
+	"local/tmo/events"
+	"local/tmo/report"
+	"local/tmo/storage"
+	"os"
+	"time"
+)
+
+func main() {
+	flags := report.RegisterFlags(flag.CommandLine, "the handoff count", 30)
+	flag.Parse()
+
+	start, end, err := flags.Range()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	store, err := storage.Open(ctx, flags.Backend, flags.DSN)
+	if err != nil {
+		fmt.Printf("Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	allCells, err := store.ListCells(ctx)
+	if err != nil {
+		fmt.Printf("Error listing cells: %v\n", err)
+		os.Exit(1)
+	}
+
+	var cells []db.Cell
+	for _, cell := range allCells {
+		if flags.Label == "" || cell.Label == flags.Label {
+			cells = append(cells, cell)
+		}
+	}
+
+	rows, err := store.ListEventsBetween(ctx, db.ListEventsBetweenParams{Start: start.Local(), End: end.Local()})
+	if err != nil {
+		fmt.Printf("Error listing events: %v\n", err)
+		os.Exit(1)
+	}
+
+	var list []db.Event
+	for _, row := range rows {
+		if flags.Label == "" || row.Label == flags.Label {
+			list = append(list, row)
+		}
+	}
+
+	gateway := "all gateways"
+	if flags.Label != "" {
+		gateway = "gateway " + flags.Label
+	}
+	fmt.Printf("Cells of %s, handoffs from %s to %s\n\n", gateway, start.Format(time.DateTime), end.Format(time.DateTime))
+
+	err = events.WriteCells(os.Stdout, cells, events.CountHandoffs(list))
+	if err != nil {
+		fmt.Printf("Error writing cells: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -2,57 +2,163 @@ package main
 
 import (
 	"context"
-	"database/sql"
-	_ "embed"
 	"flag"
 	"fmt"
+	"local/tmo/migrations"
+	"local/tmo/rollup"
+	"local/tmo/storage"
+	"log"
 	"os"
+	"strconv"
 	"strings"
 	"time"
-
-	_ "github.com/mattn/go-sqlite3"
 )
 
 func main() {
-	usage := "\tUsage: migrate"
+	usage := "\tUsage: migrate up|down <n>|status [-backend=sqlite|postgres] -dsn=<database dsn>\n" +
+		"\t       rollup -dsn=<sqlite dsn> [-retention-days=<days>]"
 	if len(os.Args) < 2 {
 		fmt.Println(usage)
 		return
 	}
 
 	subCmd := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	dsn := subCmd.String("dsn", "", "usage: -dsn=<database dsn>")
 
 	switch os.Args[1] {
 	case "migrate":
-		dsn := subCmd.String("dsn", "", "usage: -dsn=<sqlite dsn>")
+		if len(os.Args) < 3 {
+			fmt.Println(usage)
+			return
+		}
+		backend := subCmd.String("backend", storage.BackendSQLite, "usage: -backend=sqlite|postgres")
+		subCmd.Parse(os.Args[3:])
+		migrate(os.Args[2], *backend, *dsn, subCmd.Args())
+	case "rollup":
+		retentionDays := subCmd.Int("retention-days", 0, "usage: -retention-days=<days of raw data to keep, 0 keeps everything>")
 		subCmd.Parse(os.Args[2:])
-		migrate(*dsn)
+		runRollup(*dsn, *retentionDays)
 	default:
 		fmt.Println(usage)
 	}
 }
 
-func migrate(dsn string) {
+// openStore opens the database, printing the reason and returning nil on failure
+func openStore(backend string, dsn string) storage.Store {
 	dsn = strings.TrimSpace(dsn)
 	if dsn == "" {
 		fmt.Println("DSN is required")
+		return nil
+	}
+
+	store, err := storage.Open(context.Background(), backend, dsn)
+	if err != nil {
+		fmt.Printf("Error opening database: %v\n", err)
+		return nil
+	}
+
+	return store
+}
+
+func migrate(action string, backend string, dsn string, args []string) {
+	store := openStore(backend, dsn)
+	if store == nil {
 		return
 	}
-	ddl, err := os.ReadFile("schema.sql")
+	defer close(store, "database")
 
-	sqlDb, err := sql.Open("sqlite3", dsn)
+	migrator, err := store.Migrator()
 	if err != nil {
-		fmt.Printf("Error opening database: %v", err)
+		fmt.Printf("Error loading migrations: %v\n", err)
 		return
 	}
-	defer close(sqlDb, "database")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	if _, err := sqlDb.ExecContext(ctx, string(ddl)); err != nil {
-		fmt.Printf("Error creating tables: %v", err)
+
+	switch action {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		printMigrations("Applied", applied)
+		if err != nil {
+			fmt.Printf("Error migrating up: %v\n", err)
+		}
+	case "down":
+		if len(args) != 1 {
+			fmt.Println("Number of migrations to revert is required")
+			return
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			fmt.Printf("Invalid number of migrations: %s\n", args[0])
+			return
+		}
+		reverted, err := migrator.Down(ctx, n)
+		printMigrations("Reverted", reverted)
+		if err != nil {
+			fmt.Printf("Error migrating down: %v\n", err)
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fmt.Printf("Error reading migration status: %v\n", err)
+			return
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%s\t%s\n", status.Version, status.Name, state)
+		}
+	default:
+		fmt.Printf("Unknown migrate action: %s\n", action)
+	}
+}
+
+func runRollup(dsn string, retentionDays int) {
+	if retentionDays < 0 {
+		fmt.Println("Retention days must not be negative")
+		return
+	}
+
+	// Rollups use SQLite specific queries
+	store := openStore(storage.BackendSQLite, dsn)
+	if store == nil {
+		return
+	}
+	defer close(store, "database")
+	sqlite := store.(*storage.SQLite)
+
+	ctx := context.Background()
+
+	migrator, err := sqlite.Migrator()
+	if err != nil {
+		fmt.Printf("Error loading migrations: %v\n", err)
+		return
+	}
+	if err := migrator.Check(ctx); err != nil {
+		fmt.Printf("Error checking schema: %v\n", err)
 		return
 	}
+
+	job := rollup.New(sqlite.DB, rollup.Config{
+		RetentionDays: retentionDays,
+		Logger:        log.New(os.Stdout, "", 0),
+	})
+	if _, err := job.Run(ctx, time.Now()); err != nil {
+		fmt.Printf("Error rolling up: %v\n", err)
+	}
+}
+
+func printMigrations(verb string, done []migrations.Migration) {
+	if len(done) == 0 {
+		fmt.Printf("No migrations %s\n", strings.ToLower(verb))
+		return
+	}
+	for _, migration := range done {
+		fmt.Printf("%s %04d_%s\n", verb, migration.Version, migration.Name)
+	}
 }
 
 type Closer interface {
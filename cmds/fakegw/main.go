@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"local/tmo/fakegw"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+func main() {
+	logger := log.New(os.Stdout, "", log.LstdFlags)
+
+	addr := flag.String("addr", ":8080", "address to listen on")
+	username := flag.String("username", "admin", "login username")
+	password := flag.String("password", "admin", "login password")
+	tokenTTL := flag.Duration("token-ttl", 5*time.Minute, "lifetime of issued tokens")
+	source := flag.String("source", "random", "signal source: random, script or replay")
+	file := flag.String("file", "", "CSV script for -source=script, JSON responses for -source=replay")
+	seed := flag.Uint64("seed", uint64(time.Now().UnixNano()), "random seed")
+	bands4G := flag.String("bands-4g", "b66", "comma separated 4G bands for -source=random")
+	bands5G := flag.String("bands-5g", "n41", "comma separated 5G bands for -source=random, e.g. n41,n25 for carrier aggregation")
+	unauthorizedRate := flag.Float64("unauthorized-rate", 0, "fraction of gateway requests rejected with 401")
+	serverErrorRate := flag.Float64("server-error-rate", 0, "fraction of requests answered with 500")
+	timeoutRate := flag.Float64("timeout-rate", 0, "fraction of requests that hang for -timeout")
+	timeout := flag.Duration("timeout", 30*time.Second, "how long timed out requests hang")
+	flag.Parse()
+
+	base := fakegw.DefaultGateway()
+	base.Signal.FourG.Bands = splitBands(*bands4G)
+	base.Signal.FiveG.Bands = splitBands(*bands5G)
+
+	var src fakegw.Source
+	switch *source {
+	case "random":
+		src = fakegw.NewRandomWalk(base, *seed)
+	case "script":
+		f := openFile(logger, *file)
+		script, err := fakegw.NewScript(f, base)
+		f.Close()
+		if err != nil {
+			logger.Fatalf("Failed to load script: %v", err)
+		}
+		src = script
+	case "replay":
+		f := openFile(logger, *file)
+		replay, err := fakegw.NewReplay(f)
+		f.Close()
+		if err != nil {
+			logger.Fatalf("Failed to load replay: %v", err)
+		}
+		src = replay
+	default:
+		logger.Fatalf("Unknown source: %s", *source)
+	}
+
+	server := fakegw.NewServer(fakegw.Config{
+		Username: *username,
+		Password: *password,
+		TokenTTL: *tokenTTL,
+		Source:   src,
+		Seed:     *seed,
+		Logger:   logger,
+		Faults: fakegw.Faults{
+			UnauthorizedRate: *unauthorizedRate,
+			ServerErrorRate:  *serverErrorRate,
+			TimeoutRate:      *timeoutRate,
+			Timeout:          *timeout,
+		},
+	})
+
+	logger.Printf("Fake gateway listening on http://%s%s", *addr, fakegw.BasePath)
+	err := http.ListenAndServe(*addr, server)
+	if err != nil {
+		logger.Fatalf("Fake gateway exited with error: %v", err)
+	}
+}
+
+func openFile(logger *log.Logger, name string) *os.File {
+	if name == "" {
+		logger.Fatal("-file is required")
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		logger.Fatalf("Failed to open %s: %v", name, err)
+	}
+	return f
+}
+
+func splitBands(s string) []string {
+	var bands []string
+	for _, band := range strings.Split(s, ",") {
+		if band = strings.TrimSpace(band); band != "" {
+			bands = append(bands, band)
+		}
+	}
+	return bands
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"local/tmo/db"
+	"local/tmo/events"
+	"local/tmo/report"
+	"local/tmo/storage"
+	"os"
+	"time"
+)
+
+func main() {
+	flags := report.RegisterFlags(flag.CommandLine, "the listing", 30)
+	all := flag.Bool("events", false, "also list every event, including registration, roaming and APN changes")
+	flag.Parse()
+
+	start, end, err := flags.Range()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// An outage still going on is only counted up to now
+	if end.After(time.Now()) {
+		end = time.Now()
+	}
+
+	ctx := context.Background()
+
+	store, err := storage.Open(ctx, flags.Backend, flags.DSN)
+	if err != nil {
+		fmt.Printf("Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	rows, err := store.ListEventsBetween(ctx, db.ListEventsBetweenParams{Start: start.Local(), End: end.Local()})
+	if err != nil {
+		fmt.Printf("Error listing events: %v\n", err)
+		os.Exit(1)
+	}
+
+	var list []db.Event
+	for _, row := range rows {
+		if flags.Label == "" || row.Label == flags.Label {
+			list = append(list, row)
+		}
+	}
+
+	gateway := "all gateways"
+	if flags.Label != "" {
+		gateway = "gateway " + flags.Label
+	}
+	fmt.Printf("Outages for %s from %s to %s\n\n", gateway, start.Format(time.DateTime), end.Format(time.DateTime))
+
+	err = events.WriteOutages(os.Stdout, events.Outages(list, start.Local(), end.Local()))
+	if err != nil {
+		fmt.Printf("Error writing outages: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *all {
+		fmt.Println("\nEvents")
+		err = events.WriteEvents(os.Stdout, list)
+		if err != nil {
+			fmt.Printf("Error writing events: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
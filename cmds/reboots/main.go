@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"local/tmo/db"
+	"local/tmo/events"
+	"local/tmo/report"
+	"local/tmo/storage"
+	"os"
+	"time"
+)
+
+func main() {
+	flags := report.RegisterFlags(flag.CommandLine, "the listing", 30)
+	window := flag.Duration("window", 24*time.Hour, "time before and after each event the signal is averaged over")
+	flag.Parse()
+
+	start, end, err := flags.Range()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	store, err := storage.Open(ctx, flags.Backend, flags.DSN)
+	if err != nil {
+		fmt.Printf("Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	rows, err := store.ListEventsBetween(ctx, db.ListEventsBetweenParams{Start: start.Local(), End: end.Local()})
+	if err != nil {
+		fmt.Printf("Error listing events: %v\n", err)
+		os.Exit(1)
+	}
+
+	var comparisons []report.Comparison
+	for _, row := range rows {
+		if row.Type != events.Reboot && row.Type != events.Firmware {
+			continue
+		}
+		if flags.Label != "" && row.Label != flags.Label {
+			continue
+		}
+
+		comparison, err := report.Compare(ctx, store, row, *window)
+		if err != nil {
+			fmt.Printf("Error comparing signal: %v\n", err)
+			os.Exit(1)
+		}
+		comparisons = append(comparisons, comparison)
+	}
+
+	fmt.Printf("Average signal %s before and after each reboot and firmware update\n\n", *window)
+	err = report.WriteComparisons(os.Stdout, comparisons)
+	if err != nil {
+		fmt.Printf("Error writing comparisons: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"local/tmo/report"
+	"local/tmo/storage"
+	"os"
+)
+
+func main() {
+	flags := report.RegisterFlags(flag.CommandLine, "the report", 7)
+	flag.Parse()
+
+	start, end, err := flags.Range()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	store, err := storage.Open(ctx, flags.Backend, flags.DSN)
+	if err != nil {
+		fmt.Printf("Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	r, err := report.Build(ctx, store, flags.Label, start.Local(), end.Local())
+	if err != nil {
+		fmt.Printf("Error building report: %v\n", err)
+		os.Exit(1)
+	}
+
+	err = r.Write(os.Stdout)
+	if err != nil {
+		fmt.Printf("Error writing report: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"local/tmo/api"
+	"local/tmo/influx"
+	"local/tmo/mqtt"
+	"local/tmo/storage"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultGatewayURL is the API address of a gateway on its own network
+const defaultGatewayURL = "http://192.168.12.1/TMI/v1"
+
+// defaultConfig is the configuration used for everything not set by the config file, environment or flags
+func defaultConfig() Config {
+	return Config{
+		DBBackend:   storage.BackendSQLite,
+		DBDSN:       "file:tmo.db?cache=shared&mode=rwc&_journal_mode=WAL&_synchronous=NORMAL",
+		InitTimeout: 3 * time.Second,
+		Influx: influx.Config{
+			BatchSize:     influx.DefaultBatchSize,
+			FlushInterval: influx.DefaultFlushInterval,
+			MaxSpoolBytes: influx.DefaultMaxSpoolBytes,
+		},
+		MQTT: mqtt.Config{
+			ClientID:        mqtt.DefaultClientID,
+			TopicPrefix:     mqtt.DefaultTopicPrefix,
+			DiscoveryPrefix: mqtt.DefaultDiscoveryPrefix,
+		},
+		GatewayDefaults: GatewayConfig{
+			URL:                 defaultGatewayURL,
+			Driver:              api.DriverAuto,
+			PollDuration:        5 * time.Minute,
+			RequestTimeout:      30 * time.Second,
+			ClientsPollDuration: ptr(15 * time.Minute),
+			CellTelemetry:       ptr(false),
+			PolicyDryRun:        ptr(false),
+			Night: &NightSchedule{
+				Start:        23,
+				End:          3,
+				PollDuration: time.Minute,
+			},
+		},
+	}
+}
+
+// loadConfig builds the configuration from the defaults, the YAML config file, the environment and the flags,
+// each overriding the one before. Every problem found is reported together.
+func loadConfig(args []string, getenv func(string) string) (Config, error) {
+	// Parse once to find the config file and report invalid flags before anything else is read
+	parsed := defaultConfig()
+	flags := flag.NewFlagSet("tmo", flag.ContinueOnError)
+	configPath := bindFlags(flags, &parsed)
+	err := flags.Parse(args)
+	if err != nil {
+		return Config{}, err
+	}
+	if *configPath == "" {
+		*configPath = getenv("GATEWAY_CONFIG")
+	}
+
+	config := defaultConfig()
+	if *configPath != "" {
+		err := readConfigFile(*configPath, &config)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	// An explicit null in the defaults of the file disables the setting
+	defaults := &config.GatewayDefaults
+	if defaults.Night == nil {
+		defaults.Night = &NightSchedule{}
+	}
+	if defaults.ClientsPollDuration == nil {
+		defaults.ClientsPollDuration = ptr(time.Duration(0))
+	}
+	if defaults.CellTelemetry == nil {
+		defaults.CellTelemetry = ptr(false)
+	}
+	if defaults.PolicyDryRun == nil {
+		defaults.PolicyDryRun = ptr(false)
+	}
+
+	errs := []error{applyEnv(&config, getenv)}
+
+	// Parse again into the loaded configuration, so only the flags given override it
+	flags = flag.NewFlagSet("tmo", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	bindFlags(flags, &config)
+	err = flags.Parse(args)
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.Gateways = withDefaults(config.Gateways, config.GatewayDefaults)
+
+	errs = append(errs, config.validate())
+	return config, errors.Join(errs...)
+}
+
+// bindFlags defines a flag for every setting, defaulting to its current value, and returns the config file flag
+func bindFlags(flags *flag.FlagSet, config *Config) *string {
+	configPath := flags.String("config", "", "YAML config file, defaults to $GATEWAY_CONFIG")
+
+	flags.StringVar(&config.DBBackend, "db-backend", config.DBBackend, "storage backend, sqlite or postgres")
+	flags.StringVar(&config.DBDSN, "db-dsn", config.DBDSN, "database DSN")
+	flags.StringVar(&config.MetricsAddr, "metrics-addr", config.MetricsAddr, "address to serve Prometheus metrics on")
+	flags.StringVar(&config.DashboardAddr, "dashboard-addr", config.DashboardAddr, "address to serve the web dashboard on")
+	flags.StringVar(&config.Influx.URL, "influx-url", config.Influx.URL, "write endpoint to post every poll to as InfluxDB line protocol")
+	flags.StringVar(&config.Influx.File, "influx-file", config.Influx.File, "file to append every poll to as InfluxDB line protocol")
+	flags.StringVar(&config.Influx.Token, "influx-token", config.Influx.Token, "token sent with every post to the write endpoint")
+	flags.IntVar(&config.Influx.BatchSize, "influx-batch-size", config.Influx.BatchSize, "most lines written to InfluxDB at once")
+	flags.DurationVar(&config.Influx.FlushInterval, "influx-flush-interval", config.Influx.FlushInterval, "longest time lines wait to be written to InfluxDB")
+	flags.StringVar(&config.Influx.SpoolDir, "influx-spool-dir", config.Influx.SpoolDir, "directory to keep the batches that failed to export in until the endpoint is back")
+	flags.Int64Var(&config.Influx.MaxSpoolBytes, "influx-max-spool-bytes", config.Influx.MaxSpoolBytes, "spool size the oldest batches are dropped at, 0 never drops them")
+	flags.StringVar(&config.MQTT.Broker, "mqtt-broker", config.MQTT.Broker, "MQTT broker to publish every poll to, such as tcp://localhost:1883")
+	flags.StringVar(&config.MQTT.Username, "mqtt-username", config.MQTT.Username, "MQTT broker username")
+	flags.StringVar(&config.MQTT.Password, "mqtt-password", config.MQTT.Password, "MQTT broker password")
+	flags.StringVar(&config.MQTT.ClientID, "mqtt-client-id", config.MQTT.ClientID, "MQTT client ID")
+	flags.StringVar(&config.MQTT.TopicPrefix, "mqtt-topic-prefix", config.MQTT.TopicPrefix, "first level of every MQTT topic published to")
+	flags.StringVar(&config.MQTT.DiscoveryPrefix, "mqtt-discovery-prefix", config.MQTT.DiscoveryPrefix, "Home Assistant discovery prefix, empty disables discovery")
+	flags.StringVar(&config.RecordDir, "record-dir", config.RecordDir, "directory to archive raw gateway responses to")
+	flags.StringVar(&config.ReplayPath, "replay", config.ReplayPath, "recording file or directory to load instead of polling")
+	flags.IntVar(&config.RetentionDays, "retention-days", config.RetentionDays, "days of raw snapshots to keep once rolled up, 0 keeps them forever")
+	flags.DurationVar(&config.InitTimeout, "init-timeout", config.InitTimeout, "time allowed to connect to the database at startup")
+
+	defaults := &config.GatewayDefaults
+	flags.StringVar(&defaults.Label, "label", defaults.Label, "gateway label, when no gateways are listed in the config file")
+	flags.StringVar(&defaults.URL, "url", defaults.URL, "gateway API URL, when no gateways are listed in the config file")
+	flags.StringVar(&defaults.Driver, "driver", defaults.Driver, "default gateway API, auto, tmi or nokia")
+	flags.StringVar(&defaults.Username, "username", defaults.Username, "default gateway username")
+	flags.StringVar(&defaults.Password, "password", defaults.Password, "default gateway password")
+	flags.DurationVar(&defaults.PollDuration, "poll-frequency", defaults.PollDuration, "default time between polls")
+	flags.DurationVar(&defaults.RequestTimeout, "request-timeout", defaults.RequestTimeout, "default time allowed for each gateway request")
+	flags.DurationVar(defaults.ClientsPollDuration, "clients-poll-frequency", *defaults.ClientsPollDuration, "default time between recordings of the connected clients, 0 disables them")
+	flags.BoolVar(defaults.CellTelemetry, "cell-telemetry", *defaults.CellTelemetry, "fetch the radio channels of the serving cells with every poll")
+	flags.BoolVar(defaults.PolicyDryRun, "policy-dry-run", *defaults.PolicyDryRun, "log and record policy firings without rebooting or posting to webhooks")
+	flags.IntVar(&defaults.Night.Start, "night-start", defaults.Night.Start, "hour of the day the night schedule starts")
+	flags.IntVar(&defaults.Night.End, "night-end", defaults.Night.End, "hour of the day the night schedule ends, equal to -night-start disables it")
+	flags.DurationVar(&defaults.Night.PollDuration, "night-poll-frequency", defaults.Night.PollDuration, "time between polls during the night schedule")
+
+	return configPath
+}
+
+// readConfigFile overrides the configuration with the settings in a YAML file
+func readConfigFile(path string, config *Config) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening config file: %w", err)
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+
+	err = decoder.Decode(config)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("error reading config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// applyEnv overrides the configuration with the GATEWAY_* environment variables that are set
+func applyEnv(config *Config, getenv func(string) string) error {
+	var errs []error
+
+	setString := func(name string, value *string) {
+		if s := getenv(name); s != "" {
+			*value = s
+		}
+	}
+	setString("GATEWAY_DB_BACKEND", &config.DBBackend)
+	setString("GATEWAY_DB_DSN", &config.DBDSN)
+	setString("GATEWAY_METRICS_ADDR", &config.MetricsAddr)
+	setString("GATEWAY_DASHBOARD_ADDR", &config.DashboardAddr)
+	setString("GATEWAY_INFLUX_URL", &config.Influx.URL)
+	setString("GATEWAY_INFLUX_TOKEN", &config.Influx.Token)
+	setString("GATEWAY_MQTT_BROKER", &config.MQTT.Broker)
+	setString("GATEWAY_MQTT_USERNAME", &config.MQTT.Username)
+	setString("GATEWAY_MQTT_PASSWORD", &config.MQTT.Password)
+	setString("GATEWAY_RECORD_DIR", &config.RecordDir)
+	setString("GATEWAY_REPLAY", &config.ReplayPath)
+	setString("GATEWAY_DRIVER", &config.GatewayDefaults.Driver)
+	setString("GATEWAY_USERNAME", &config.GatewayDefaults.Username)
+	setString("GATEWAY_PASSWORD", &config.GatewayDefaults.Password)
+
+	if s := getenv("GATEWAY_POLL_FREQ"); s != "" {
+		duration, err := time.ParseDuration(s)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid GATEWAY_POLL_FREQ: %w", err))
+		} else {
+			config.GatewayDefaults.PollDuration = duration
+		}
+	}
+
+	if s := getenv("GATEWAY_RETENTION_DAYS"); s != "" {
+		days, err := strconv.Atoi(s)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid GATEWAY_RETENTION_DAYS: %w", err))
+		} else {
+			config.RetentionDays = days
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// withDefaults fills in the settings left out of each gateway, polling the defaults if no gateways are listed
+func withDefaults(gateways []GatewayConfig, defaults GatewayConfig) []GatewayConfig {
+	if len(gateways) == 0 {
+		return []GatewayConfig{defaults}
+	}
+
+	result := make([]GatewayConfig, len(gateways))
+	for i, gateway := range gateways {
+		if gateway.URL == "" {
+			gateway.URL = defaults.URL
+		}
+		if gateway.Driver == "" {
+			gateway.Driver = defaults.Driver
+		}
+		if gateway.Username == "" {
+			gateway.Username = defaults.Username
+		}
+		if gateway.Password == "" {
+			gateway.Password = defaults.Password
+		}
+		if gateway.PollDuration == 0 {
+			gateway.PollDuration = defaults.PollDuration
+		}
+		if gateway.RequestTimeout == 0 {
+			gateway.RequestTimeout = defaults.RequestTimeout
+		}
+		if gateway.ClientsPollDuration == nil {
+			gateway.ClientsPollDuration = defaults.ClientsPollDuration
+		}
+		if gateway.CellTelemetry == nil {
+			gateway.CellTelemetry = defaults.CellTelemetry
+		}
+		if gateway.Policies == nil {
+			gateway.Policies = defaults.Policies
+		}
+		if gateway.PolicyDryRun == nil {
+			gateway.PolicyDryRun = defaults.PolicyDryRun
+		}
+		if gateway.Night == nil {
+			gateway.Night = defaults.Night
+		}
+		result[i] = gateway
+	}
+
+	return result
+}
+
+// ptr returns a pointer to a copy of the value, for the settings a gateway may leave out
+func ptr[T any](value T) *T {
+	return &value
+}
+
+// validate checks the configuration, reporting every problem together
+func (c Config) validate() error {
+	var errs []error
+
+	if c.DBBackend != storage.BackendSQLite && c.DBBackend != storage.BackendPostgres {
+		errs = append(errs, fmt.Errorf("db backend must be %s or %s, got %q", storage.BackendSQLite, storage.BackendPostgres, c.DBBackend))
+	}
+	if c.DBDSN == "" {
+		errs = append(errs, errors.New("db dsn is required"))
+	}
+	if c.RetentionDays < 0 {
+		errs = append(errs, errors.New("retention days must not be negative"))
+	}
+	// Rollups only run against SQLite, so retention would never delete anything from PostgreSQL
+	if c.RetentionDays > 0 && c.DBBackend == storage.BackendPostgres {
+		errs = append(errs, fmt.Errorf("retention days are not supported by the %s backend", storage.BackendPostgres))
+	}
+	if c.InitTimeout <= 0 {
+		errs = append(errs, errors.New("init timeout must be positive"))
+	}
+	if c.Influx.Enabled() {
+		if err := c.Influx.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("influx: %w", err))
+		}
+	}
+	if c.MQTT.Enabled() {
+		if err := c.MQTT.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("mqtt: %w", err))
+		}
+	}
+
+	// Replays load recordings instead of connecting to the gateways
+	if c.ReplayPath == "" {
+		errs = append(errs, validateGateways(c.Gateways))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateGateways checks every gateway can be polled, reporting all problems together
+func validateGateways(gateways []GatewayConfig) error {
+	if len(gateways) == 0 {
+		return errors.New("no gateways configured")
+	}
+
+	var errs []error
+	labels := make(map[string]bool)
+	for i, gateway := range gateways {
+		name := gateway.Label
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+
+		if gateway.Label == "" && len(gateways) > 1 {
+			errs = append(errs, fmt.Errorf("gateway %s: label is required when polling more than one gateway", name))
+		}
+		if gateway.Label != "" && labels[gateway.Label] {
+			errs = append(errs, fmt.Errorf("gateway %s: duplicate label", name))
+		}
+		labels[gateway.Label] = true
+
+		if gateway.URL == "" {
+			errs = append(errs, fmt.Errorf("gateway %s: url is required", name))
+		}
+		if !api.IsDriver(gateway.Driver) {
+			errs = append(errs, fmt.Errorf("gateway %s: driver must be %s, %s or %s, got %q", name, api.DriverAuto, api.DriverTMI, api.DriverNokia, gateway.Driver))
+		}
+		if gateway.Username == "" || gateway.Password == "" {
+			errs = append(errs, fmt.Errorf("gateway %s: username and password are required", name))
+		}
+		if gateway.PollDuration <= 0 {
+			errs = append(errs, fmt.Errorf("gateway %s: poll frequency must be positive", name))
+		}
+		if gateway.RequestTimeout < 0 {
+			errs = append(errs, fmt.Errorf("gateway %s: request timeout must not be negative", name))
+		}
+		if gateway.clientsPollDuration() < 0 {
+			errs = append(errs, fmt.Errorf("gateway %s: clients poll frequency must not be negative", name))
+		}
+		rules := make(map[string]bool)
+		for j, rule := range gateway.Policies {
+			ruleName := rule.Name
+			if ruleName == "" {
+				ruleName = fmt.Sprintf("#%d", j+1)
+			}
+			if rule.Name != "" && rules[rule.Name] {
+				errs = append(errs, fmt.Errorf("gateway %s: policy %s: duplicate name", name, ruleName))
+			}
+			rules[rule.Name] = true
+			if err := rule.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("gateway %s: policy %s: %w", name, ruleName, err))
+			}
+		}
+		if night := gateway.Night; night != nil {
+			if night.Start < 0 || night.Start > 23 || night.End < 0 || night.End > 23 {
+				errs = append(errs, fmt.Errorf("gateway %s: night start and end must be hours from 0 to 23", name))
+			}
+			if night.Start != night.End && night.PollDuration <= 0 {
+				errs = append(errs, fmt.Errorf("gateway %s: night poll frequency must be positive", name))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
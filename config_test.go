@@ -0,0 +1,272 @@
+package main
+
+import (
+	"local/tmo/api"
+	"local/tmo/policy"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, contents string) string {
+	path := filepath.Join(t.TempDir(), "tmo.yaml")
+	err := os.WriteFile(path, []byte(contents), 0o600)
+	if err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+// env returns a getenv function reading from a map
+func env(vars map[string]string) func(string) string {
+	return func(name string) string {
+		return vars[name]
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		config, err := loadConfig(nil, env(map[string]string{"GATEWAY_USERNAME": "admin", "GATEWAY_PASSWORD": "secret"}))
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if len(config.Gateways) != 1 {
+			t.Fatalf("Expected 1 gateway, got %d", len(config.Gateways))
+		}
+		gateway := config.Gateways[0]
+		if gateway.URL != defaultGatewayURL || gateway.Username != "admin" || gateway.PollDuration != 5*time.Minute || gateway.Driver != api.DriverAuto {
+			t.Errorf("Unexpected gateway: %+v", gateway)
+		}
+		if *gateway.Night != (NightSchedule{Start: 23, End: 3, PollDuration: time.Minute}) {
+			t.Errorf("Unexpected night schedule: %+v", *gateway.Night)
+		}
+		if config.InitTimeout != 3*time.Second || config.DBBackend != "sqlite" {
+			t.Errorf("Unexpected config: %+v", config)
+		}
+	})
+
+	t.Run("File Environment And Flags", func(t *testing.T) {
+		path := writeConfig(t, `
+db_dsn: file.db
+metrics_addr: :9100
+dashboard_addr: :8080
+retention_days: 30
+influx:
+  url: http://localhost:8086/api/v2/write?org=home&bucket=tmo
+  batch_size: 50
+mqtt:
+  topic_prefix: lte
+  discovery_prefix: ""
+defaults:
+  username: admin
+  password: secret
+  poll_frequency: 2m
+  policies:
+    - name: no-5g
+      condition: no_5g
+      for: 15m
+      actions: [reboot, log]
+      max_per_day: 3
+  night:
+    start: 1
+    end: 5
+    poll_frequency: 30s
+gateways:
+  - label: home
+    url: http://192.168.12.1/TMI/v1
+    poll_frequency: 1m
+  - label: lab
+    url: http://10.0.0.2
+    driver: nokia
+    password: other
+    clients_poll_frequency: 1h
+    cell_telemetry: true
+    policy_dry_run: true
+    policies:
+      - name: low-sinr
+        condition: sinr_below
+        threshold: 0
+        for: 30m
+        actions: [webhook]
+        webhook_url: http://10.0.0.3/hook
+    night:
+      start: 0
+      end: 0
+`)
+		args := []string{"-config=" + path, "-retention-days=7", "-night-end=6", "-influx-spool-dir=spool", "-influx-batch-size=100", "-mqtt-broker=tcp://localhost:1883", "-mqtt-client-id=tmo-2"}
+		vars := map[string]string{"GATEWAY_DB_DSN": "env.db", "GATEWAY_METRICS_ADDR": ":9200", "GATEWAY_INFLUX_TOKEN": "secret", "GATEWAY_MQTT_PASSWORD": "secret"}
+		config, err := loadConfig(args, env(vars))
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if config.DBDSN != "env.db" || config.MetricsAddr != ":9200" || config.DashboardAddr != ":8080" || config.RetentionDays != 7 {
+			t.Errorf("Unexpected config: %+v", config)
+		}
+		if influx := config.Influx; influx.BatchSize != 100 || influx.FlushInterval != 10*time.Second || influx.Token != "secret" || influx.SpoolDir != "spool" {
+			t.Errorf("Unexpected influx config: %+v", influx)
+		}
+		if mqtt := config.MQTT; mqtt.Broker != "tcp://localhost:1883" || mqtt.ClientID != "tmo-2" || mqtt.TopicPrefix != "lte" || mqtt.DiscoveryPrefix != "" || mqtt.Password != "secret" {
+			t.Errorf("Unexpected mqtt config: %+v", mqtt)
+		}
+		if len(config.Gateways) != 2 {
+			t.Fatalf("Expected 2 gateways, got %d", len(config.Gateways))
+		}
+
+		home, lab := config.Gateways[0], config.Gateways[1]
+		if home.Username != "admin" || home.Password != "secret" || home.PollDuration != time.Minute {
+			t.Errorf("Unexpected home gateway: %+v", home)
+		}
+		if *home.Night != (NightSchedule{Start: 1, End: 6, PollDuration: 30 * time.Second}) {
+			t.Errorf("Unexpected home night schedule: %+v", *home.Night)
+		}
+		if home.Driver != api.DriverAuto || lab.Driver != api.DriverNokia {
+			t.Errorf("Unexpected drivers: %q and %q", home.Driver, lab.Driver)
+		}
+		if home.cellTelemetry() || !lab.cellTelemetry() {
+			t.Errorf("Expected cell telemetry for the lab gateway only, got %t and %t", home.cellTelemetry(), lab.cellTelemetry())
+		}
+		if home.clientsPollDuration() != 15*time.Minute || lab.clientsPollDuration() != time.Hour {
+			t.Errorf("Unexpected clients poll frequencies: %s and %s", home.clientsPollDuration(), lab.clientsPollDuration())
+		}
+		if len(home.Policies) != 1 || home.Policies[0].Name != "no-5g" || home.Policies[0].For != 15*time.Minute || home.policyDryRun() {
+			t.Errorf("Expected the home gateway to use the default policies, got %+v", home.Policies)
+		}
+		if len(lab.Policies) != 1 || lab.Policies[0].Condition != policy.SINRBelow || !lab.policyDryRun() {
+			t.Errorf("Expected the lab gateway to use its own policies in a dry run, got %+v", lab.Policies)
+		}
+		if lab.Password != "other" || lab.PollDuration != 2*time.Minute || lab.Night.Start != lab.Night.End {
+			t.Errorf("Unexpected lab gateway: %+v", lab)
+		}
+	})
+
+	t.Run("Explicit False And Zero Override The Defaults", func(t *testing.T) {
+		path := writeConfig(t, `
+defaults:
+  username: admin
+  password: secret
+  clients_poll_frequency: 30m
+  cell_telemetry: true
+  policy_dry_run: true
+gateways:
+  - label: home
+  - label: lab
+    clients_poll_frequency: 0s
+    cell_telemetry: false
+    policy_dry_run: false
+`)
+		config, err := loadConfig([]string{"-config", path}, env(nil))
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		home, lab := config.Gateways[0], config.Gateways[1]
+		if !home.cellTelemetry() || !home.policyDryRun() || home.clientsPollDuration() != 30*time.Minute {
+			t.Errorf("Expected the home gateway to use the defaults, got %t, %t and %s", home.cellTelemetry(), home.policyDryRun(), home.clientsPollDuration())
+		}
+		if lab.cellTelemetry() || lab.policyDryRun() || lab.clientsPollDuration() != 0 {
+			t.Errorf("Expected the lab gateway to turn everything off, got %t, %t and %s", lab.cellTelemetry(), lab.policyDryRun(), lab.clientsPollDuration())
+		}
+	})
+
+	t.Run("Reports Every Problem", func(t *testing.T) {
+		vars := map[string]string{"GATEWAY_POLL_FREQ": "often", "GATEWAY_RETENTION_DAYS": "-1 days", "GATEWAY_DB_BACKEND": "mysql"}
+		_, err := loadConfig([]string{"-night-start=25", "-influx-url=localhost:8086", "-mqtt-broker=localhost:1883"}, env(vars))
+		if err == nil {
+			t.Fatal("Expected error")
+		}
+		for _, expected := range []string{"GATEWAY_POLL_FREQ", "GATEWAY_RETENTION_DAYS", "db backend", "username and password", "hours from 0 to 23", "influx: url", "mqtt: broker"} {
+			if !strings.Contains(err.Error(), expected) {
+				t.Errorf("Expected error to contain %q, got %s", expected, err)
+			}
+		}
+	})
+
+	t.Run("Retention With PostgreSQL", func(t *testing.T) {
+		vars := map[string]string{"GATEWAY_USERNAME": "admin", "GATEWAY_PASSWORD": "secret", "GATEWAY_DB_BACKEND": "postgres", "GATEWAY_RETENTION_DAYS": "90"}
+		_, err := loadConfig(nil, env(vars))
+		if err == nil || !strings.Contains(err.Error(), "retention days are not supported") {
+			t.Errorf("Expected retention to be rejected with postgres, got %v", err)
+		}
+	})
+
+	t.Run("Unknown Field", func(t *testing.T) {
+		path := writeConfig(t, "gateways:\n  - label: home\n    adress: http://192.168.12.1\n")
+		_, err := loadConfig([]string{"-config", path}, env(nil))
+		if err == nil {
+			t.Error("Expected error for unknown field")
+		}
+	})
+
+	t.Run("Invalid Flag", func(t *testing.T) {
+		_, err := loadConfig([]string{"-poll-frequency=often"}, env(nil))
+		if err == nil {
+			t.Error("Expected error for invalid flag")
+		}
+	})
+}
+
+func TestValidateGateways(t *testing.T) {
+	valid := GatewayConfig{Label: "home", URL: "http://localhost", Username: "admin", Password: "admin", PollDuration: time.Minute}
+
+	t.Run("Success", func(t *testing.T) {
+		lab := valid
+		lab.Label = "lab"
+		err := validateGateways([]GatewayConfig{valid, lab})
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		}
+	})
+
+	t.Run("Single Gateway Without Label", func(t *testing.T) {
+		unlabeled := valid
+		unlabeled.Label = ""
+		err := validateGateways([]GatewayConfig{unlabeled})
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		}
+	})
+
+	t.Run("Reports Every Problem", func(t *testing.T) {
+		err := validateGateways([]GatewayConfig{valid, valid, {
+			URL: "http://localhost", Driver: "zte", ClientsPollDuration: ptr(-time.Minute),
+			Policies: []policy.Rule{
+				{Name: "reboot", Condition: "flapping", Actions: []string{policy.ActionReboot}},
+				{Name: "reboot", Condition: policy.NoFiveG, Actions: []string{policy.ActionLog}},
+			},
+		}})
+		if err == nil {
+			t.Fatal("Expected error")
+		}
+		for _, expected := range []string{
+			"home: duplicate label", "#3: label is required", "#3: username and password", "#3: poll frequency", "#3: driver must be",
+			"#3: clients poll frequency", "#3: policy reboot: condition must be", "#3: policy reboot: duplicate name",
+		} {
+			if !strings.Contains(err.Error(), expected) {
+				t.Errorf("Expected error to contain %q, got %s", expected, err)
+			}
+		}
+	})
+}
+
+func TestNightScheduleContains(t *testing.T) {
+	tests := []struct {
+		night    NightSchedule
+		hour     int
+		expected bool
+	}{
+		{NightSchedule{Start: 23, End: 3}, 23, true},
+		{NightSchedule{Start: 23, End: 3}, 2, true},
+		{NightSchedule{Start: 23, End: 3}, 3, false},
+		{NightSchedule{Start: 1, End: 5}, 0, false},
+		{NightSchedule{Start: 1, End: 5}, 4, true},
+		{NightSchedule{Start: 0, End: 0}, 0, false},
+	}
+	for _, test := range tests {
+		if actual := test.night.contains(test.hour); actual != test.expected {
+			t.Errorf("Expected %+v contains %d to be %t, got %t", test.night, test.hour, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package dashboard
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"local/tmo/db"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Page sizes of the paginated endpoints
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+// Page is one page of a listing. Next is the after parameter that fetches the following page, it is left out of the
+// last page.
+type Page[T any] struct {
+	Items []T   `json:"items"`
+	Next  int64 `json:"next,omitempty"`
+}
+
+// Device is a gateway device, a new one is stored whenever its software is updated
+type Device struct {
+	ID              int64  `json:"id"`
+	Label           string `json:"label"`
+	Serial          string `json:"serial"`
+	Name            string `json:"name"`
+	FriendlyName    string `json:"friendly_name"`
+	Manufacturer    string `json:"manufacturer"`
+	Model           string `json:"model"`
+	Type            string `json:"type"`
+	Role            string `json:"role"`
+	HardwareVersion string `json:"hardware_version"`
+	SoftwareVersion string `json:"software_version"`
+	UpdateState     string `json:"update_state"`
+	MacID           string `json:"mac_id"`
+	Enabled         bool   `json:"enabled"`
+	MeshSupported   bool   `json:"mesh_supported"`
+}
+
+// Snapshot is a stored poll of a gateway
+type Snapshot struct {
+	ID           int64     `json:"id"`
+	DeviceID     int64     `json:"device_id"`
+	Label        string    `json:"label"`
+	CreatedAt    time.Time `json:"created_at"`
+	Uptime       int64     `json:"uptime"`
+	Registration string    `json:"registration"`
+	Roaming      bool      `json:"roaming"`
+	Apn          string    `json:"apn"`
+	HasIPv6      bool      `json:"has_ipv6"`
+}
+
+// Signal is the stored signal of one generation in a snapshot
+type Signal struct {
+	ID          int64     `json:"id"`
+	SnapshotID  int64     `json:"snapshot_id"`
+	Label       string    `json:"label"`
+	CreatedAt   time.Time `json:"created_at"`
+	Generation  string    `json:"generation"`
+	Band        string    `json:"band"`
+	AntennaUsed string    `json:"antenna_used"`
+	Bars        float64   `json:"bars"`
+	Cid         int64     `json:"cid"`
+	Enbid       int64     `json:"enbid"`
+	Gnbid       int64     `json:"gnbid"`
+	Rsrp        int64     `json:"rsrp"`
+	Rsrq        int64     `json:"rsrq"`
+	Rssi        int64     `json:"rssi"`
+	Sinr        int64     `json:"sinr"`
+}
+
+// Spread is the lowest, average and highest value of a signal metric
+type Spread struct {
+	Min int64   `json:"min"`
+	Avg float64 `json:"avg"`
+	Max int64   `json:"max"`
+}
+
+// BandStats summarizes the signals of one gateway on one primary band of a generation
+type BandStats struct {
+	Label      string `json:"label"`
+	Generation string `json:"generation"`
+	Band       string `json:"band"`
+	Samples    int64  `json:"samples"`
+	Rsrp       Spread `json:"rsrp"`
+	Rsrq       Spread `json:"rsrq"`
+	Rssi       Spread `json:"rssi"`
+	Sinr       Spread `json:"sinr"`
+}
+
+// Stats summarizes the signals stored between From and To
+type Stats struct {
+	From  time.Time   `json:"from"`
+	To    time.Time   `json:"to"`
+	Bands []BandStats `json:"bands"`
+}
+
+// handleDevices writes a page of the stored devices
+func (s *Server) handleDevices(w http.ResponseWriter, r *http.Request) {
+	after, limit, err := parsePage(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rows, err := s.source.ListDevicesPage(r.Context(), db.ListDevicesPageParams{After: after, PageSize: limit + 1})
+	if err != nil {
+		s.logger.Printf("Failed to list devices: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to list devices")
+		return
+	}
+
+	writeJSON(w, paginate(rows, limit, func(row db.Device) (int64, Device) {
+		return row.ID, Device{
+			ID:              row.ID,
+			Label:           row.Label,
+			Serial:          row.Serial,
+			Name:            row.Name,
+			FriendlyName:    row.FriendlyName,
+			Manufacturer:    row.Manufacturer,
+			Model:           row.Model,
+			Type:            row.Type,
+			Role:            row.Role,
+			HardwareVersion: row.HardwareVersion,
+			SoftwareVersion: row.SoftwareVersion,
+			UpdateState:     row.UpdateState,
+			MacID:           row.Macid,
+			Enabled:         row.Isenabled,
+			MeshSupported:   row.IsmeshSupported,
+		}
+	}))
+}
+
+// handleSnapshots writes a page of the snapshots taken between the from and to parameters, the last day by default,
+// of the gateway in the label parameter or of every gateway
+func (s *Server) handleSnapshots(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseRange(r, time.Now(), 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	after, limit, err := parsePage(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rows, err := s.source.ListSnapshotsPage(r.Context(), db.ListSnapshotsPageParams{
+		Start: from, End: to, Label: optional(r, "label"), After: after, PageSize: limit + 1,
+	})
+	if err != nil {
+		s.logger.Printf("Failed to list snapshots: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to list snapshots")
+		return
+	}
+
+	writeJSON(w, paginate(rows, limit, func(row db.Snapshot) (int64, Snapshot) {
+		return row.ID, Snapshot{
+			ID:           row.ID,
+			DeviceID:     row.Deviceid,
+			Label:        row.Label,
+			CreatedAt:    row.CreatedAt,
+			Uptime:       row.Uptime,
+			Registration: row.Registration,
+			Roaming:      row.Roaming,
+			Apn:          row.Apn,
+			HasIPv6:      row.HasIpv6,
+		}
+	}))
+}
+
+// handleSignals writes a page of the signals stored between the from and to parameters, the last day by default,
+// narrowed down by the label, generation, band and cid parameters
+func (s *Server) handleSignals(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseRange(r, time.Now(), 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	after, limit, err := parsePage(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	generation := optional(r, "generation")
+	if generation.Valid {
+		generation.String = strings.ToUpper(generation.String)
+		if generation.String != "4G" && generation.String != "5G" {
+			writeError(w, http.StatusBadRequest, "invalid generation: must be 4G or 5G")
+			return
+		}
+	}
+	var cid sql.NullInt64
+	if s := r.URL.Query().Get("cid"); s != "" {
+		cid.Int64, err = strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid cid: must be an integer")
+			return
+		}
+		cid.Valid = true
+	}
+
+	rows, err := s.source.ListSignalsPage(r.Context(), db.ListSignalsPageParams{
+		Start:      from,
+		End:        to,
+		Label:      optional(r, "label"),
+		Generation: generation,
+		Band:       optional(r, "band"),
+		Cid:        cid,
+		After:      after,
+		PageSize:   limit + 1,
+	})
+	if err != nil {
+		s.logger.Printf("Failed to list signals: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to list signals")
+		return
+	}
+
+	writeJSON(w, paginate(rows, limit, func(row db.ListSignalsPageRow) (int64, Signal) {
+		return row.ID, Signal{
+			ID:          row.ID,
+			SnapshotID:  row.Snapshotid,
+			Label:       row.Label,
+			CreatedAt:   row.CreatedAt,
+			Generation:  row.Generation,
+			Band:        row.Band,
+			AntennaUsed: row.AntennaUsed,
+			Bars:        row.Bars,
+			Cid:         row.Cid,
+			Enbid:       row.Enbid,
+			Gnbid:       row.Gnbid,
+			Rsrp:        row.Rsrp,
+			Rsrq:        row.Rsrq,
+			Rssi:        row.Rssi,
+			Sinr:        row.Sinr,
+		}
+	}))
+}
+
+// handleStats writes the spread of the signals stored between the from and to parameters, the last day by default,
+// of each gateway on each band, or of the gateway in the label parameter
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseRange(r, time.Now(), 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rows, err := s.source.SummarizeSignalsBetween(r.Context(), db.SummarizeSignalsBetweenParams{
+		Start: from, End: to, Label: optional(r, "label"),
+	})
+	if err != nil {
+		s.logger.Printf("Failed to summarize signals: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to summarize signals")
+		return
+	}
+
+	stats := Stats{From: from, To: to, Bands: make([]BandStats, len(rows))}
+	for i, row := range rows {
+		stats.Bands[i] = BandStats{
+			Label:      row.Label,
+			Generation: row.Generation,
+			Band:       row.Band,
+			Samples:    row.Samples,
+			Rsrp:       Spread{Min: row.RsrpMin, Avg: row.RsrpAvg, Max: row.RsrpMax},
+			Rsrq:       Spread{Min: row.RsrqMin, Avg: row.RsrqAvg, Max: row.RsrqMax},
+			Rssi:       Spread{Min: row.RssiMin, Avg: row.RssiAvg, Max: row.RssiMax},
+			Sinr:       Spread{Min: row.SinrMin, Avg: row.SinrAvg, Max: row.SinrMax},
+		}
+	}
+	writeJSON(w, stats)
+}
+
+// parsePage reads the after and limit query parameters, a page is limit rows with an id above after
+func parsePage(r *http.Request) (int64, int64, error) {
+	query := r.URL.Query()
+
+	var after int64
+	if s := query.Get("after"); s != "" {
+		var err error
+		after, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || after < 0 {
+			return 0, 0, errors.New("invalid after: must be a non-negative integer")
+		}
+	}
+
+	limit := int64(defaultLimit)
+	if s := query.Get("limit"); s != "" {
+		var err error
+		limit, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return 0, 0, fmt.Errorf("invalid limit: must be between 1 and %d", maxLimit)
+		}
+	}
+	return after, limit, nil
+}
+
+// paginate converts the rows, fetched one past the limit to tell whether another page follows
+func paginate[R, T any](rows []R, limit int64, convert func(R) (int64, T)) Page[T] {
+	page := Page[T]{Items: make([]T, 0, min(int64(len(rows)), limit))}
+	var id int64
+	for _, row := range rows[:min(int64(len(rows)), limit)] {
+		var item T
+		id, item = convert(row)
+		page.Items = append(page.Items, item)
+	}
+	if int64(len(rows)) > limit {
+		page.Next = id
+	}
+	return page
+}
+
+// optional reads a query parameter that is left out of the query when empty
+func optional(r *http.Request, name string) sql.NullString {
+	value := r.URL.Query().Get(name)
+	return sql.NullString{String: value, Valid: value != ""}
+}
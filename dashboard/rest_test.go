@@ -0,0 +1,204 @@
+package dashboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"local/tmo/db"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// decode decodes the JSON body of a response that must have the status
+func decode(t *testing.T, s *Server, path string, status int, v any) {
+	t.Helper()
+	response := get(t, s, path)
+	if response.Code != status || response.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Expected %d, got %d %s", status, response.Code, response.Body)
+	}
+	err := json.NewDecoder(response.Body).Decode(v)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+func TestDevices(t *testing.T) {
+	source := &fakeSource{devices: []db.Device{
+		{ID: 1, Serial: "ABC123", SoftwareVersion: "1.0.0", Label: "home"},
+		{ID: 2, Serial: "ABC123", SoftwareVersion: "1.1.0", Label: "home"},
+		{ID: 5, Serial: "DEF456", SoftwareVersion: "1.0.0", Label: "lab", Isenabled: true},
+	}}
+	s := NewServer(source, nil, log.New(io.Discard, "", 0))
+
+	var page Page[Device]
+	decode(t, s, "/api/devices?limit=2", http.StatusOK, &page)
+	if len(page.Items) != 2 || page.Items[1].SoftwareVersion != "1.1.0" || page.Next != 2 {
+		t.Fatalf("Expected the first 2 devices and a next page, got %+v", page)
+	}
+	// One more than the limit tells whether there is a next page
+	if asked := source.askedPage.(db.ListDevicesPageParams); asked.PageSize != 3 || asked.After != 0 {
+		t.Errorf("Unexpected page asked for: %+v", asked)
+	}
+
+	page = Page[Device]{}
+	decode(t, s, "/api/devices?limit=2&after=2", http.StatusOK, &page)
+	if len(page.Items) != 1 || page.Items[0].Serial != "DEF456" || !page.Items[0].Enabled || page.Next != 0 {
+		t.Errorf("Expected the last device without a next page, got %+v", page)
+	}
+
+	t.Run("Default Limit", func(t *testing.T) {
+		response := get(t, s, "/api/devices")
+		if asked := source.askedPage.(db.ListDevicesPageParams); asked.PageSize != defaultLimit+1 {
+			t.Errorf("Expected the default limit, got %+v", asked)
+		}
+		if strings.Contains(response.Body.String(), "next") {
+			t.Errorf("Expected no next page, got %s", response.Body)
+		}
+	})
+
+	t.Run("Invalid Pages", func(t *testing.T) {
+		for _, query := range []string{"limit=0", "limit=1001", "limit=ten", "after=-1", "after=last"} {
+			var body Error
+			decode(t, s, "/api/devices?"+query, http.StatusBadRequest, &body)
+			if body.Error.Status != http.StatusBadRequest || body.Error.Message == "" {
+				t.Errorf("Expected %q to be rejected with a message, got %+v", query, body)
+			}
+		}
+	})
+}
+
+func TestSnapshots(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	source := &fakeSource{snapshots: []db.Snapshot{
+		{ID: 7, Deviceid: 1, Label: "home", CreatedAt: from, Registration: "registered", HasIpv6: true},
+	}}
+	s := NewServer(source, nil, log.New(io.Discard, "", 0))
+
+	// Pages make ranges longer than the history allows fine
+	var page Page[Snapshot]
+	decode(t, s, "/api/snapshots?label=home&from=2025-01-01T00:00:00Z&to=2025-04-01T00:00:00Z", http.StatusOK, &page)
+	if len(page.Items) != 1 || page.Items[0].DeviceID != 1 || !page.Items[0].HasIPv6 || !page.Items[0].CreatedAt.Equal(from) {
+		t.Errorf("Unexpected snapshots: %+v", page)
+	}
+	asked := source.askedPage.(db.ListSnapshotsPageParams)
+	if !asked.Start.Equal(from) || !asked.End.Equal(time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)) || asked.Label != (sql.NullString{String: "home", Valid: true}) {
+		t.Errorf("Unexpected snapshots asked for: %+v", asked)
+	}
+
+	decode(t, s, "/api/snapshots", http.StatusOK, &page)
+	asked = source.askedPage.(db.ListSnapshotsPageParams)
+	if asked.Label.Valid || asked.End.Sub(asked.Start) != 24*time.Hour {
+		t.Errorf("Expected every gateway over the last day, got %+v", asked)
+	}
+
+	var body Error
+	decode(t, s, "/api/snapshots?from=2025-04-01", http.StatusBadRequest, &body)
+	if !strings.HasPrefix(body.Error.Message, "invalid from") {
+		t.Errorf("Unexpected error: %+v", body)
+	}
+}
+
+func TestSignals(t *testing.T) {
+	source := &fakeSource{page: []db.ListSignalsPageRow{
+		{ID: 3, Snapshotid: 7, Label: "home", Generation: "5G", Band: "n41", Cid: 12, Gnbid: 1, Rsrp: -90, Sinr: 14},
+	}}
+	s := NewServer(source, nil, log.New(io.Discard, "", 0))
+
+	var page Page[Signal]
+	decode(t, s, "/api/signals?generation=5g&band=n41&cid=12&limit=10&after=2", http.StatusOK, &page)
+	if len(page.Items) != 1 || page.Items[0].SnapshotID != 7 || page.Items[0].Rsrp != -90 || page.Items[0].Gnbid != 1 {
+		t.Errorf("Unexpected signals: %+v", page)
+	}
+	asked := source.askedPage.(db.ListSignalsPageParams)
+	if asked.Generation.String != "5G" || asked.Band.String != "n41" || asked.Cid != (sql.NullInt64{Int64: 12, Valid: true}) ||
+		asked.Label.Valid || asked.After != 2 || asked.PageSize != 11 {
+		t.Errorf("Unexpected signals asked for: %+v", asked)
+	}
+
+	t.Run("Invalid Filters", func(t *testing.T) {
+		for query, message := range map[string]string{
+			"generation=3G": "invalid generation",
+			"cid=n41":       "invalid cid",
+			"limit=5000":    "invalid limit",
+			"to=tomorrow":   "invalid to",
+		} {
+			var body Error
+			decode(t, s, "/api/signals?"+query, http.StatusBadRequest, &body)
+			if !strings.HasPrefix(body.Error.Message, message) {
+				t.Errorf("Expected %q for %q, got %+v", message, query, body)
+			}
+		}
+	})
+}
+
+func TestStats(t *testing.T) {
+	source := &fakeSource{summaries: []db.SummarizeSignalsBetweenRow{
+		{Label: "home", Generation: "5G", Band: "n41", Samples: 4, RsrpMin: -100, RsrpAvg: -92.5, RsrpMax: -85, SinrMin: 3, SinrAvg: 9, SinrMax: 15},
+	}}
+	s := NewServer(source, nil, log.New(io.Discard, "", 0))
+
+	var stats Stats
+	decode(t, s, "/api/stats?label=home&from=2025-04-01T00:00:00Z&to=2025-04-02T00:00:00Z", http.StatusOK, &stats)
+	if len(stats.Bands) != 1 || stats.Bands[0].Samples != 4 || stats.Bands[0].Rsrp != (Spread{Min: -100, Avg: -92.5, Max: -85}) ||
+		stats.Bands[0].Sinr.Max != 15 || !stats.From.Equal(time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if asked := source.askedPage.(db.SummarizeSignalsBetweenParams); asked.Label.String != "home" {
+		t.Errorf("Unexpected stats asked for: %+v", asked)
+	}
+
+	source.summaries = nil
+	response := get(t, s, "/api/stats")
+	if !strings.Contains(response.Body.String(), `"bands":[]`) {
+		t.Errorf("Expected no bands, got %s", response.Body)
+	}
+}
+
+func TestLocalTime(t *testing.T) {
+	store := setupLocalStore(t, time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC))
+	s := NewServer(store, nil, log.New(io.Discard, "", 0))
+
+	// Bounds in UTC find the rows stored with the local offset
+	query := "?from=2025-04-01T11:00:00Z&to=2025-04-01T13:00:00Z"
+
+	var snapshots Page[Snapshot]
+	decode(t, s, "/api/snapshots"+query, http.StatusOK, &snapshots)
+	if len(snapshots.Items) != 1 || !snapshots.Items[0].CreatedAt.Equal(time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the snapshot within the UTC range, got %+v", snapshots)
+	}
+
+	var signals Page[Signal]
+	decode(t, s, "/api/signals"+query, http.StatusOK, &signals)
+	if len(signals.Items) != 1 || signals.Items[0].Band != "n41" {
+		t.Errorf("Expected the signal within the UTC range, got %+v", signals)
+	}
+
+	var stats Stats
+	decode(t, s, "/api/stats"+query, http.StatusOK, &stats)
+	if len(stats.Bands) != 1 || stats.Bands[0].Samples != 1 {
+		t.Errorf("Expected the signal to be summarized, got %+v", stats)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	source := &fakeSource{err: errors.New("database is locked")}
+	s := NewServer(source, nil, log.New(io.Discard, "", 0))
+
+	for _, path := range []string{"/api/devices", "/api/snapshots", "/api/signals", "/api/stats", "/api/history"} {
+		var body Error
+		decode(t, s, path, http.StatusInternalServerError, &body)
+		if body.Error.Status != http.StatusInternalServerError || strings.Contains(body.Error.Message, "locked") {
+			t.Errorf("Expected an internal error without details from %s, got %+v", path, body)
+		}
+	}
+
+	var body Error
+	decode(t, s, "/api/unknown", http.StatusNotFound, &body)
+	if body.Error.Message != "not found" {
+		t.Errorf("Unexpected error: %+v", body)
+	}
+}
@@ -0,0 +1,106 @@
+package dashboard
+
+import (
+	"cmp"
+	"local/tmo/db"
+	"slices"
+	"time"
+)
+
+// maxPoints is how many points each series is averaged down to at most, enough for a chart the width of a screen
+const maxPoints = 360
+
+// Point is the average signal of the samples in the step of a series starting at Time
+type Point struct {
+	Time    time.Time `json:"time"`
+	Samples int       `json:"samples"`
+	Rsrp    float64   `json:"rsrp"`
+	Rsrq    float64   `json:"rsrq"`
+	Rssi    float64   `json:"rssi"`
+	Sinr    float64   `json:"sinr"`
+}
+
+// Series is the signal of one gateway on one primary band of a generation over time
+type Series struct {
+	Label      string  `json:"label"`
+	Generation string  `json:"generation"`
+	Band       string  `json:"band"`
+	Points     []Point `json:"points"`
+}
+
+// History is the signal of every gateway between From and To, averaged over steps of Step seconds
+type History struct {
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Step   int64     `json:"step"`
+	Series []Series  `json:"series"`
+}
+
+// seriesKey identifies the series a signal belongs to
+type seriesKey struct {
+	label, generation, band string
+}
+
+// buildHistory groups the signals between from and to into a series per gateway, generation and primary band, each
+// averaged over the same steps so that at most maxPoints are left. The signals must be ordered by time and are only
+// kept if they are for the label, or for any gateway if the label is empty.
+func buildHistory(rows []db.ListSignalsBetweenRow, label string, from, to time.Time) History {
+	// Whole seconds, rounded up so the steps cover the range
+	step := max((to.Sub(from) / maxPoints).Truncate(time.Second), time.Second)
+	if step*maxPoints < to.Sub(from) {
+		step += time.Second
+	}
+	history := History{From: from, To: to, Step: int64(step / time.Second), Series: []Series{}}
+
+	series := make(map[seriesKey]*Series)
+	var keys []seriesKey
+	// sums holds the totals of the bucket each series is filling
+	sums := make(map[seriesKey]*Point)
+	flush := func(key seriesKey) {
+		sum := sums[key]
+		if sum == nil {
+			return
+		}
+		n := float64(sum.Samples)
+		series[key].Points = append(series[key].Points, Point{
+			Time: sum.Time, Samples: sum.Samples, Rsrp: sum.Rsrp / n, Rsrq: sum.Rsrq / n, Rssi: sum.Rssi / n, Sinr: sum.Sinr / n,
+		})
+		delete(sums, key)
+	}
+
+	for _, row := range rows {
+		if (label != "" && row.Label != label) || row.CreatedAt.Before(from) || !row.CreatedAt.Before(to) {
+			continue
+		}
+
+		key := seriesKey{row.Label, row.Generation, row.Band}
+		if series[key] == nil {
+			series[key] = &Series{Label: row.Label, Generation: row.Generation, Band: row.Band}
+			keys = append(keys, key)
+		}
+
+		bucket := from.Add(row.CreatedAt.Sub(from) / step * step)
+		if sum := sums[key]; sum != nil && !sum.Time.Equal(bucket) {
+			flush(key)
+		}
+		sum := sums[key]
+		if sum == nil {
+			sum = &Point{Time: bucket}
+			sums[key] = sum
+		}
+		sum.Samples++
+		sum.Rsrp += float64(row.Rsrp)
+		sum.Rsrq += float64(row.Rsrq)
+		sum.Rssi += float64(row.Rssi)
+		sum.Sinr += float64(row.Sinr)
+	}
+
+	slices.SortFunc(keys, func(a, b seriesKey) int {
+		return cmp.Or(cmp.Compare(a.label, b.label), cmp.Compare(a.generation, b.generation), cmp.Compare(a.band, b.band))
+	})
+	for _, key := range keys {
+		flush(key)
+		history.Series = append(history.Series, *series[key])
+	}
+	return history
+}
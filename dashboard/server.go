@@ -0,0 +1,218 @@
+package dashboard
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"local/tmo/api"
+	"local/tmo/db"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// maxRange is the longest history served at once, longer ranges are better read from the report
+const maxRange = 31 * 24 * time.Hour
+
+// defaultRange is the history shown when no range is given
+const defaultRange = 24 * time.Hour
+
+//go:embed static
+var static embed.FS
+
+// Source is the store the signal history and the REST API are read from
+type Source interface {
+	ListSignalsBetween(ctx context.Context, arg db.ListSignalsBetweenParams) ([]db.ListSignalsBetweenRow, error)
+	ListDevicesPage(ctx context.Context, arg db.ListDevicesPageParams) ([]db.Device, error)
+	ListSnapshotsPage(ctx context.Context, arg db.ListSnapshotsPageParams) ([]db.Snapshot, error)
+	ListSignalsPage(ctx context.Context, arg db.ListSignalsPageParams) ([]db.ListSignalsPageRow, error)
+	SummarizeSignalsBetween(ctx context.Context, arg db.SummarizeSignalsBetweenParams) ([]db.SummarizeSignalsBetweenRow, error)
+}
+
+// Server serves the dashboard, the latest poll of each gateway and their signal history
+type Server struct {
+	source Source
+	labels []string
+	logger *log.Logger
+	mux    *http.ServeMux
+
+	mu     sync.Mutex
+	status map[string]*Status
+}
+
+// NewServer creates a server for the gateways with the given labels, reading their history from the source
+func NewServer(source Source, labels []string, logger *log.Logger) *Server {
+	s := &Server{
+		source: source,
+		labels: labels,
+		logger: logger,
+		mux:    http.NewServeMux(),
+		status: make(map[string]*Status),
+	}
+
+	assets, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	s.mux.Handle("GET /", http.FileServerFS(assets))
+	s.mux.HandleFunc("GET /api/status", s.handleStatus)
+	s.mux.HandleFunc("GET /api/history", s.handleHistory)
+	s.mux.HandleFunc("GET /api/devices", s.handleDevices)
+	s.mux.HandleFunc("GET /api/snapshots", s.handleSnapshots)
+	s.mux.HandleFunc("GET /api/signals", s.handleSignals)
+	s.mux.HandleFunc("GET /api/stats", s.handleStats)
+	s.mux.HandleFunc("GET /api/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "not found")
+	})
+	return s
+}
+
+// Handle serves another handler alongside the dashboard
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// ServeHTTP routes requests to the dashboard and its data
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ObservePoll records the outcome of a poll of the gateway with the given label. A response is kept even if the poll
+// failed after receiving it, as a gateway without 5G fails to be stored but is what the dashboard is there to show.
+func (s *Server) ObservePoll(label string, gateway api.GatewayResponse, duration time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := s.status[label]
+	if status == nil {
+		status = &Status{Label: label}
+		s.status[label] = status
+	}
+
+	status.Polled = time.Now()
+	status.Error = ""
+	if err != nil {
+		status.Error = err.Error()
+	}
+	if received(gateway) {
+		status.Updated = status.Polled
+		status.Gateway = &gateway
+		status.FourG = newSignalStatus(gateway.Signal.FourG)
+		status.FiveG = newSignalStatus(gateway.Signal.FiveG)
+	}
+}
+
+// received reports whether a poll got as far as a response from the gateway, a poll that failed before returns an
+// empty one
+func received(gateway api.GatewayResponse) bool {
+	return gateway.Time.LocalTime != 0 || gateway.Device.Serial != "" || len(gateway.Signal.FourG.Bands) > 0 || len(gateway.Signal.FiveG.Bands) > 0
+}
+
+// Statuses returns the status of every configured gateway in the order they are configured, then of any other
+// gateway polled
+func (s *Server) Statuses() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var statuses []Status
+	for _, label := range s.labels {
+		status := s.status[label]
+		if status == nil {
+			status = &Status{Label: label}
+		}
+		statuses = append(statuses, status.withVerdict())
+	}
+	for _, label := range slices.Sorted(maps.Keys(s.status)) {
+		if !slices.Contains(s.labels, label) {
+			statuses = append(statuses, s.status[label].withVerdict())
+		}
+	}
+	return statuses
+}
+
+// handleStatus writes the status of every gateway
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.Statuses())
+}
+
+// handleHistory writes the signal history between the from and to query parameters, the last day by default,
+// of the gateway in the label parameter or of every gateway
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseRange(r, time.Now(), maxRange)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rows, err := s.source.ListSignalsBetween(r.Context(), db.ListSignalsBetweenParams{Start: from, End: to})
+	if err != nil {
+		s.logger.Printf("Failed to list signals: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to list signals")
+		return
+	}
+
+	writeJSON(w, buildHistory(rows, r.URL.Query().Get("label"), from, to))
+}
+
+// parseRange reads the RFC 3339 from and to query parameters, to defaulting to now and from to a day before to, and
+// returns them in local time. The range may not be longer than longest, unless longest is 0.
+func parseRange(r *http.Request, now time.Time, longest time.Duration) (time.Time, time.Time, error) {
+	query := r.URL.Query()
+
+	to := now
+	if s := query.Get("to"); s != "" {
+		var err error
+		to, err = time.Parse(time.RFC3339, s)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+
+	from := to.Add(-defaultRange)
+	if s := query.Get("from"); s != "" {
+		var err error
+		from, err = time.Parse(time.RFC3339, s)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("from must be before to")
+	}
+	if longest != 0 && to.Sub(from) > longest {
+		return time.Time{}, time.Time{}, fmt.Errorf("range must not be longer than %d days", longest/(24*time.Hour))
+	}
+	// Times are stored with the local offset and compared as text, so the bounds must have it too
+	return from.Local(), to.Local(), nil
+}
+
+// writeJSON writes the value as a JSON response
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// Error is the body of every error response of the API
+type Error struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail is the status of an error response and what went wrong
+type ErrorDetail struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// writeError writes an error response with the status and message
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Error{Error: ErrorDetail{Status: status, Message: message}})
+}
@@ -0,0 +1,305 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"local/tmo/api"
+	"local/tmo/db"
+	"local/tmo/storage"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSource returns its rows, or err, whatever the range asked for. Pages are cut by id from the rows.
+type fakeSource struct {
+	signals   []db.ListSignalsBetweenRow
+	devices   []db.Device
+	snapshots []db.Snapshot
+	page      []db.ListSignalsPageRow
+	summaries []db.SummarizeSignalsBetweenRow
+	err       error
+	asked     db.ListSignalsBetweenParams
+	askedPage any
+}
+
+func (f *fakeSource) ListSignalsBetween(ctx context.Context, arg db.ListSignalsBetweenParams) ([]db.ListSignalsBetweenRow, error) {
+	f.asked = arg
+	return f.signals, f.err
+}
+
+func (f *fakeSource) ListDevicesPage(ctx context.Context, arg db.ListDevicesPageParams) ([]db.Device, error) {
+	f.askedPage = arg
+	return cut(f.devices, arg.After, arg.PageSize, func(device db.Device) int64 { return device.ID }), f.err
+}
+
+func (f *fakeSource) ListSnapshotsPage(ctx context.Context, arg db.ListSnapshotsPageParams) ([]db.Snapshot, error) {
+	f.askedPage = arg
+	return cut(f.snapshots, arg.After, arg.PageSize, func(snapshot db.Snapshot) int64 { return snapshot.ID }), f.err
+}
+
+func (f *fakeSource) ListSignalsPage(ctx context.Context, arg db.ListSignalsPageParams) ([]db.ListSignalsPageRow, error) {
+	f.askedPage = arg
+	return cut(f.page, arg.After, arg.PageSize, func(signal db.ListSignalsPageRow) int64 { return signal.ID }), f.err
+}
+
+func (f *fakeSource) SummarizeSignalsBetween(ctx context.Context, arg db.SummarizeSignalsBetweenParams) ([]db.SummarizeSignalsBetweenRow, error) {
+	f.askedPage = arg
+	return f.summaries, f.err
+}
+
+// cut returns up to size of the rows with an id above after
+func cut[T any](rows []T, after, size int64, id func(T) int64) []T {
+	var page []T
+	for _, row := range rows {
+		if id(row) > after && int64(len(page)) < size {
+			page = append(page, row)
+		}
+	}
+	return page
+}
+
+// get serves a GET request for the path and returns the response
+func get(t *testing.T, s *Server, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	return recorder
+}
+
+// gateway returns a response with a fair 4G signal and the given 5G signal
+func gateway(fiveGBands []string, rsrp, sinr int) api.GatewayResponse {
+	return api.GatewayResponse{
+		Device: api.Device{Serial: "ABC123"},
+		Time:   api.Time{LocalTime: 1743465600},
+		Signal: api.Signal{
+			FourG: api.SignalStats{Bands: []string{"b66"}, Rsrp: -95, Sinr: 10},
+			FiveG: api.SignalStats{Bands: fiveGBands, Rsrp: rsrp, Sinr: sinr},
+		},
+	}
+}
+
+func TestStatus(t *testing.T) {
+	s := NewServer(&fakeSource{}, []string{"home", "lab"}, log.New(io.Discard, "", 0))
+
+	s.ObservePoll("home", gateway([]string{"n41"}, -85, 15), time.Second, nil)
+	s.ObservePoll("office", gateway(nil, 0, 0), time.Second, errors.New("gateway response has no 4G or no 5G bands"))
+
+	response := get(t, s, "/api/status")
+	if response.Code != http.StatusOK || response.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Unexpected response: %d %s", response.Code, response.Body)
+	}
+	var statuses []Status
+	err := json.NewDecoder(response.Body).Decode(&statuses)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(statuses) != 3 || statuses[0].Label != "home" || statuses[1].Label != "lab" || statuses[2].Label != "office" {
+		t.Fatalf("Expected the configured gateways first, got %+v", statuses)
+	}
+	if statuses[0].Verdict != Good || statuses[0].FiveG.Bands[0] != "n41" || statuses[0].FourG.Quality != Fair {
+		t.Errorf("Unexpected home status: %+v", statuses[0])
+	}
+	if statuses[1].Verdict != VerdictUnknown {
+		t.Errorf("Expected an unknown verdict before the first poll, got %q", statuses[1].Verdict)
+	}
+	if statuses[2].Verdict != VerdictNoFiveG || statuses[2].Error == "" {
+		t.Errorf("Expected no 5G with the error of the poll, got %+v", statuses[2])
+	}
+
+	t.Run("Unreachable", func(t *testing.T) {
+		s.ObservePoll("home", api.GatewayResponse{}, time.Second, errors.New("connection refused"))
+		status := s.Statuses()[0]
+		if status.Verdict != VerdictUnreachable || status.Gateway == nil || status.FiveG.Rsrp != -85 {
+			t.Errorf("Expected the last response to be kept while unreachable, got %+v", status)
+		}
+	})
+}
+
+func TestQuality(t *testing.T) {
+	tests := []struct {
+		stats    api.SignalStats
+		expected string
+	}{
+		{api.SignalStats{Bands: []string{"n41"}, Rsrp: -75, Sinr: 25}, Excellent},
+		{api.SignalStats{Bands: []string{"n41"}, Rsrp: -75, Sinr: 14}, Good},
+		{api.SignalStats{Bands: []string{"n41"}, Rsrp: -95, Sinr: 25}, Fair},
+		{api.SignalStats{Bands: []string{"n41"}, Rsrp: -105, Sinr: 25}, Poor},
+		{api.SignalStats{Bands: []string{"n41"}, Rsrp: -80, Sinr: -1}, Poor},
+		{api.SignalStats{Rsrp: -75, Sinr: 25}, NoSignal},
+	}
+	for _, test := range tests {
+		if got := Quality(test.stats); got != test.expected {
+			t.Errorf("Expected %s for %+v, got %s", test.expected, test.stats, got)
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	from := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	signal := func(label, generation, band string, minutes int, rsrp int64) db.ListSignalsBetweenRow {
+		return db.ListSignalsBetweenRow{
+			Label: label, Generation: generation, Band: band, CreatedAt: from.Add(time.Duration(minutes) * time.Minute),
+			Rsrp: rsrp, Rsrq: -10, Rssi: -70, Sinr: 12,
+		}
+	}
+	source := &fakeSource{signals: []db.ListSignalsBetweenRow{
+		signal("home", "5G", "n41", 0, -80),
+		signal("home", "4G", "b66", 0, -90),
+		signal("home", "5G", "n41", 1, -90),
+		signal("home", "5G", "n25", 10, -100),
+		signal("home", "5G", "n41", 12, -84),
+		signal("lab", "5G", "n41", 5, -70),
+	}}
+	s := NewServer(source, []string{"home", "lab"}, log.New(io.Discard, "", 0))
+
+	response := get(t, s, "/api/history?label=home&from=2025-04-01T00:00:00Z&to=2025-04-01T06:00:00Z")
+	if response.Code != http.StatusOK {
+		t.Fatalf("Unexpected response: %d %s", response.Code, response.Body)
+	}
+	var history History
+	err := json.NewDecoder(response.Body).Decode(&history)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if !source.asked.Start.Equal(from) || !source.asked.End.Equal(from.Add(6*time.Hour)) {
+		t.Errorf("Unexpected range asked for: %+v", source.asked)
+	}
+	// 6 hours over 360 points is a minute a step
+	if history.Step != 60 || len(history.Series) != 3 {
+		t.Fatalf("Expected 3 series in steps of 60s, got %+v", history)
+	}
+
+	fourG, n25, n41 := history.Series[0], history.Series[1], history.Series[2]
+	if fourG.Generation != "4G" || n25.Band != "n25" || n41.Band != "n41" || n41.Label != "home" {
+		t.Fatalf("Unexpected series order: %+v", history.Series)
+	}
+	if len(n41.Points) != 3 || n41.Points[0].Rsrp != -80 || n41.Points[2].Rsrp != -84 || !n41.Points[2].Time.Equal(from.Add(12*time.Minute)) {
+		t.Errorf("Unexpected n41 points: %+v", n41.Points)
+	}
+
+	t.Run("Averaged", func(t *testing.T) {
+		response := get(t, s, "/api/history?label=home&from=2025-04-01T00:00:00Z&to=2025-04-08T00:00:00Z")
+		var history History
+		json.NewDecoder(response.Body).Decode(&history)
+		n41 := history.Series[2]
+		// A week over 360 points is 28 minutes a step
+		if history.Step != 1680 || len(n41.Points) != 1 || n41.Points[0].Samples != 3 || n41.Points[0].Rsrp != -84.66666666666667 {
+			t.Errorf("Expected the n41 signals to be averaged into one point, got %+v", history)
+		}
+	})
+
+	t.Run("Invalid Ranges", func(t *testing.T) {
+		for _, query := range []string{
+			"from=yesterday",
+			"to=2025-04-01",
+			"from=2025-04-02T00:00:00Z&to=2025-04-01T00:00:00Z",
+			"from=2025-01-01T00:00:00Z&to=2025-04-01T00:00:00Z",
+		} {
+			response := get(t, s, "/api/history?"+query)
+			if response.Code != http.StatusBadRequest || !strings.Contains(response.Body.String(), `"status":400`) {
+				t.Errorf("Expected %q to be rejected, got %d", query, response.Code)
+			}
+		}
+	})
+
+	t.Run("Default Range", func(t *testing.T) {
+		response := get(t, s, "/api/history")
+		if source.asked.End.Sub(source.asked.Start) != 24*time.Hour || time.Since(source.asked.End) > time.Minute {
+			t.Errorf("Expected the last day, got %+v", source.asked)
+		}
+		// The signals are all older, an empty list is easier to chart than null
+		if !strings.Contains(response.Body.String(), `"series":[]`) {
+			t.Errorf("Expected no series, got %s", response.Body)
+		}
+	})
+
+	t.Run("Storage Error", func(t *testing.T) {
+		source.err = errors.New("database is locked")
+		response := get(t, s, "/api/history")
+		if response.Code != http.StatusInternalServerError || strings.Contains(response.Body.String(), "locked") {
+			t.Errorf("Expected an internal error without details, got %d %s", response.Code, response.Body)
+		}
+	})
+}
+
+// setupLocalStore sets time.Local to a zone east of UTC, restoring it after the test, and opens a migrated SQLite
+// store with one snapshot of the home gateway at the given time and its 5G signal, stored the way the poller does
+func setupLocalStore(t *testing.T, at time.Time) storage.Store {
+	t.Helper()
+	local := time.Local
+	time.Local = time.FixedZone("EDT", -4*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	ctx := context.Background()
+	store, err := storage.OpenSQLite(ctx, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	migrator, err := store.Migrator()
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	_, err = migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	tx, err := store.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin: %v", err)
+	}
+	defer tx.Rollback()
+	device, err := tx.CreateDevice(ctx, db.CreateDeviceParams{Serial: "ABC123", SoftwareVersion: "1.0.0", Label: "home"})
+	if err != nil {
+		t.Fatalf("Failed to create device: %v", err)
+	}
+	snapshot, err := tx.CreateSnapshot(ctx, db.CreateSnapshotParams{Deviceid: device.ID, CreatedAt: at.Local(), Label: "home"})
+	if err != nil {
+		t.Fatalf("Failed to create snapshot: %v", err)
+	}
+	_, err = tx.CreateSignal(ctx, db.CreateSignalParams{Snapshotid: snapshot.ID, Generation: "5G", Band: "n41", Rsrp: -90, Sinr: 12})
+	if err != nil {
+		t.Fatalf("Failed to create signal: %v", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	return store
+}
+
+func TestHistoryLocalTime(t *testing.T) {
+	store := setupLocalStore(t, time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC))
+	s := NewServer(store, []string{"home"}, log.New(io.Discard, "", 0))
+
+	// The dashboard asks for ranges in UTC, whatever the zone of the host
+	var history History
+	decode(t, s, "/api/history?from=2025-04-01T11:00:00Z&to=2025-04-01T13:00:00Z", http.StatusOK, &history)
+	if len(history.Series) != 1 || len(history.Series[0].Points) != 1 || history.Series[0].Points[0].Rsrp != -90 {
+		t.Errorf("Expected the signal within the UTC range, got %+v", history)
+	}
+}
+
+func TestAssets(t *testing.T) {
+	s := NewServer(&fakeSource{}, nil, log.New(io.Discard, "", 0))
+
+	for path, expected := range map[string]string{"/": "<title>Gateway Signal</title>", "/app.js": "api/history", "/style.css": ".chart"} {
+		response := get(t, s, path)
+		if response.Code != http.StatusOK || !strings.Contains(response.Body.String(), expected) {
+			t.Errorf("Expected %s to contain %q, got %d", path, expected, response.Code)
+		}
+	}
+	if response := get(t, s, "/missing.js"); response.Code != http.StatusNotFound {
+		t.Errorf("Expected a missing asset to be not found, got %d", response.Code)
+	}
+}
@@ -0,0 +1,318 @@
+"use strict";
+
+// How often the status and a relative range are refreshed
+const STATUS_REFRESH_MS = 30 * 1000;
+const HISTORY_REFRESH_MS = 60 * 1000;
+
+// Line colors, assigned to the series in the order they are first seen
+const COLORS = ["#1971c2", "#e8590c", "#2f9e44", "#ae3ec9", "#c92a2a", "#0c8599", "#5c940d", "#862e9c"];
+
+const VERDICTS = {
+  excellent: "5G is excellent",
+  good: "5G is good",
+  fair: "5G is fair",
+  poor: "5G is poor",
+  "no 5G": "No 5G right now",
+  unreachable: "Gateway unreachable",
+  unknown: "Waiting for the first poll",
+};
+
+const state = {
+  // range is a relative range in hours, or null for the custom from and to
+  range: 24,
+  from: null,
+  to: null,
+  label: "",
+  colors: new Map(),
+};
+
+function parseHours(range) {
+  return parseInt(range, 10);
+}
+
+function formatTime(date) {
+  return date.toLocaleString([], { month: "short", day: "numeric", hour: "2-digit", minute: "2-digit" });
+}
+
+function element(tag, attributes = {}, text) {
+  const node = document.createElement(tag);
+  for (const [name, value] of Object.entries(attributes)) {
+    node.setAttribute(name, value);
+  }
+  if (text !== undefined) {
+    node.textContent = text;
+  }
+  return node;
+}
+
+function svgElement(tag, attributes = {}, text) {
+  const node = document.createElementNS("http://www.w3.org/2000/svg", tag);
+  for (const [name, value] of Object.entries(attributes)) {
+    node.setAttribute(name, value);
+  }
+  if (text !== undefined) {
+    node.textContent = text;
+  }
+  return node;
+}
+
+async function fetchJSON(url) {
+  const response = await fetch(url);
+  if (!response.ok) {
+    const body = await response.json().catch(() => null);
+    throw new Error(body?.error?.message || response.statusText);
+  }
+  return response.json();
+}
+
+// Status panel
+
+function signalRow(name, signal) {
+  const row = element("tr");
+  row.append(element("th", {}, name));
+  if (!signal || signal.quality === "none") {
+    row.append(element("td", { colspan: 4, class: "quality-none" }, "not connected"));
+    return row;
+  }
+  row.append(
+    element("td", {}, signal.bands.join(", ")),
+    element("td", {}, `RSRP ${signal.rsrp}`),
+    element("td", {}, `SINR ${signal.sinr}`),
+    element("td", { class: `quality-${signal.quality}` }, signal.quality),
+  );
+  return row;
+}
+
+function statusCard(status) {
+  const card = element("article", { class: `card ${status.verdict.replace(" ", "-").toLowerCase()}` });
+  card.append(
+    element("h2", {}, status.label || "Gateway"),
+    element("p", { class: "verdict" }, VERDICTS[status.verdict] || status.verdict),
+  );
+
+  if (status.gateway) {
+    const table = element("table");
+    table.append(signalRow("5G", status["5g"]), signalRow("4G", status["4g"]));
+    card.append(table);
+    card.append(element("p", { class: "since" }, `Updated ${formatTime(new Date(status.updated))}`));
+  }
+  if (status.error) {
+    card.append(element("p", { class: "error" }, `Last poll failed: ${status.error}`));
+  }
+  return card;
+}
+
+async function refreshStatus() {
+  try {
+    const statuses = await fetchJSON("api/status");
+    document.getElementById("status").replaceChildren(...statuses.map(statusCard));
+    document.getElementById("updated").textContent = `Refreshed ${formatTime(new Date())}`;
+    updateGatewayChoice(statuses.map((status) => status.label));
+  } catch (err) {
+    showError(`Failed to load the status: ${err.message}`);
+  }
+}
+
+function updateGatewayChoice(labels) {
+  const select = document.getElementById("gateway");
+  document.getElementById("gateway-choice").hidden = labels.length < 2;
+  const known = new Set(Array.from(select.options, (option) => option.value));
+  for (const label of labels) {
+    if (!known.has(label)) {
+      select.append(element("option", { value: label }, label));
+    }
+  }
+}
+
+// Charts
+
+function seriesName(series) {
+  const parts = [series.generation, series.band];
+  if (series.label) {
+    parts.unshift(series.label);
+  }
+  return parts.join(" ");
+}
+
+function seriesColor(name) {
+  if (!state.colors.has(name)) {
+    state.colors.set(name, COLORS[state.colors.size % COLORS.length]);
+  }
+  return state.colors.get(name);
+}
+
+// niceStep picks a round interval for about count ticks over the span
+function niceStep(span, count) {
+  const raw = span / count;
+  const magnitude = Math.pow(10, Math.floor(Math.log10(raw)));
+  for (const factor of [1, 2, 5, 10]) {
+    if (raw <= factor * magnitude) {
+      return factor * magnitude;
+    }
+  }
+  return 10 * magnitude;
+}
+
+function drawChart(svg, metric, history) {
+  const width = svg.clientWidth || 600;
+  const height = svg.clientHeight || 220;
+  const margin = { top: 10, right: 10, bottom: 24, left: 40 };
+  svg.setAttribute("viewBox", `0 0 ${width} ${height}`);
+  svg.replaceChildren();
+
+  const from = new Date(history.from).getTime();
+  const to = new Date(history.to).getTime();
+  const values = history.series.flatMap((series) => series.points.map((point) => point[metric]));
+  if (values.length === 0) {
+    svg.append(svgElement("text", { x: width / 2, y: height / 2, "text-anchor": "middle", class: "empty" }, "No signal stored in this range"));
+    return;
+  }
+
+  let min = Math.min(...values);
+  let max = Math.max(...values);
+  if (min === max) {
+    min -= 1;
+    max += 1;
+  }
+  const yStep = niceStep(max - min, 4);
+  min = Math.floor(min / yStep) * yStep;
+  max = Math.ceil(max / yStep) * yStep;
+
+  const x = (time) => margin.left + ((time - from) / (to - from)) * (width - margin.left - margin.right);
+  const y = (value) => height - margin.bottom - ((value - min) / (max - min)) * (height - margin.top - margin.bottom);
+
+  for (let value = min; value <= max; value += yStep) {
+    svg.append(
+      svgElement("line", { x1: margin.left, x2: width - margin.right, y1: y(value), y2: y(value), class: "axis" }),
+      svgElement("text", { x: margin.left - 6, y: y(value) + 3, "text-anchor": "end", class: "tick" }, String(Math.round(value * 100) / 100)),
+    );
+  }
+  const ticks = 5;
+  for (let i = 0; i <= ticks; i++) {
+    const time = from + ((to - from) * i) / ticks;
+    const anchor = i === 0 ? "start" : i === ticks ? "end" : "middle";
+    svg.append(svgElement("text", { x: x(time), y: height - 6, "text-anchor": anchor, class: "tick" }, formatTime(new Date(time))));
+  }
+
+  // A line is broken where no signal was stored for more than a few steps, while the gateway was down
+  const gap = Math.max(history.step * 1000 * 3, 15 * 60 * 1000);
+  for (const series of history.series) {
+    let path = "";
+    let last = null;
+    for (const point of series.points) {
+      const time = new Date(point.time).getTime();
+      path += `${last === null || time - last > gap ? "M" : "L"}${x(time).toFixed(1)},${y(point[metric]).toFixed(1)}`;
+      last = time;
+    }
+    const line = svgElement("path", { d: path, class: "line", stroke: seriesColor(seriesName(series)) });
+    line.append(svgElement("title", {}, seriesName(series)));
+    svg.append(line);
+  }
+}
+
+function drawLegend(history) {
+  const items = history.series.map((series) => {
+    const name = seriesName(series);
+    const item = element("li");
+    const swatch = element("span");
+    swatch.style.background = seriesColor(name);
+    item.append(swatch, name);
+    return item;
+  });
+  document.getElementById("legend").replaceChildren(...items);
+}
+
+async function refreshHistory() {
+  const params = new URLSearchParams();
+  if (state.range !== null) {
+    const to = new Date();
+    params.set("from", new Date(to.getTime() - state.range * 3600 * 1000).toISOString());
+    params.set("to", to.toISOString());
+  } else {
+    params.set("from", state.from.toISOString());
+    params.set("to", state.to.toISOString());
+  }
+  if (state.label) {
+    params.set("label", state.label);
+  }
+
+  try {
+    const history = await fetchJSON(`api/history?${params}`);
+    for (const figure of document.querySelectorAll(".chart")) {
+      drawChart(figure.querySelector("svg"), figure.dataset.metric, history);
+    }
+    drawLegend(history);
+    hideError();
+  } catch (err) {
+    showError(`Failed to load the history: ${err.message}`);
+  }
+}
+
+function showError(message) {
+  const error = document.getElementById("error");
+  error.textContent = message;
+  error.hidden = false;
+}
+
+function hideError() {
+  document.getElementById("error").hidden = true;
+}
+
+function selectButton(selected) {
+  for (const button of document.querySelectorAll(".ranges button")) {
+    button.classList.toggle("selected", button === selected);
+  }
+}
+
+function setup() {
+  for (const button of document.querySelectorAll(".ranges button")) {
+    button.addEventListener("click", () => {
+      state.range = parseHours(button.dataset.range);
+      selectButton(button);
+      refreshHistory();
+    });
+  }
+
+  document.getElementById("custom").addEventListener("submit", (event) => {
+    event.preventDefault();
+    const form = event.target;
+    const from = new Date(form.elements.from.value);
+    const to = new Date(form.elements.to.value);
+    if (!(from < to)) {
+      showError("The start of the range must be before its end");
+      return;
+    }
+    state.range = null;
+    state.from = from;
+    state.to = to;
+    selectButton(null);
+    refreshHistory();
+  });
+
+  document.getElementById("gateway").addEventListener("change", (event) => {
+    state.label = event.target.value;
+    refreshHistory();
+  });
+
+  let resizing;
+  window.addEventListener("resize", () => {
+    clearTimeout(resizing);
+    resizing = setTimeout(refreshHistory, 250);
+  });
+
+  // Polls are pushed as they complete, the interval covers the stream while it reconnects
+  const stream = new EventSource("api/stream");
+  stream.addEventListener("poll", refreshStatus);
+  stream.addEventListener("failure", refreshStatus);
+
+  refreshStatus();
+  refreshHistory();
+  setInterval(refreshStatus, STATUS_REFRESH_MS);
+  setInterval(() => {
+    if (state.range !== null) {
+      refreshHistory();
+    }
+  }, HISTORY_REFRESH_MS);
+}
+
+setup();
@@ -0,0 +1,99 @@
+package dashboard
+
+import (
+	"local/tmo/api"
+	"time"
+)
+
+// Signal qualities, from the worst of the RSRP and SINR ratings
+const (
+	Excellent = "excellent"
+	Good      = "good"
+	Fair      = "fair"
+	Poor      = "poor"
+	// NoSignal is the quality of a generation the gateway reports no band for
+	NoSignal = "none"
+)
+
+// Verdicts sum up the 5G connection of a gateway for the status panel
+const (
+	// VerdictUnknown is the verdict before the first response
+	VerdictUnknown = "unknown"
+	// VerdictUnreachable is the verdict when the latest poll got no response
+	VerdictUnreachable = "unreachable"
+	// VerdictNoFiveG is the verdict when the gateway reports no 5G band
+	VerdictNoFiveG = "no 5G"
+)
+
+// Status is what is known about a gateway from its polls
+type Status struct {
+	Label string `json:"label"`
+	// Polled is when the gateway was last polled and Error why that poll failed, if it did
+	Polled time.Time `json:"polled"`
+	Error  string    `json:"error,omitempty"`
+	// Updated is when the latest response was received, Gateway holds it
+	Updated time.Time            `json:"updated"`
+	Gateway *api.GatewayResponse `json:"gateway,omitempty"`
+	FourG   *SignalStatus        `json:"4g,omitempty"`
+	FiveG   *SignalStatus        `json:"5g,omitempty"`
+	// Verdict is the quality of the 5G signal, or why there is none
+	Verdict string `json:"verdict"`
+}
+
+// SignalStatus is the latest signal of one generation with its quality
+type SignalStatus struct {
+	Bands   []string `json:"bands"`
+	Bars    float64  `json:"bars"`
+	Rsrp    int      `json:"rsrp"`
+	Rsrq    int      `json:"rsrq"`
+	Rssi    int      `json:"rssi"`
+	Sinr    int      `json:"sinr"`
+	Quality string   `json:"quality"`
+}
+
+// newSignalStatus rates the signal of one generation
+func newSignalStatus(stats api.SignalStats) *SignalStatus {
+	return &SignalStatus{
+		Bands:   stats.Bands,
+		Bars:    stats.Bars,
+		Rsrp:    stats.Rsrp,
+		Rsrq:    stats.Rsrq,
+		Rssi:    stats.Rssi,
+		Sinr:    stats.Sinr,
+		Quality: Quality(stats),
+	}
+}
+
+// withVerdict returns a copy of the status with its verdict worked out
+func (s Status) withVerdict() Status {
+	switch {
+	case s.Gateway == nil:
+		s.Verdict = VerdictUnknown
+	case s.Updated.Before(s.Polled):
+		s.Verdict = VerdictUnreachable
+	case s.FiveG.Quality == NoSignal:
+		s.Verdict = VerdictNoFiveG
+	default:
+		s.Verdict = s.FiveG.Quality
+	}
+	return s
+}
+
+// Quality rates a signal by the worse of its RSRP and SINR, with the thresholds commonly used for LTE and NR
+func Quality(stats api.SignalStats) string {
+	if len(stats.Bands) == 0 {
+		return NoSignal
+	}
+
+	ratings := []string{Poor, Fair, Good, Excellent}
+	rate := func(value int, thresholds [3]int) int {
+		rating := 0
+		for _, threshold := range thresholds {
+			if value >= threshold {
+				rating++
+			}
+		}
+		return rating
+	}
+	return ratings[min(rate(stats.Rsrp, [3]int{-100, -90, -80}), rate(stats.Sinr, [3]int{0, 13, 20}))]
+}
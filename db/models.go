@@ -8,6 +8,44 @@ import (
 	"time"
 )
 
+type Cell struct {
+	ID          int64
+	Label       string
+	Generation  string
+	Enbid       int64
+	Gnbid       int64
+	Cid         int64
+	Bands       string
+	FirstSeen   time.Time
+	LastSeen    time.Time
+	SampleCount int64
+	RsrpAvg     float64
+	RsrqAvg     float64
+	RssiAvg     float64
+	SinrAvg     float64
+}
+
+type ClientDevice struct {
+	ID        int64
+	Label     string
+	Mac       string
+	Name      string
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+type ClientObservation struct {
+	ID             int64
+	Clientdeviceid int64
+	Label          string
+	CreatedAt      time.Time
+	Connection     string
+	Connected      bool
+	Ipv4           string
+	Ipv6           string
+	Signal         int64
+}
+
 type Device struct {
 	ID              int64
 	FriendlyName    string
@@ -24,6 +62,22 @@ type Device struct {
 	SoftwareVersion string
 	Type            string
 	UpdateState     string
+	Label           string
+}
+
+type Event struct {
+	ID        int64
+	Label     string
+	Type      string
+	CreatedAt time.Time
+	OldValue  string
+	NewValue  string
+	Detail    string
+}
+
+type RollupWatermark struct {
+	ID         int64
+	Snapshotid int64
 }
 
 type Signal struct {
@@ -42,9 +96,97 @@ type Signal struct {
 	Sinr        int64
 }
 
-type Snapshot struct {
+type SignalBand struct {
 	ID        int64
-	Deviceid  int64
-	CreatedAt time.Time
-	Uptime    int64
+	Signalid  int64
+	Band      string
+	IsPrimary bool
+}
+
+type SignalChannel struct {
+	ID        int64
+	Signalid  int64
+	Pci       int64
+	Arfcn     int64
+	Bandwidth string
+	Cqi       int64
+	Tac       string
+	Plmn      string
+	Ecgi      string
+}
+
+type SignalRollupDay struct {
+	ID          int64
+	PeriodStart time.Time
+	Generation  string
+	Band        string
+	Cid         int64
+	Enbid       int64
+	Gnbid       int64
+	SampleCount int64
+	RsrpMin     int64
+	RsrpMax     int64
+	RsrpAvg     float64
+	RsrpP5      int64
+	RsrpP95     int64
+	RsrqMin     int64
+	RsrqMax     int64
+	RsrqAvg     float64
+	RsrqP5      int64
+	RsrqP95     int64
+	RssiMin     int64
+	RssiMax     int64
+	RssiAvg     float64
+	RssiP5      int64
+	RssiP95     int64
+	SinrMin     int64
+	SinrMax     int64
+	SinrAvg     float64
+	SinrP5      int64
+	SinrP95     int64
+	Label       string
+}
+
+type SignalRollupHour struct {
+	ID          int64
+	PeriodStart time.Time
+	Generation  string
+	Band        string
+	Cid         int64
+	Enbid       int64
+	Gnbid       int64
+	SampleCount int64
+	RsrpMin     int64
+	RsrpMax     int64
+	RsrpAvg     float64
+	RsrpP5      int64
+	RsrpP95     int64
+	RsrqMin     int64
+	RsrqMax     int64
+	RsrqAvg     float64
+	RsrqP5      int64
+	RsrqP95     int64
+	RssiMin     int64
+	RssiMax     int64
+	RssiAvg     float64
+	RssiP5      int64
+	RssiP95     int64
+	SinrMin     int64
+	SinrMax     int64
+	SinrAvg     float64
+	SinrP5      int64
+	SinrP95     int64
+	Label       string
+}
+
+type Snapshot struct {
+	ID           int64
+	Deviceid     int64
+	CreatedAt    time.Time
+	Uptime       int64
+	Label        string
+	Apn          string
+	HasIpv6      bool
+	Registration string
+	Roaming      bool
 }
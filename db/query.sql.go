@@ -7,9 +7,463 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"time"
 )
 
+const countClientsBetween = `-- name: CountClientsBetween :many
+SELECT
+    label,
+    created_at,
+    COUNT(
+        CASE
+            WHEN connected THEN 1
+        END
+    ) AS connected,
+    COUNT(
+        CASE
+            WHEN connected
+            AND connection != 'ethernet' THEN 1
+        END
+    ) AS wifi
+FROM
+    client_observation
+WHERE
+    created_at >= ?1
+    AND created_at < ?2
+GROUP BY
+    label,
+    created_at
+ORDER BY
+    created_at,
+    label
+`
+
+type CountClientsBetweenParams struct {
+	Start time.Time
+	End   time.Time
+}
+
+type CountClientsBetweenRow struct {
+	Label     string
+	CreatedAt time.Time
+	Connected int64
+	Wifi      int64
+}
+
+func (q *Queries) CountClientsBetween(ctx context.Context, arg CountClientsBetweenParams) ([]CountClientsBetweenRow, error) {
+	rows, err := q.db.QueryContext(ctx, countClientsBetween, arg.Start, arg.End)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountClientsBetweenRow
+	for rows.Next() {
+		var i CountClientsBetweenRow
+		if err := rows.Scan(
+			&i.Label,
+			&i.CreatedAt,
+			&i.Connected,
+			&i.Wifi,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countRegistrationsBetween = `-- name: CountRegistrationsBetween :many
+SELECT
+    label,
+    registration,
+    roaming,
+    apn,
+    has_ipv6,
+    COUNT(*) AS total
+FROM
+    snapshot
+WHERE
+    created_at >= ?1
+    AND created_at < ?2
+GROUP BY
+    label,
+    registration,
+    roaming,
+    apn,
+    has_ipv6
+ORDER BY
+    label,
+    registration,
+    roaming,
+    apn,
+    has_ipv6
+`
+
+type CountRegistrationsBetweenParams struct {
+	Start time.Time
+	End   time.Time
+}
+
+type CountRegistrationsBetweenRow struct {
+	Label        string
+	Registration string
+	Roaming      bool
+	Apn          string
+	HasIpv6      bool
+	Total        int64
+}
+
+func (q *Queries) CountRegistrationsBetween(ctx context.Context, arg CountRegistrationsBetweenParams) ([]CountRegistrationsBetweenRow, error) {
+	rows, err := q.db.QueryContext(ctx, countRegistrationsBetween, arg.Start, arg.End)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountRegistrationsBetweenRow
+	for rows.Next() {
+		var i CountRegistrationsBetweenRow
+		if err := rows.Scan(
+			&i.Label,
+			&i.Registration,
+			&i.Roaming,
+			&i.Apn,
+			&i.HasIpv6,
+			&i.Total,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countSnapshotsBetween = `-- name: CountSnapshotsBetween :many
+SELECT
+    snapshot.label,
+    COUNT(*) AS total,
+    COUNT(five_g.id) AS with_5g
+FROM
+    snapshot
+    LEFT JOIN signal five_g ON five_g.snapshotid = snapshot.id
+    AND five_g.generation = '5G'
+WHERE
+    snapshot.created_at >= ?1
+    AND snapshot.created_at < ?2
+GROUP BY
+    snapshot.label
+ORDER BY
+    snapshot.label
+`
+
+type CountSnapshotsBetweenParams struct {
+	Start time.Time
+	End   time.Time
+}
+
+type CountSnapshotsBetweenRow struct {
+	Label  string
+	Total  int64
+	With5g int64
+}
+
+func (q *Queries) CountSnapshotsBetween(ctx context.Context, arg CountSnapshotsBetweenParams) ([]CountSnapshotsBetweenRow, error) {
+	rows, err := q.db.QueryContext(ctx, countSnapshotsBetween, arg.Start, arg.End)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountSnapshotsBetweenRow
+	for rows.Next() {
+		var i CountSnapshotsBetweenRow
+		if err := rows.Scan(&i.Label, &i.Total, &i.With5g); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createCell = `-- name: CreateCell :one
+INSERT INTO
+    cell (
+        label,
+        generation,
+        enbid,
+        gnbid,
+        cid,
+        bands,
+        first_seen,
+        last_seen,
+        sample_count,
+        rsrp_avg,
+        rsrq_avg,
+        rssi_avg,
+        sinr_avg
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, label, generation, enbid, gnbid, cid, bands, first_seen, last_seen, sample_count, rsrp_avg, rsrq_avg, rssi_avg, sinr_avg
+`
+
+type CreateCellParams struct {
+	Label       string
+	Generation  string
+	Enbid       int64
+	Gnbid       int64
+	Cid         int64
+	Bands       string
+	FirstSeen   time.Time
+	LastSeen    time.Time
+	SampleCount int64
+	RsrpAvg     float64
+	RsrqAvg     float64
+	RssiAvg     float64
+	SinrAvg     float64
+}
+
+func (q *Queries) CreateCell(ctx context.Context, arg CreateCellParams) (Cell, error) {
+	row := q.db.QueryRowContext(ctx, createCell,
+		arg.Label,
+		arg.Generation,
+		arg.Enbid,
+		arg.Gnbid,
+		arg.Cid,
+		arg.Bands,
+		arg.FirstSeen,
+		arg.LastSeen,
+		arg.SampleCount,
+		arg.RsrpAvg,
+		arg.RsrqAvg,
+		arg.RssiAvg,
+		arg.SinrAvg,
+	)
+	var i Cell
+	err := row.Scan(
+		&i.ID,
+		&i.Label,
+		&i.Generation,
+		&i.Enbid,
+		&i.Gnbid,
+		&i.Cid,
+		&i.Bands,
+		&i.FirstSeen,
+		&i.LastSeen,
+		&i.SampleCount,
+		&i.RsrpAvg,
+		&i.RsrqAvg,
+		&i.RssiAvg,
+		&i.SinrAvg,
+	)
+	return i, err
+}
+
+const createClientDevice = `-- name: CreateClientDevice :one
+INSERT INTO
+    client_device (label, mac, name, first_seen, last_seen)
+VALUES
+    (?, ?, ?, ?, ?) RETURNING id, label, mac, name, first_seen, last_seen
+`
+
+type CreateClientDeviceParams struct {
+	Label     string
+	Mac       string
+	Name      string
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+func (q *Queries) CreateClientDevice(ctx context.Context, arg CreateClientDeviceParams) (ClientDevice, error) {
+	row := q.db.QueryRowContext(ctx, createClientDevice,
+		arg.Label,
+		arg.Mac,
+		arg.Name,
+		arg.FirstSeen,
+		arg.LastSeen,
+	)
+	var i ClientDevice
+	err := row.Scan(
+		&i.ID,
+		&i.Label,
+		&i.Mac,
+		&i.Name,
+		&i.FirstSeen,
+		&i.LastSeen,
+	)
+	return i, err
+}
+
+const createClientObservation = `-- name: CreateClientObservation :one
+INSERT INTO
+    client_observation (
+        clientdeviceid,
+        label,
+        created_at,
+        connection,
+        connected,
+        ipv4,
+        ipv6,
+        signal
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, clientdeviceid, label, created_at, connection, connected, ipv4, ipv6, signal
+`
+
+type CreateClientObservationParams struct {
+	Clientdeviceid int64
+	Label          string
+	CreatedAt      time.Time
+	Connection     string
+	Connected      bool
+	Ipv4           string
+	Ipv6           string
+	Signal         int64
+}
+
+func (q *Queries) CreateClientObservation(ctx context.Context, arg CreateClientObservationParams) (ClientObservation, error) {
+	row := q.db.QueryRowContext(ctx, createClientObservation,
+		arg.Clientdeviceid,
+		arg.Label,
+		arg.CreatedAt,
+		arg.Connection,
+		arg.Connected,
+		arg.Ipv4,
+		arg.Ipv6,
+		arg.Signal,
+	)
+	var i ClientObservation
+	err := row.Scan(
+		&i.ID,
+		&i.Clientdeviceid,
+		&i.Label,
+		&i.CreatedAt,
+		&i.Connection,
+		&i.Connected,
+		&i.Ipv4,
+		&i.Ipv6,
+		&i.Signal,
+	)
+	return i, err
+}
+
+const createDailyRollup = `-- name: CreateDailyRollup :exec
+INSERT INTO
+    signal_rollup_day (
+        period_start,
+        label,
+        generation,
+        band,
+        cid,
+        enbid,
+        gnbid,
+        sample_count,
+        rsrp_min,
+        rsrp_max,
+        rsrp_avg,
+        rsrp_p5,
+        rsrp_p95,
+        rsrq_min,
+        rsrq_max,
+        rsrq_avg,
+        rsrq_p5,
+        rsrq_p95,
+        rssi_min,
+        rssi_max,
+        rssi_avg,
+        rssi_p5,
+        rssi_p95,
+        sinr_min,
+        sinr_max,
+        sinr_avg,
+        sinr_p5,
+        sinr_p95
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreateDailyRollupParams struct {
+	PeriodStart time.Time
+	Label       string
+	Generation  string
+	Band        string
+	Cid         int64
+	Enbid       int64
+	Gnbid       int64
+	SampleCount int64
+	RsrpMin     int64
+	RsrpMax     int64
+	RsrpAvg     float64
+	RsrpP5      int64
+	RsrpP95     int64
+	RsrqMin     int64
+	RsrqMax     int64
+	RsrqAvg     float64
+	RsrqP5      int64
+	RsrqP95     int64
+	RssiMin     int64
+	RssiMax     int64
+	RssiAvg     float64
+	RssiP5      int64
+	RssiP95     int64
+	SinrMin     int64
+	SinrMax     int64
+	SinrAvg     float64
+	SinrP5      int64
+	SinrP95     int64
+}
+
+func (q *Queries) CreateDailyRollup(ctx context.Context, arg CreateDailyRollupParams) error {
+	_, err := q.db.ExecContext(ctx, createDailyRollup,
+		arg.PeriodStart,
+		arg.Label,
+		arg.Generation,
+		arg.Band,
+		arg.Cid,
+		arg.Enbid,
+		arg.Gnbid,
+		arg.SampleCount,
+		arg.RsrpMin,
+		arg.RsrpMax,
+		arg.RsrpAvg,
+		arg.RsrpP5,
+		arg.RsrpP95,
+		arg.RsrqMin,
+		arg.RsrqMax,
+		arg.RsrqAvg,
+		arg.RsrqP5,
+		arg.RsrqP95,
+		arg.RssiMin,
+		arg.RssiMax,
+		arg.RssiAvg,
+		arg.RssiP5,
+		arg.RssiP95,
+		arg.SinrMin,
+		arg.SinrMax,
+		arg.SinrAvg,
+		arg.SinrP5,
+		arg.SinrP95,
+	)
+	return err
+}
+
 const createDevice = `-- name: CreateDevice :one
 INSERT INTO
     device (
@@ -26,10 +480,11 @@ INSERT INTO
         serial,
         software_version,
         type,
-        update_state
+        update_state,
+        label
     )
 VALUES
-    (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, friendly_name, hardware_version, isenabled, ismesh_supported, macid, manufacturer, manufacturer_oui, model, name, role, serial, software_version, type, update_state
+    (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, friendly_name, hardware_version, isenabled, ismesh_supported, macid, manufacturer, manufacturer_oui, model, name, role, serial, software_version, type, update_state, label
 `
 
 type CreateDeviceParams struct {
@@ -47,6 +502,7 @@ type CreateDeviceParams struct {
 	SoftwareVersion string
 	Type            string
 	UpdateState     string
+	Label           string
 }
 
 func (q *Queries) CreateDevice(ctx context.Context, arg CreateDeviceParams) (Device, error) {
@@ -65,6 +521,7 @@ func (q *Queries) CreateDevice(ctx context.Context, arg CreateDeviceParams) (Dev
 		arg.SoftwareVersion,
 		arg.Type,
 		arg.UpdateState,
+		arg.Label,
 	)
 	var i Device
 	err := row.Scan(
@@ -83,10 +540,150 @@ func (q *Queries) CreateDevice(ctx context.Context, arg CreateDeviceParams) (Dev
 		&i.SoftwareVersion,
 		&i.Type,
 		&i.UpdateState,
+		&i.Label,
+	)
+	return i, err
+}
+
+const createEvent = `-- name: CreateEvent :one
+INSERT INTO
+    event (label, type, created_at, old_value, new_value, detail)
+VALUES
+    (?, ?, ?, ?, ?, ?) RETURNING id, label, type, created_at, old_value, new_value, detail
+`
+
+type CreateEventParams struct {
+	Label     string
+	Type      string
+	CreatedAt time.Time
+	OldValue  string
+	NewValue  string
+	Detail    string
+}
+
+func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event, error) {
+	row := q.db.QueryRowContext(ctx, createEvent,
+		arg.Label,
+		arg.Type,
+		arg.CreatedAt,
+		arg.OldValue,
+		arg.NewValue,
+		arg.Detail,
+	)
+	var i Event
+	err := row.Scan(
+		&i.ID,
+		&i.Label,
+		&i.Type,
+		&i.CreatedAt,
+		&i.OldValue,
+		&i.NewValue,
+		&i.Detail,
 	)
 	return i, err
 }
 
+const createHourlyRollup = `-- name: CreateHourlyRollup :exec
+INSERT INTO
+    signal_rollup_hour (
+        period_start,
+        label,
+        generation,
+        band,
+        cid,
+        enbid,
+        gnbid,
+        sample_count,
+        rsrp_min,
+        rsrp_max,
+        rsrp_avg,
+        rsrp_p5,
+        rsrp_p95,
+        rsrq_min,
+        rsrq_max,
+        rsrq_avg,
+        rsrq_p5,
+        rsrq_p95,
+        rssi_min,
+        rssi_max,
+        rssi_avg,
+        rssi_p5,
+        rssi_p95,
+        sinr_min,
+        sinr_max,
+        sinr_avg,
+        sinr_p5,
+        sinr_p95
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreateHourlyRollupParams struct {
+	PeriodStart time.Time
+	Label       string
+	Generation  string
+	Band        string
+	Cid         int64
+	Enbid       int64
+	Gnbid       int64
+	SampleCount int64
+	RsrpMin     int64
+	RsrpMax     int64
+	RsrpAvg     float64
+	RsrpP5      int64
+	RsrpP95     int64
+	RsrqMin     int64
+	RsrqMax     int64
+	RsrqAvg     float64
+	RsrqP5      int64
+	RsrqP95     int64
+	RssiMin     int64
+	RssiMax     int64
+	RssiAvg     float64
+	RssiP5      int64
+	RssiP95     int64
+	SinrMin     int64
+	SinrMax     int64
+	SinrAvg     float64
+	SinrP5      int64
+	SinrP95     int64
+}
+
+func (q *Queries) CreateHourlyRollup(ctx context.Context, arg CreateHourlyRollupParams) error {
+	_, err := q.db.ExecContext(ctx, createHourlyRollup,
+		arg.PeriodStart,
+		arg.Label,
+		arg.Generation,
+		arg.Band,
+		arg.Cid,
+		arg.Enbid,
+		arg.Gnbid,
+		arg.SampleCount,
+		arg.RsrpMin,
+		arg.RsrpMax,
+		arg.RsrpAvg,
+		arg.RsrpP5,
+		arg.RsrpP95,
+		arg.RsrqMin,
+		arg.RsrqMax,
+		arg.RsrqAvg,
+		arg.RsrqP5,
+		arg.RsrqP95,
+		arg.RssiMin,
+		arg.RssiMax,
+		arg.RssiAvg,
+		arg.RssiP5,
+		arg.RssiP95,
+		arg.SinrMin,
+		arg.SinrMax,
+		arg.SinrAvg,
+		arg.SinrP5,
+		arg.SinrP95,
+	)
+	return err
+}
+
 const createSignal = `-- name: CreateSignal :one
 INSERT INTO
     signal (
@@ -156,65 +753,1416 @@ func (q *Queries) CreateSignal(ctx context.Context, arg CreateSignalParams) (Sig
 	return i, err
 }
 
+const createSignalBand = `-- name: CreateSignalBand :one
+INSERT INTO
+    signal_band (signalid, band, is_primary)
+VALUES
+    (?, ?, ?) RETURNING id, signalid, band, is_primary
+`
+
+type CreateSignalBandParams struct {
+	Signalid  int64
+	Band      string
+	IsPrimary bool
+}
+
+func (q *Queries) CreateSignalBand(ctx context.Context, arg CreateSignalBandParams) (SignalBand, error) {
+	row := q.db.QueryRowContext(ctx, createSignalBand, arg.Signalid, arg.Band, arg.IsPrimary)
+	var i SignalBand
+	err := row.Scan(
+		&i.ID,
+		&i.Signalid,
+		&i.Band,
+		&i.IsPrimary,
+	)
+	return i, err
+}
+
+const createSignalChannel = `-- name: CreateSignalChannel :one
+INSERT INTO
+    signal_channel (
+        signalid,
+        pci,
+        arfcn,
+        bandwidth,
+        cqi,
+        tac,
+        plmn,
+        ecgi
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, signalid, pci, arfcn, bandwidth, cqi, tac, plmn, ecgi
+`
+
+type CreateSignalChannelParams struct {
+	Signalid  int64
+	Pci       int64
+	Arfcn     int64
+	Bandwidth string
+	Cqi       int64
+	Tac       string
+	Plmn      string
+	Ecgi      string
+}
+
+func (q *Queries) CreateSignalChannel(ctx context.Context, arg CreateSignalChannelParams) (SignalChannel, error) {
+	row := q.db.QueryRowContext(ctx, createSignalChannel,
+		arg.Signalid,
+		arg.Pci,
+		arg.Arfcn,
+		arg.Bandwidth,
+		arg.Cqi,
+		arg.Tac,
+		arg.Plmn,
+		arg.Ecgi,
+	)
+	var i SignalChannel
+	err := row.Scan(
+		&i.ID,
+		&i.Signalid,
+		&i.Pci,
+		&i.Arfcn,
+		&i.Bandwidth,
+		&i.Cqi,
+		&i.Tac,
+		&i.Plmn,
+		&i.Ecgi,
+	)
+	return i, err
+}
+
 const createSnapshot = `-- name: CreateSnapshot :one
 INSERT INTO
-    snapshot (deviceid, created_at, uptime)
+    snapshot (
+        deviceid,
+        created_at,
+        uptime,
+        label,
+        apn,
+        has_ipv6,
+        registration,
+        roaming
+    )
 VALUES
-    (?, ?, ?) RETURNING id, deviceid, created_at, uptime
+    (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, deviceid, created_at, uptime, label, apn, has_ipv6, registration, roaming
 `
 
 type CreateSnapshotParams struct {
-	Deviceid  int64
-	CreatedAt time.Time
-	Uptime    int64
+	Deviceid     int64
+	CreatedAt    time.Time
+	Uptime       int64
+	Label        string
+	Apn          string
+	HasIpv6      bool
+	Registration string
+	Roaming      bool
 }
 
 func (q *Queries) CreateSnapshot(ctx context.Context, arg CreateSnapshotParams) (Snapshot, error) {
-	row := q.db.QueryRowContext(ctx, createSnapshot, arg.Deviceid, arg.CreatedAt, arg.Uptime)
+	row := q.db.QueryRowContext(ctx, createSnapshot,
+		arg.Deviceid,
+		arg.CreatedAt,
+		arg.Uptime,
+		arg.Label,
+		arg.Apn,
+		arg.HasIpv6,
+		arg.Registration,
+		arg.Roaming,
+	)
 	var i Snapshot
 	err := row.Scan(
 		&i.ID,
 		&i.Deviceid,
 		&i.CreatedAt,
 		&i.Uptime,
+		&i.Label,
+		&i.Apn,
+		&i.HasIpv6,
+		&i.Registration,
+		&i.Roaming,
 	)
 	return i, err
 }
 
-const getDevice = `-- name: GetDevice :one
+const deleteDailyRollupsBetween = `-- name: DeleteDailyRollupsBetween :exec
+DELETE FROM signal_rollup_day
+WHERE
+    period_start >= ?1
+    AND period_start < ?2
+`
+
+type DeleteDailyRollupsBetweenParams struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (q *Queries) DeleteDailyRollupsBetween(ctx context.Context, arg DeleteDailyRollupsBetweenParams) error {
+	_, err := q.db.ExecContext(ctx, deleteDailyRollupsBetween, arg.Start, arg.End)
+	return err
+}
+
+const deleteHourlyRollupsBetween = `-- name: DeleteHourlyRollupsBetween :exec
+DELETE FROM signal_rollup_hour
+WHERE
+    period_start >= ?1
+    AND period_start < ?2
+`
+
+type DeleteHourlyRollupsBetweenParams struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (q *Queries) DeleteHourlyRollupsBetween(ctx context.Context, arg DeleteHourlyRollupsBetweenParams) error {
+	_, err := q.db.ExecContext(ctx, deleteHourlyRollupsBetween, arg.Start, arg.End)
+	return err
+}
+
+const deleteSignalBandsBefore = `-- name: DeleteSignalBandsBefore :execrows
+DELETE FROM signal_band
+WHERE
+    signalid IN (
+        SELECT
+            signal.id
+        FROM
+            signal
+            JOIN snapshot ON snapshot.id = signal.snapshotid
+        WHERE
+            snapshot.created_at < ?
+    )
+`
+
+func (q *Queries) DeleteSignalBandsBefore(ctx context.Context, createdAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteSignalBandsBefore, createdAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteSignalChannelsBefore = `-- name: DeleteSignalChannelsBefore :execrows
+DELETE FROM signal_channel
+WHERE
+    signalid IN (
+        SELECT
+            signal.id
+        FROM
+            signal
+            JOIN snapshot ON snapshot.id = signal.snapshotid
+        WHERE
+            snapshot.created_at < ?
+    )
+`
+
+func (q *Queries) DeleteSignalChannelsBefore(ctx context.Context, createdAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteSignalChannelsBefore, createdAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteSignalsBefore = `-- name: DeleteSignalsBefore :execrows
+DELETE FROM signal
+WHERE
+    snapshotid IN (
+        SELECT
+            id
+        FROM
+            snapshot
+        WHERE
+            created_at < ?
+    )
+`
+
+func (q *Queries) DeleteSignalsBefore(ctx context.Context, createdAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteSignalsBefore, createdAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteSnapshotsBefore = `-- name: DeleteSnapshotsBefore :execrows
+DELETE FROM snapshot
+WHERE
+    created_at < ?
+`
+
+func (q *Queries) DeleteSnapshotsBefore(ctx context.Context, createdAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteSnapshotsBefore, createdAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getCell = `-- name: GetCell :one
 SELECT
-    id, friendly_name, hardware_version, isenabled, ismesh_supported, macid, manufacturer, manufacturer_oui, model, name, role, serial, software_version, type, update_state
+    id, label, generation, enbid, gnbid, cid, bands, first_seen, last_seen, sample_count, rsrp_avg, rsrq_avg, rssi_avg, sinr_avg
 FROM
-    device
+    cell
 WHERE
-    serial = ?
-    AND software_version = ?
+    label = ?
+    AND generation = ?
+    AND enbid = ?
+    AND gnbid = ?
+    AND cid = ?
 `
 
-type GetDeviceParams struct {
-	Serial          string
-	SoftwareVersion string
+type GetCellParams struct {
+	Label      string
+	Generation string
+	Enbid      int64
+	Gnbid      int64
+	Cid        int64
 }
 
-func (q *Queries) GetDevice(ctx context.Context, arg GetDeviceParams) (Device, error) {
-	row := q.db.QueryRowContext(ctx, getDevice, arg.Serial, arg.SoftwareVersion)
-	var i Device
+func (q *Queries) GetCell(ctx context.Context, arg GetCellParams) (Cell, error) {
+	row := q.db.QueryRowContext(ctx, getCell,
+		arg.Label,
+		arg.Generation,
+		arg.Enbid,
+		arg.Gnbid,
+		arg.Cid,
+	)
+	var i Cell
 	err := row.Scan(
 		&i.ID,
-		&i.FriendlyName,
-		&i.HardwareVersion,
-		&i.Isenabled,
-		&i.IsmeshSupported,
-		&i.Macid,
-		&i.Manufacturer,
-		&i.ManufacturerOui,
-		&i.Model,
-		&i.Name,
-		&i.Role,
-		&i.Serial,
-		&i.SoftwareVersion,
-		&i.Type,
+		&i.Label,
+		&i.Generation,
+		&i.Enbid,
+		&i.Gnbid,
+		&i.Cid,
+		&i.Bands,
+		&i.FirstSeen,
+		&i.LastSeen,
+		&i.SampleCount,
+		&i.RsrpAvg,
+		&i.RsrqAvg,
+		&i.RssiAvg,
+		&i.SinrAvg,
+	)
+	return i, err
+}
+
+const getClientDevice = `-- name: GetClientDevice :one
+SELECT
+    id, label, mac, name, first_seen, last_seen
+FROM
+    client_device
+WHERE
+    label = ?
+    AND mac = ?
+`
+
+type GetClientDeviceParams struct {
+	Label string
+	Mac   string
+}
+
+func (q *Queries) GetClientDevice(ctx context.Context, arg GetClientDeviceParams) (ClientDevice, error) {
+	row := q.db.QueryRowContext(ctx, getClientDevice, arg.Label, arg.Mac)
+	var i ClientDevice
+	err := row.Scan(
+		&i.ID,
+		&i.Label,
+		&i.Mac,
+		&i.Name,
+		&i.FirstSeen,
+		&i.LastSeen,
+	)
+	return i, err
+}
+
+const getDevice = `-- name: GetDevice :one
+SELECT
+    id, friendly_name, hardware_version, isenabled, ismesh_supported, macid, manufacturer, manufacturer_oui, model, name, role, serial, software_version, type, update_state, label
+FROM
+    device
+WHERE
+    serial = ?
+    AND software_version = ?
+`
+
+type GetDeviceParams struct {
+	Serial          string
+	SoftwareVersion string
+}
+
+func (q *Queries) GetDevice(ctx context.Context, arg GetDeviceParams) (Device, error) {
+	row := q.db.QueryRowContext(ctx, getDevice, arg.Serial, arg.SoftwareVersion)
+	var i Device
+	err := row.Scan(
+		&i.ID,
+		&i.FriendlyName,
+		&i.HardwareVersion,
+		&i.Isenabled,
+		&i.IsmeshSupported,
+		&i.Macid,
+		&i.Manufacturer,
+		&i.ManufacturerOui,
+		&i.Model,
+		&i.Name,
+		&i.Role,
+		&i.Serial,
+		&i.SoftwareVersion,
+		&i.Type,
 		&i.UpdateState,
+		&i.Label,
+	)
+	return i, err
+}
+
+const getFirstSnapshotTime = `-- name: GetFirstSnapshotTime :one
+SELECT
+    created_at
+FROM
+    snapshot
+ORDER BY
+    created_at
+LIMIT
+    1
+`
+
+func (q *Queries) GetFirstSnapshotTime(ctx context.Context) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getFirstSnapshotTime)
+	var created_at time.Time
+	err := row.Scan(&created_at)
+	return created_at, err
+}
+
+const getLatestDailyRollupTime = `-- name: GetLatestDailyRollupTime :one
+SELECT
+    period_start
+FROM
+    signal_rollup_day
+ORDER BY
+    period_start DESC
+LIMIT
+    1
+`
+
+func (q *Queries) GetLatestDailyRollupTime(ctx context.Context) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getLatestDailyRollupTime)
+	var period_start time.Time
+	err := row.Scan(&period_start)
+	return period_start, err
+}
+
+const getLatestHourlyRollupTime = `-- name: GetLatestHourlyRollupTime :one
+SELECT
+    period_start
+FROM
+    signal_rollup_hour
+ORDER BY
+    period_start DESC
+LIMIT
+    1
+`
+
+func (q *Queries) GetLatestHourlyRollupTime(ctx context.Context) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getLatestHourlyRollupTime)
+	var period_start time.Time
+	err := row.Scan(&period_start)
+	return period_start, err
+}
+
+const getLatestSnapshot = `-- name: GetLatestSnapshot :one
+SELECT
+    snapshot.created_at,
+    snapshot.uptime,
+    device.serial,
+    device.software_version
+FROM
+    snapshot
+    JOIN device ON device.id = snapshot.deviceid
+WHERE
+    snapshot.label = ?
+ORDER BY
+    snapshot.created_at DESC,
+    snapshot.id DESC
+LIMIT
+    1
+`
+
+type GetLatestSnapshotRow struct {
+	CreatedAt       time.Time
+	Uptime          int64
+	Serial          string
+	SoftwareVersion string
+}
+
+func (q *Queries) GetLatestSnapshot(ctx context.Context, label string) (GetLatestSnapshotRow, error) {
+	row := q.db.QueryRowContext(ctx, getLatestSnapshot, label)
+	var i GetLatestSnapshotRow
+	err := row.Scan(
+		&i.CreatedAt,
+		&i.Uptime,
+		&i.Serial,
+		&i.SoftwareVersion,
+	)
+	return i, err
+}
+
+const getLatestSnapshotID = `-- name: GetLatestSnapshotID :one
+SELECT
+    id
+FROM
+    snapshot
+ORDER BY
+    id DESC
+LIMIT
+    1
+`
+
+func (q *Queries) GetLatestSnapshotID(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getLatestSnapshotID)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getRollupWatermark = `-- name: GetRollupWatermark :one
+SELECT
+    snapshotid
+FROM
+    rollup_watermark
+WHERE
+    id = 1
+`
+
+func (q *Queries) GetRollupWatermark(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getRollupWatermark)
+	var snapshotid int64
+	err := row.Scan(&snapshotid)
+	return snapshotid, err
+}
+
+const getServingCell = `-- name: GetServingCell :one
+SELECT
+    id, label, generation, enbid, gnbid, cid, bands, first_seen, last_seen, sample_count, rsrp_avg, rsrq_avg, rssi_avg, sinr_avg
+FROM
+    cell
+WHERE
+    label = ?
+    AND generation = ?
+ORDER BY
+    last_seen DESC,
+    id DESC
+LIMIT
+    1
+`
+
+type GetServingCellParams struct {
+	Label      string
+	Generation string
+}
+
+func (q *Queries) GetServingCell(ctx context.Context, arg GetServingCellParams) (Cell, error) {
+	row := q.db.QueryRowContext(ctx, getServingCell, arg.Label, arg.Generation)
+	var i Cell
+	err := row.Scan(
+		&i.ID,
+		&i.Label,
+		&i.Generation,
+		&i.Enbid,
+		&i.Gnbid,
+		&i.Cid,
+		&i.Bands,
+		&i.FirstSeen,
+		&i.LastSeen,
+		&i.SampleCount,
+		&i.RsrpAvg,
+		&i.RsrqAvg,
+		&i.RssiAvg,
+		&i.SinrAvg,
+	)
+	return i, err
+}
+
+const listCells = `-- name: ListCells :many
+SELECT
+    id, label, generation, enbid, gnbid, cid, bands, first_seen, last_seen, sample_count, rsrp_avg, rsrq_avg, rssi_avg, sinr_avg
+FROM
+    cell
+ORDER BY
+    label,
+    generation,
+    sample_count DESC,
+    id
+`
+
+func (q *Queries) ListCells(ctx context.Context) ([]Cell, error) {
+	rows, err := q.db.QueryContext(ctx, listCells)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Cell
+	for rows.Next() {
+		var i Cell
+		if err := rows.Scan(
+			&i.ID,
+			&i.Label,
+			&i.Generation,
+			&i.Enbid,
+			&i.Gnbid,
+			&i.Cid,
+			&i.Bands,
+			&i.FirstSeen,
+			&i.LastSeen,
+			&i.SampleCount,
+			&i.RsrpAvg,
+			&i.RsrqAvg,
+			&i.RssiAvg,
+			&i.SinrAvg,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChannelsBetween = `-- name: ListChannelsBetween :many
+SELECT
+    snapshot.label,
+    signal.generation,
+    signal.band,
+    signal_channel.arfcn,
+    signal_channel.pci,
+    signal_channel.bandwidth,
+    COUNT(*) AS samples,
+    CAST(AVG(signal.rsrp) AS REAL) AS rsrp_avg,
+    CAST(AVG(signal.sinr) AS REAL) AS sinr_avg
+FROM
+    signal_channel
+    JOIN signal ON signal.id = signal_channel.signalid
+    JOIN snapshot ON snapshot.id = signal.snapshotid
+WHERE
+    snapshot.created_at >= ?1
+    AND snapshot.created_at < ?2
+GROUP BY
+    snapshot.label,
+    signal.generation,
+    signal.band,
+    signal_channel.arfcn,
+    signal_channel.pci,
+    signal_channel.bandwidth
+ORDER BY
+    signal.generation,
+    signal.band,
+    signal_channel.arfcn,
+    signal_channel.pci,
+    snapshot.label
+`
+
+type ListChannelsBetweenParams struct {
+	Start time.Time
+	End   time.Time
+}
+
+type ListChannelsBetweenRow struct {
+	Label      string
+	Generation string
+	Band       string
+	Arfcn      int64
+	Pci        int64
+	Bandwidth  string
+	Samples    int64
+	RsrpAvg    float64
+	SinrAvg    float64
+}
+
+func (q *Queries) ListChannelsBetween(ctx context.Context, arg ListChannelsBetweenParams) ([]ListChannelsBetweenRow, error) {
+	rows, err := q.db.QueryContext(ctx, listChannelsBetween, arg.Start, arg.End)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListChannelsBetweenRow
+	for rows.Next() {
+		var i ListChannelsBetweenRow
+		if err := rows.Scan(
+			&i.Label,
+			&i.Generation,
+			&i.Band,
+			&i.Arfcn,
+			&i.Pci,
+			&i.Bandwidth,
+			&i.Samples,
+			&i.RsrpAvg,
+			&i.SinrAvg,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClientDevices = `-- name: ListClientDevices :many
+SELECT
+    id, label, mac, name, first_seen, last_seen
+FROM
+    client_device
+ORDER BY
+    label,
+    last_seen DESC,
+    id
+`
+
+func (q *Queries) ListClientDevices(ctx context.Context) ([]ClientDevice, error) {
+	rows, err := q.db.QueryContext(ctx, listClientDevices)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClientDevice
+	for rows.Next() {
+		var i ClientDevice
+		if err := rows.Scan(
+			&i.ID,
+			&i.Label,
+			&i.Mac,
+			&i.Name,
+			&i.FirstSeen,
+			&i.LastSeen,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDevicesPage = `-- name: ListDevicesPage :many
+SELECT
+    id, friendly_name, hardware_version, isenabled, ismesh_supported, macid, manufacturer, manufacturer_oui, model, name, role, serial, software_version, type, update_state, label
+FROM
+    device
+WHERE
+    id > ?1
+ORDER BY
+    id
+LIMIT
+    ?2
+`
+
+type ListDevicesPageParams struct {
+	After    int64
+	PageSize int64
+}
+
+func (q *Queries) ListDevicesPage(ctx context.Context, arg ListDevicesPageParams) ([]Device, error) {
+	rows, err := q.db.QueryContext(ctx, listDevicesPage, arg.After, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Device
+	for rows.Next() {
+		var i Device
+		if err := rows.Scan(
+			&i.ID,
+			&i.FriendlyName,
+			&i.HardwareVersion,
+			&i.Isenabled,
+			&i.IsmeshSupported,
+			&i.Macid,
+			&i.Manufacturer,
+			&i.ManufacturerOui,
+			&i.Model,
+			&i.Name,
+			&i.Role,
+			&i.Serial,
+			&i.SoftwareVersion,
+			&i.Type,
+			&i.UpdateState,
+			&i.Label,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEventsBetween = `-- name: ListEventsBetween :many
+SELECT
+    id, label, type, created_at, old_value, new_value, detail
+FROM
+    event
+WHERE
+    created_at >= ?1
+    AND created_at < ?2
+ORDER BY
+    created_at,
+    id
+`
+
+type ListEventsBetweenParams struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (q *Queries) ListEventsBetween(ctx context.Context, arg ListEventsBetweenParams) ([]Event, error) {
+	rows, err := q.db.QueryContext(ctx, listEventsBetween, arg.Start, arg.End)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.Label,
+			&i.Type,
+			&i.CreatedAt,
+			&i.OldValue,
+			&i.NewValue,
+			&i.Detail,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLastEvents = `-- name: ListLastEvents :many
+SELECT
+    id, label, type, created_at, old_value, new_value, detail
+FROM
+    event
+WHERE
+    id IN (
+        SELECT
+            MAX(id)
+        FROM
+            event
+        WHERE
+            event.label = ?
+        GROUP BY
+            event.type
+    )
+ORDER BY
+    id
+`
+
+func (q *Queries) ListLastEvents(ctx context.Context, label string) ([]Event, error) {
+	rows, err := q.db.QueryContext(ctx, listLastEvents, label)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.Label,
+			&i.Type,
+			&i.CreatedAt,
+			&i.OldValue,
+			&i.NewValue,
+			&i.Detail,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSignalBands = `-- name: ListSignalBands :many
+SELECT
+    id, signalid, band, is_primary
+FROM
+    signal_band
+WHERE
+    signalid = ?
+ORDER BY
+    id
+`
+
+func (q *Queries) ListSignalBands(ctx context.Context, signalid int64) ([]SignalBand, error) {
+	rows, err := q.db.QueryContext(ctx, listSignalBands, signalid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SignalBand
+	for rows.Next() {
+		var i SignalBand
+		if err := rows.Scan(
+			&i.ID,
+			&i.Signalid,
+			&i.Band,
+			&i.IsPrimary,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSignalsBetween = `-- name: ListSignalsBetween :many
+SELECT
+    signal.id, signal.snapshotid, signal.antenna_used, signal.generation, signal.band, signal.bars, signal.cid, signal.enbid, signal.gnbid, signal.rsrp, signal.rsrq, signal.rssi, signal.sinr,
+    snapshot.created_at,
+    snapshot.label
+FROM
+    signal
+    JOIN snapshot ON snapshot.id = signal.snapshotid
+WHERE
+    snapshot.created_at >= ?1
+    AND snapshot.created_at < ?2
+ORDER BY
+    snapshot.created_at
+`
+
+type ListSignalsBetweenParams struct {
+	Start time.Time
+	End   time.Time
+}
+
+type ListSignalsBetweenRow struct {
+	ID          int64
+	Snapshotid  int64
+	AntennaUsed string
+	Generation  string
+	Band        string
+	Bars        float64
+	Cid         int64
+	Enbid       int64
+	Gnbid       int64
+	Rsrp        int64
+	Rsrq        int64
+	Rssi        int64
+	Sinr        int64
+	CreatedAt   time.Time
+	Label       string
+}
+
+func (q *Queries) ListSignalsBetween(ctx context.Context, arg ListSignalsBetweenParams) ([]ListSignalsBetweenRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSignalsBetween, arg.Start, arg.End)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSignalsBetweenRow
+	for rows.Next() {
+		var i ListSignalsBetweenRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Snapshotid,
+			&i.AntennaUsed,
+			&i.Generation,
+			&i.Band,
+			&i.Bars,
+			&i.Cid,
+			&i.Enbid,
+			&i.Gnbid,
+			&i.Rsrp,
+			&i.Rsrq,
+			&i.Rssi,
+			&i.Sinr,
+			&i.CreatedAt,
+			&i.Label,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSignalsPage = `-- name: ListSignalsPage :many
+SELECT
+    signal.id, signal.snapshotid, signal.antenna_used, signal.generation, signal.band, signal.bars, signal.cid, signal.enbid, signal.gnbid, signal.rsrp, signal.rsrq, signal.rssi, signal.sinr,
+    snapshot.created_at,
+    snapshot.label
+FROM
+    signal
+    JOIN snapshot ON snapshot.id = signal.snapshotid
+WHERE
+    snapshot.created_at >= ?1
+    AND snapshot.created_at < ?2
+    AND (
+        snapshot.label = ?3
+        OR ?3 IS NULL
+    )
+    AND (
+        signal.generation = ?4
+        OR ?4 IS NULL
+    )
+    AND (
+        signal.band = ?5
+        OR ?5 IS NULL
+    )
+    AND (
+        signal.cid = ?6
+        OR ?6 IS NULL
+    )
+    AND signal.id > ?7
+ORDER BY
+    signal.id
+LIMIT
+    ?8
+`
+
+type ListSignalsPageParams struct {
+	Start      time.Time
+	End        time.Time
+	Label      sql.NullString
+	Generation sql.NullString
+	Band       sql.NullString
+	Cid        sql.NullInt64
+	After      int64
+	PageSize   int64
+}
+
+type ListSignalsPageRow struct {
+	ID          int64
+	Snapshotid  int64
+	AntennaUsed string
+	Generation  string
+	Band        string
+	Bars        float64
+	Cid         int64
+	Enbid       int64
+	Gnbid       int64
+	Rsrp        int64
+	Rsrq        int64
+	Rssi        int64
+	Sinr        int64
+	CreatedAt   time.Time
+	Label       string
+}
+
+func (q *Queries) ListSignalsPage(ctx context.Context, arg ListSignalsPageParams) ([]ListSignalsPageRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSignalsPage,
+		arg.Start,
+		arg.End,
+		arg.Label,
+		arg.Generation,
+		arg.Band,
+		arg.Cid,
+		arg.After,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSignalsPageRow
+	for rows.Next() {
+		var i ListSignalsPageRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Snapshotid,
+			&i.AntennaUsed,
+			&i.Generation,
+			&i.Band,
+			&i.Bars,
+			&i.Cid,
+			&i.Enbid,
+			&i.Gnbid,
+			&i.Rsrp,
+			&i.Rsrq,
+			&i.Rssi,
+			&i.Sinr,
+			&i.CreatedAt,
+			&i.Label,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSnapshotTimesAfter = `-- name: ListSnapshotTimesAfter :many
+SELECT
+    created_at
+FROM
+    snapshot
+WHERE
+    id > ?1
+    AND id <= ?2
+ORDER BY
+    created_at
+`
+
+type ListSnapshotTimesAfterParams struct {
+	After int64
+	Last  int64
+}
+
+func (q *Queries) ListSnapshotTimesAfter(ctx context.Context, arg ListSnapshotTimesAfterParams) ([]time.Time, error) {
+	rows, err := q.db.QueryContext(ctx, listSnapshotTimesAfter, arg.After, arg.Last)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []time.Time
+	for rows.Next() {
+		var created_at time.Time
+		if err := rows.Scan(&created_at); err != nil {
+			return nil, err
+		}
+		items = append(items, created_at)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSnapshotsPage = `-- name: ListSnapshotsPage :many
+SELECT
+    id, deviceid, created_at, uptime, label, apn, has_ipv6, registration, roaming
+FROM
+    snapshot
+WHERE
+    created_at >= ?1
+    AND created_at < ?2
+    AND (
+        label = ?3
+        OR ?3 IS NULL
+    )
+    AND id > ?4
+ORDER BY
+    id
+LIMIT
+    ?5
+`
+
+type ListSnapshotsPageParams struct {
+	Start    time.Time
+	End      time.Time
+	Label    sql.NullString
+	After    int64
+	PageSize int64
+}
+
+func (q *Queries) ListSnapshotsPage(ctx context.Context, arg ListSnapshotsPageParams) ([]Snapshot, error) {
+	rows, err := q.db.QueryContext(ctx, listSnapshotsPage,
+		arg.Start,
+		arg.End,
+		arg.Label,
+		arg.After,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Snapshot
+	for rows.Next() {
+		var i Snapshot
+		if err := rows.Scan(
+			&i.ID,
+			&i.Deviceid,
+			&i.CreatedAt,
+			&i.Uptime,
+			&i.Label,
+			&i.Apn,
+			&i.HasIpv6,
+			&i.Registration,
+			&i.Roaming,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setRollupWatermark = `-- name: SetRollupWatermark :exec
+INSERT INTO
+    rollup_watermark (id, snapshotid)
+VALUES
+    (1, ?) ON CONFLICT (id) DO
+UPDATE
+SET
+    snapshotid = excluded.snapshotid
+`
+
+func (q *Queries) SetRollupWatermark(ctx context.Context, snapshotid int64) error {
+	_, err := q.db.ExecContext(ctx, setRollupWatermark, snapshotid)
+	return err
+}
+
+const summarizeSignalsBetween = `-- name: SummarizeSignalsBetween :many
+SELECT
+    snapshot.label,
+    signal.generation,
+    signal.band,
+    COUNT(*) AS samples,
+    CAST(MIN(signal.rsrp) AS INTEGER) AS rsrp_min,
+    CAST(AVG(signal.rsrp) AS REAL) AS rsrp_avg,
+    CAST(MAX(signal.rsrp) AS INTEGER) AS rsrp_max,
+    CAST(MIN(signal.rsrq) AS INTEGER) AS rsrq_min,
+    CAST(AVG(signal.rsrq) AS REAL) AS rsrq_avg,
+    CAST(MAX(signal.rsrq) AS INTEGER) AS rsrq_max,
+    CAST(MIN(signal.rssi) AS INTEGER) AS rssi_min,
+    CAST(AVG(signal.rssi) AS REAL) AS rssi_avg,
+    CAST(MAX(signal.rssi) AS INTEGER) AS rssi_max,
+    CAST(MIN(signal.sinr) AS INTEGER) AS sinr_min,
+    CAST(AVG(signal.sinr) AS REAL) AS sinr_avg,
+    CAST(MAX(signal.sinr) AS INTEGER) AS sinr_max
+FROM
+    signal
+    JOIN snapshot ON snapshot.id = signal.snapshotid
+WHERE
+    snapshot.created_at >= ?1
+    AND snapshot.created_at < ?2
+    AND (
+        snapshot.label = ?3
+        OR ?3 IS NULL
+    )
+GROUP BY
+    snapshot.label,
+    signal.generation,
+    signal.band
+ORDER BY
+    snapshot.label,
+    signal.generation,
+    signal.band
+`
+
+type SummarizeSignalsBetweenParams struct {
+	Start time.Time
+	End   time.Time
+	Label sql.NullString
+}
+
+type SummarizeSignalsBetweenRow struct {
+	Label      string
+	Generation string
+	Band       string
+	Samples    int64
+	RsrpMin    int64
+	RsrpAvg    float64
+	RsrpMax    int64
+	RsrqMin    int64
+	RsrqAvg    float64
+	RsrqMax    int64
+	RssiMin    int64
+	RssiAvg    float64
+	RssiMax    int64
+	SinrMin    int64
+	SinrAvg    float64
+	SinrMax    int64
+}
+
+func (q *Queries) SummarizeSignalsBetween(ctx context.Context, arg SummarizeSignalsBetweenParams) ([]SummarizeSignalsBetweenRow, error) {
+	rows, err := q.db.QueryContext(ctx, summarizeSignalsBetween, arg.Start, arg.End, arg.Label)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SummarizeSignalsBetweenRow
+	for rows.Next() {
+		var i SummarizeSignalsBetweenRow
+		if err := rows.Scan(
+			&i.Label,
+			&i.Generation,
+			&i.Band,
+			&i.Samples,
+			&i.RsrpMin,
+			&i.RsrpAvg,
+			&i.RsrpMax,
+			&i.RsrqMin,
+			&i.RsrqAvg,
+			&i.RsrqMax,
+			&i.RssiMin,
+			&i.RssiAvg,
+			&i.RssiMax,
+			&i.SinrMin,
+			&i.SinrAvg,
+			&i.SinrMax,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateCell = `-- name: UpdateCell :one
+UPDATE cell
+SET
+    bands = ?,
+    last_seen = ?,
+    sample_count = ?,
+    rsrp_avg = ?,
+    rsrq_avg = ?,
+    rssi_avg = ?,
+    sinr_avg = ?
+WHERE
+    id = ? RETURNING id, label, generation, enbid, gnbid, cid, bands, first_seen, last_seen, sample_count, rsrp_avg, rsrq_avg, rssi_avg, sinr_avg
+`
+
+type UpdateCellParams struct {
+	Bands       string
+	LastSeen    time.Time
+	SampleCount int64
+	RsrpAvg     float64
+	RsrqAvg     float64
+	RssiAvg     float64
+	SinrAvg     float64
+	ID          int64
+}
+
+func (q *Queries) UpdateCell(ctx context.Context, arg UpdateCellParams) (Cell, error) {
+	row := q.db.QueryRowContext(ctx, updateCell,
+		arg.Bands,
+		arg.LastSeen,
+		arg.SampleCount,
+		arg.RsrpAvg,
+		arg.RsrqAvg,
+		arg.RssiAvg,
+		arg.SinrAvg,
+		arg.ID,
+	)
+	var i Cell
+	err := row.Scan(
+		&i.ID,
+		&i.Label,
+		&i.Generation,
+		&i.Enbid,
+		&i.Gnbid,
+		&i.Cid,
+		&i.Bands,
+		&i.FirstSeen,
+		&i.LastSeen,
+		&i.SampleCount,
+		&i.RsrpAvg,
+		&i.RsrqAvg,
+		&i.RssiAvg,
+		&i.SinrAvg,
 	)
 	return i, err
 }
+
+const updateClientDevice = `-- name: UpdateClientDevice :one
+UPDATE client_device
+SET
+    name = ?,
+    last_seen = ?
+WHERE
+    id = ? RETURNING id, label, mac, name, first_seen, last_seen
+`
+
+type UpdateClientDeviceParams struct {
+	Name     string
+	LastSeen time.Time
+	ID       int64
+}
+
+func (q *Queries) UpdateClientDevice(ctx context.Context, arg UpdateClientDeviceParams) (ClientDevice, error) {
+	row := q.db.QueryRowContext(ctx, updateClientDevice, arg.Name, arg.LastSeen, arg.ID)
+	var i ClientDevice
+	err := row.Scan(
+		&i.ID,
+		&i.Label,
+		&i.Mac,
+		&i.Name,
+		&i.FirstSeen,
+		&i.LastSeen,
+	)
+	return i, err
+}
+
+const updateDeviceLabel = `-- name: UpdateDeviceLabel :exec
+UPDATE device
+SET
+    label = ?
+WHERE
+    id = ?
+`
+
+type UpdateDeviceLabelParams struct {
+	Label string
+	ID    int64
+}
+
+func (q *Queries) UpdateDeviceLabel(ctx context.Context, arg UpdateDeviceLabelParams) error {
+	_, err := q.db.ExecContext(ctx, updateDeviceLabel, arg.Label, arg.ID)
+	return err
+}
@@ -0,0 +1,154 @@
+package events
+
+import (
+	"local/tmo/api"
+	"local/tmo/db"
+	"maps"
+	"strconv"
+	"time"
+)
+
+// Event types, stored in the type column of the event table
+const (
+	// OutageStart and OutageEnd bound a period the gateway was unreachable or not registered to the network
+	OutageStart = "outage_start"
+	OutageEnd   = "outage_end"
+	// UnreachableStart and UnreachableEnd bound a period the gateway could not be reached at all
+	UnreachableStart = "unreachable_start"
+	UnreachableEnd   = "unreachable_end"
+	// Registration, Roaming, APN and Firmware record a change of the value reported by the gateway
+	Registration = "registration"
+	Roaming      = "roaming"
+	APN          = "apn"
+	Firmware     = "firmware"
+	// Reboot is recorded at the time the gateway booted, with the uptime it had reached before
+	Reboot = "reboot"
+	// Handoff records a change of the serving cell of the generation in the detail between snapshots
+	Handoff = "handoff"
+	// Policy records a policy rule firing, with the rule name as the new value and what it did in the detail
+	Policy = "policy"
+)
+
+// Registered is the registration state of a gateway attached to the network
+const Registered = "registered"
+
+// rebootTolerance is how far the boot time worked out from the gateway clock and uptime may move without a reboot
+const rebootTolerance = 5 * time.Minute
+
+// State is what is known about the connectivity of a gateway after its last poll
+type State struct {
+	// values holds the last registration, roaming and APN values by event type, a missing value is unknown
+	values      map[string]string
+	Outage      bool
+	Unreachable bool
+	// Boot is when the gateway booted and Uptime how long it had been up by its last response, zero if unknown
+	Boot   time.Time
+	Uptime time.Duration
+}
+
+// Restore rebuilds the state of a gateway from the most recent event of each type and its latest snapshot, if any,
+// so an outage going on when the poller stopped is ended by the first poll after it restarts, and a reboot or
+// firmware update while it was stopped is still detected
+func Restore(last []db.Event, latest *db.GetLatestSnapshotRow) State {
+	state := State{values: make(map[string]string)}
+	var outage, unreachable db.Event
+	for _, event := range last {
+		switch event.Type {
+		case Registration, Roaming, APN:
+			state.values[event.Type] = event.NewValue
+		case OutageStart, OutageEnd:
+			if event.ID > outage.ID {
+				outage = event
+			}
+		case UnreachableStart, UnreachableEnd:
+			if event.ID > unreachable.ID {
+				unreachable = event
+			}
+		}
+	}
+	state.Outage = outage.Type == OutageStart
+	state.Unreachable = unreachable.Type == UnreachableStart
+
+	if latest != nil {
+		state.values[Firmware] = latest.SoftwareVersion
+		state.Uptime = time.Duration(latest.Uptime) * time.Second
+		state.Boot = latest.CreatedAt.Add(-state.Uptime)
+	}
+	return state
+}
+
+// Observe returns the state after a successful poll of a response taken at the given time and the events it caused
+func (s State) Observe(label string, at time.Time, gateway api.GatewayResponse) (State, []db.CreateEventParams) {
+	generic := gateway.Signal.Generic
+	next := State{values: maps.Clone(s.values), Outage: s.Outage}
+	if next.values == nil {
+		next.values = make(map[string]string)
+	}
+
+	var events []db.CreateEventParams
+	add := func(at time.Time, eventType, oldValue, newValue, detail string) {
+		events = append(events, db.CreateEventParams{
+			Label:     label,
+			Type:      eventType,
+			CreatedAt: at,
+			OldValue:  oldValue,
+			NewValue:  newValue,
+			Detail:    detail,
+		})
+	}
+
+	if s.Unreachable {
+		add(at, UnreachableEnd, "", "", "")
+	}
+
+	// Uptime going backwards, or the boot time moving forwards after a long gap between polls, is a reboot
+	next.Uptime = time.Duration(gateway.Time.UpTime) * time.Second
+	next.Boot = at.Add(-next.Uptime)
+	if !s.Boot.IsZero() && (next.Uptime < s.Uptime || next.Boot.Sub(s.Boot) > rebootTolerance) {
+		add(next.Boot, Reboot, s.Uptime.String(), next.Uptime.String(), "")
+	}
+
+	// The first poll sets each value without an event, there is nothing to compare it to
+	values := []struct {
+		eventType string
+		value     string
+	}{
+		{Firmware, gateway.Device.SoftwareVersion},
+		{Registration, generic.Registration},
+		{Roaming, strconv.FormatBool(generic.Roaming)},
+		{APN, generic.Apn},
+	}
+	for _, v := range values {
+		old, known := next.values[v.eventType]
+		if known && old != v.value {
+			add(at, v.eventType, old, v.value, "")
+		}
+		next.values[v.eventType] = v.value
+	}
+
+	registered := generic.Registration == Registered
+	if !s.Outage && !registered {
+		add(at, OutageStart, "", generic.Registration, "registration "+generic.Registration)
+		next.Outage = true
+	} else if s.Outage && registered {
+		add(at, OutageEnd, "", generic.Registration, "")
+		next.Outage = false
+	}
+
+	return next, events
+}
+
+// ObserveUnreachable returns the state after a poll at the given time failed to reach the gateway and the events it caused
+func (s State) ObserveUnreachable(label string, at time.Time, err error) (State, []db.CreateEventParams) {
+	next := State{values: s.values, Outage: true, Unreachable: true, Boot: s.Boot, Uptime: s.Uptime}
+
+	var events []db.CreateEventParams
+	if !s.Unreachable {
+		events = append(events, db.CreateEventParams{Label: label, Type: UnreachableStart, CreatedAt: at, Detail: err.Error()})
+	}
+	if !s.Outage {
+		events = append(events, db.CreateEventParams{Label: label, Type: OutageStart, CreatedAt: at, Detail: "gateway unreachable"})
+	}
+
+	return next, events
+}
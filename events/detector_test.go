@@ -0,0 +1,146 @@
+package events
+
+import (
+	"errors"
+	"local/tmo/api"
+	"local/tmo/db"
+	"slices"
+	"testing"
+	"time"
+)
+
+// response returns a gateway response reporting the generic values
+func response(generic api.Generic) api.GatewayResponse {
+	return api.GatewayResponse{Signal: api.Signal{Generic: generic}}
+}
+
+// types returns the types of events in order
+func types(events []db.CreateEventParams) []string {
+	var types []string
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+func TestStateObserve(t *testing.T) {
+	at := time.Date(2025, 4, 1, 10, 0, 0, 0, time.UTC)
+	home := api.Generic{Apn: "FBB.HOME", Registration: Registered}
+
+	var state State
+	state, events := state.Observe("home", at, response(home))
+	if len(events) != 0 {
+		t.Errorf("Expected no events on the first poll, got %v", types(events))
+	}
+
+	steps := []struct {
+		name     string
+		generic  api.Generic
+		expected []string
+	}{
+		{"Unchanged", home, nil},
+		{"Deregistered", api.Generic{Apn: "FBB.HOME", Registration: "searching"}, []string{Registration, OutageStart}},
+		{"Still Deregistered", api.Generic{Apn: "FBB.HOME", Registration: "searching"}, nil},
+		{"Registered Roaming", api.Generic{Apn: "FBB.HOME", Registration: Registered, Roaming: true}, []string{Registration, Roaming, OutageEnd}},
+		{"APN Changed", api.Generic{Apn: "FAST.T-MOBILE.COM", Registration: Registered, Roaming: true}, []string{APN}},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			state, events = state.Observe("home", at, response(step.generic))
+			if !slices.Equal(types(events), step.expected) {
+				t.Errorf("Expected events %v, got %v", step.expected, types(events))
+			}
+		})
+	}
+
+	if events[0].OldValue != "FBB.HOME" || events[0].NewValue != "FAST.T-MOBILE.COM" || events[0].Label != "home" {
+		t.Errorf("Unexpected APN event: %+v", events[0])
+	}
+}
+
+func TestStateObserveUnreachable(t *testing.T) {
+	at := time.Date(2025, 4, 1, 10, 0, 0, 0, time.UTC)
+	var state State
+	state, _ = state.Observe("home", at, response(api.Generic{Registration: Registered}))
+
+	state, events := state.ObserveUnreachable("home", at, errors.New("connection refused"))
+	if !slices.Equal(types(events), []string{UnreachableStart, OutageStart}) {
+		t.Errorf("Unexpected events: %v", types(events))
+	}
+	if events[0].Detail != "connection refused" {
+		t.Errorf("Expected the error as detail, got %q", events[0].Detail)
+	}
+
+	state, events = state.ObserveUnreachable("home", at, errors.New("connection refused"))
+	if len(events) != 0 {
+		t.Errorf("Expected no events while still unreachable, got %v", types(events))
+	}
+
+	_, events = state.Observe("home", at, response(api.Generic{Registration: Registered}))
+	if !slices.Equal(types(events), []string{UnreachableEnd, OutageEnd}) {
+		t.Errorf("Unexpected events: %v", types(events))
+	}
+}
+
+func TestRestore(t *testing.T) {
+	state := Restore([]db.Event{
+		{ID: 1, Type: Registration, NewValue: "searching"},
+		{ID: 2, Type: OutageEnd},
+		{ID: 3, Type: OutageStart},
+		{ID: 4, Type: APN, NewValue: "FBB.HOME"},
+	}, nil)
+	if !state.Outage || state.Unreachable {
+		t.Errorf("Expected an outage without the gateway being unreachable, got %+v", state)
+	}
+
+	_, events := state.Observe("home", time.Now(), response(api.Generic{Apn: "FBB.HOME", Registration: Registered}))
+	if !slices.Equal(types(events), []string{Registration, OutageEnd}) {
+		t.Errorf("Unexpected events: %v", types(events))
+	}
+}
+
+func TestStateReboot(t *testing.T) {
+	at := time.Date(2025, 4, 1, 10, 0, 0, 0, time.UTC)
+	poll := func(state State, at time.Time, uptime time.Duration, version string) (State, []db.CreateEventParams) {
+		gateway := response(api.Generic{Registration: Registered})
+		gateway.Time.UpTime = int(uptime.Seconds())
+		gateway.Device.SoftwareVersion = version
+		return state.Observe("home", at, gateway)
+	}
+
+	state, _ := poll(State{}, at, 2*time.Hour, "1.0")
+	state, events := poll(state, at.Add(5*time.Minute), 2*time.Hour+5*time.Minute, "1.0")
+	if len(events) != 0 {
+		t.Errorf("Expected no events while the gateway stays up, got %v", types(events))
+	}
+
+	t.Run("Uptime Reset", func(t *testing.T) {
+		_, events := poll(state, at.Add(10*time.Minute), time.Minute, "1.1")
+		if !slices.Equal(types(events), []string{Reboot, Firmware}) {
+			t.Fatalf("Unexpected events: %v", types(events))
+		}
+		if !events[0].CreatedAt.Equal(at.Add(9*time.Minute)) || events[0].OldValue != "2h5m0s" {
+			t.Errorf("Expected a reboot at the boot time after 2h5m up, got %+v", events[0])
+		}
+		if events[1].OldValue != "1.0" || events[1].NewValue != "1.1" {
+			t.Errorf("Unexpected firmware event: %+v", events[1])
+		}
+	})
+
+	t.Run("Rebooted While Not Polled", func(t *testing.T) {
+		// The uptime is higher than before, but the gateway booted long after the last poll
+		_, events := poll(state, at.Add(24*time.Hour), 3*time.Hour, "1.0")
+		if !slices.Equal(types(events), []string{Reboot}) {
+			t.Errorf("Unexpected events: %v", types(events))
+		}
+	})
+
+	t.Run("Restored", func(t *testing.T) {
+		restored := Restore(nil, &db.GetLatestSnapshotRow{CreatedAt: at, Uptime: int64(time.Hour.Seconds()), SoftwareVersion: "1.0"})
+		_, events := poll(restored, at.Add(time.Hour), 30*time.Minute, "1.1")
+		if !slices.Equal(types(events), []string{Reboot, Firmware}) {
+			t.Errorf("Unexpected events: %v", types(events))
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package events
+
+import (
+	"cmp"
+	"fmt"
+	"local/tmo/db"
+	"slices"
+	"time"
+)
+
+// CellName identifies a cell in handoff events by its eNB ID for 4G or gNB ID for 5G and its cell ID
+func CellName(generation string, enbid, gnbid, cid int64) string {
+	if generation == "5G" {
+		return fmt.Sprintf("gNB %d cell %d", gnbid, cid)
+	}
+	return fmt.Sprintf("eNB %d cell %d", enbid, cid)
+}
+
+// HandoffCount is how often a gateway was handed off from one cell to another
+type HandoffCount struct {
+	Label      string
+	Generation string
+	From       string
+	To         string
+	Count      int
+	Last       time.Time
+}
+
+// CountHandoffs counts the handoff events between each pair of cells of each gateway, most frequent first
+func CountHandoffs(events []db.Event) []HandoffCount {
+	type key struct {
+		label, generation, from, to string
+	}
+	counts := make(map[key]*HandoffCount)
+
+	for _, event := range events {
+		if event.Type != Handoff {
+			continue
+		}
+		k := key{event.Label, event.Detail, event.OldValue, event.NewValue}
+		count, ok := counts[k]
+		if !ok {
+			count = &HandoffCount{Label: event.Label, Generation: event.Detail, From: event.OldValue, To: event.NewValue}
+			counts[k] = count
+		}
+		count.Count++
+		if event.CreatedAt.After(count.Last) {
+			count.Last = event.CreatedAt
+		}
+	}
+
+	result := make([]HandoffCount, 0, len(counts))
+	for _, count := range counts {
+		result = append(result, *count)
+	}
+	slices.SortFunc(result, func(a, b HandoffCount) int {
+		return cmp.Or(cmp.Compare(a.Label, b.Label), cmp.Compare(a.Generation, b.Generation), cmp.Compare(b.Count, a.Count),
+			cmp.Compare(a.From, b.From), cmp.Compare(a.To, b.To))
+	})
+	return result
+}
@@ -0,0 +1,55 @@
+package events
+
+import (
+	"bytes"
+	"local/tmo/db"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCountHandoffs(t *testing.T) {
+	start := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	handoff := func(label, generation, from, to string, minutes int) db.Event {
+		return db.Event{
+			Label: label, Type: Handoff, CreatedAt: start.Add(time.Duration(minutes) * time.Minute),
+			OldValue: from, NewValue: to, Detail: generation,
+		}
+	}
+
+	counts := CountHandoffs([]db.Event{
+		handoff("home", "5G", "gNB 1 cell 1", "gNB 1 cell 2", 0),
+		handoff("home", "5G", "gNB 1 cell 2", "gNB 1 cell 1", 1),
+		handoff("home", "5G", "gNB 1 cell 1", "gNB 1 cell 2", 2),
+		handoff("home", "4G", "eNB 1 cell 1", "eNB 2 cell 1", 3),
+		{Label: "home", Type: OutageStart, CreatedAt: start},
+	})
+
+	if len(counts) != 3 {
+		t.Fatalf("Expected 3 handoff pairs, got %+v", counts)
+	}
+	if counts[0].Generation != "4G" || counts[0].Count != 1 {
+		t.Errorf("Expected the 4G handoff first, got %+v", counts[0])
+	}
+	if counts[1].To != "gNB 1 cell 2" || counts[1].Count != 2 || !counts[1].Last.Equal(start.Add(2*time.Minute)) {
+		t.Errorf("Expected 2 handoffs to cell 2, got %+v", counts[1])
+	}
+
+	var buf bytes.Buffer
+	err := WriteCells(&buf, []db.Cell{{Label: "home", Generation: "5G", Gnbid: 1, Cid: 2, Bands: "n41", SampleCount: 3, RsrpAvg: -90}}, counts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !strings.Contains(buf.String(), "gNB 1 cell 2") || !strings.Contains(buf.String(), "-90.0") {
+		t.Errorf("Expected the cell in the output, got:\n%s", buf.String())
+	}
+}
+
+func TestCellName(t *testing.T) {
+	if name := CellName("4G", 310, 0, 12); name != "eNB 310 cell 12" {
+		t.Errorf("Expected eNB 310 cell 12, got %s", name)
+	}
+	if name := CellName("5G", 0, 98765, 54321); name != "gNB 98765 cell 54321" {
+		t.Errorf("Expected gNB 98765 cell 54321, got %s", name)
+	}
+}
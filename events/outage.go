@@ -0,0 +1,107 @@
+package events
+
+import (
+	"local/tmo/db"
+	"sort"
+	"time"
+)
+
+// Outage is a period a gateway was unreachable or not registered to the network
+type Outage struct {
+	Label string
+	Start time.Time
+	End   time.Time
+	// Cause is the detail of the event that started the outage, empty if it started before the period listed
+	Cause string
+	// Ongoing is set for an outage that had not ended by the end of the period listed
+	Ongoing bool
+}
+
+// Duration returns how long the outage lasted
+func (o Outage) Duration() time.Duration {
+	return o.End.Sub(o.Start)
+}
+
+// Downtime is the total outage time of a gateway on one day
+type Downtime struct {
+	Label    string
+	Day      time.Time
+	Outages  int
+	Duration time.Duration
+}
+
+// Outages pairs the outage start and end events of each gateway recorded in [from, to) in order of time.
+// An end without a start is an outage that started before from, a start without an end is ongoing at to.
+func Outages(events []db.Event, from, to time.Time) []Outage {
+	var outages []Outage
+	open := make(map[string]*Outage)
+
+	for _, event := range events {
+		switch event.Type {
+		case OutageStart:
+			if open[event.Label] == nil {
+				open[event.Label] = &Outage{Label: event.Label, Start: event.CreatedAt, Cause: event.Detail}
+			}
+		case OutageEnd:
+			outage := open[event.Label]
+			if outage == nil {
+				outage = &Outage{Label: event.Label, Start: from}
+			}
+			outage.End = event.CreatedAt
+			outages = append(outages, *outage)
+			delete(open, event.Label)
+		}
+	}
+
+	for _, outage := range open {
+		outage.End = to
+		outage.Ongoing = true
+		outages = append(outages, *outage)
+	}
+
+	sort.SliceStable(outages, func(i, j int) bool {
+		return outages[i].Start.Before(outages[j].Start)
+	})
+	return outages
+}
+
+// DowntimePerDay splits outages at midnight and sums the downtime of each gateway per day, ordered by day and label
+func DowntimePerDay(outages []Outage) []Downtime {
+	type key struct {
+		label string
+		day   time.Time
+	}
+	totals := make(map[key]*Downtime)
+
+	for _, outage := range outages {
+		start := outage.Start
+		for start.Before(outage.End) {
+			day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+			end := day.AddDate(0, 0, 1)
+			if outage.End.Before(end) {
+				end = outage.End
+			}
+
+			k := key{outage.Label, day}
+			if totals[k] == nil {
+				totals[k] = &Downtime{Label: outage.Label, Day: day}
+			}
+			totals[k].Outages++
+			totals[k].Duration += end.Sub(start)
+
+			start = end
+		}
+	}
+
+	days := make([]Downtime, 0, len(totals))
+	for _, downtime := range totals {
+		days = append(days, *downtime)
+	}
+	sort.Slice(days, func(i, j int) bool {
+		if !days[i].Day.Equal(days[j].Day) {
+			return days[i].Day.Before(days[j].Day)
+		}
+		return days[i].Label < days[j].Label
+	})
+	return days
+}
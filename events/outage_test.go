@@ -0,0 +1,73 @@
+package events
+
+import (
+	"bytes"
+	"local/tmo/db"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutages(t *testing.T) {
+	from := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 3)
+	at := func(hours float64) time.Time {
+		return from.Add(time.Duration(hours * float64(time.Hour)))
+	}
+
+	list := Outages([]db.Event{
+		// Started before from
+		{Label: "home", Type: OutageEnd, CreatedAt: at(1)},
+		{Label: "home", Type: OutageStart, CreatedAt: at(23), Detail: "gateway unreachable"},
+		{Label: "lab", Type: OutageStart, CreatedAt: at(24), Detail: "registration searching"},
+		// A second start while an outage is going on is part of it
+		{Label: "home", Type: OutageStart, CreatedAt: at(24)},
+		{Label: "home", Type: Registration, CreatedAt: at(24.5)},
+		{Label: "home", Type: OutageEnd, CreatedAt: at(25)},
+		{Label: "lab", Type: OutageEnd, CreatedAt: at(24.5)},
+		// Still going on at to
+		{Label: "home", Type: OutageStart, CreatedAt: at(70), Detail: "registration searching"},
+	}, from, to)
+
+	if len(list) != 4 {
+		t.Fatalf("Expected 4 outages, got %+v", list)
+	}
+	if !list[0].Start.Equal(from) || list[0].Duration() != time.Hour || list[0].Cause != "" {
+		t.Errorf("Expected an hour long outage from the start, got %+v", list[0])
+	}
+	if list[1].Duration() != 2*time.Hour || list[1].Cause != "gateway unreachable" {
+		t.Errorf("Expected a 2 hour outage while unreachable, got %+v", list[1])
+	}
+	if list[2].Label != "lab" || list[2].Duration() != 30*time.Minute {
+		t.Errorf("Expected a 30 minute outage of lab, got %+v", list[2])
+	}
+	if !list[3].Ongoing || !list[3].End.Equal(to) {
+		t.Errorf("Expected an ongoing outage, got %+v", list[3])
+	}
+
+	days := DowntimePerDay(list)
+	expected := []Downtime{
+		{Label: "home", Day: from, Outages: 2, Duration: 2 * time.Hour},
+		{Label: "home", Day: at(24), Outages: 1, Duration: time.Hour},
+		{Label: "lab", Day: at(24), Outages: 1, Duration: 30 * time.Minute},
+		{Label: "home", Day: at(48), Outages: 1, Duration: 2 * time.Hour},
+	}
+	if len(days) != len(expected) {
+		t.Fatalf("Expected %d days, got %+v", len(expected), days)
+	}
+	for i, e := range expected {
+		if days[i] != e {
+			t.Errorf("Expected day %d to be %+v, got %+v", i, e, days[i])
+		}
+	}
+
+	var out bytes.Buffer
+	if err := WriteOutages(&out, list); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, e := range []string{"ongoing", "registration searching", "Total downtime: 5h30m0s in 4 outages"} {
+		if !strings.Contains(out.String(), e) {
+			t.Errorf("Expected output to contain %q", e)
+		}
+	}
+}
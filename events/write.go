@@ -0,0 +1,83 @@
+package events
+
+import (
+	"fmt"
+	"io"
+	"local/tmo/db"
+	"text/tabwriter"
+	"time"
+)
+
+const timeFormat = "2006-01-02 15:04:05"
+
+// WriteOutages prints the outages and the downtime of each day as plain text tables
+func WriteOutages(w io.Writer, outages []Outage) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "Outages")
+	fmt.Fprintln(tw, "GATEWAY\tSTART\tEND\tDURATION\tCAUSE")
+	var total time.Duration
+	for _, o := range outages {
+		end := o.End.Format(timeFormat)
+		if o.Ongoing {
+			end = "ongoing"
+		}
+		cause := o.Cause
+		if cause == "" {
+			cause = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", gateway(o.Label), o.Start.Format(timeFormat), end, o.Duration().Round(time.Second), cause)
+		total += o.Duration()
+	}
+
+	fmt.Fprintln(tw, "\nDowntime by day")
+	fmt.Fprintln(tw, "DAY\tGATEWAY\tOUTAGES\tDOWNTIME")
+	for _, d := range DowntimePerDay(outages) {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", d.Day.Format(time.DateOnly), gateway(d.Label), d.Outages, d.Duration.Round(time.Second))
+	}
+
+	fmt.Fprintf(tw, "\nTotal downtime: %s in %d outages\n", total.Round(time.Second), len(outages))
+
+	return tw.Flush()
+}
+
+// WriteEvents prints every event as a plain text table
+func WriteEvents(w io.Writer, events []db.Event) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "TIME\tGATEWAY\tEVENT\tFROM\tTO\tDETAIL")
+	for _, e := range events {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", e.CreatedAt.Format(timeFormat), gateway(e.Label), e.Type, e.OldValue, e.NewValue, e.Detail)
+	}
+
+	return tw.Flush()
+}
+
+// gateway names an unlabeled gateway in tables
+func gateway(label string) string {
+	if label == "" {
+		return "-"
+	}
+	return label
+}
+
+// WriteCells prints the inventory of cells each gateway has been served by and the handoffs between them as plain text tables
+func WriteCells(w io.Writer, cells []db.Cell, handoffs []HandoffCount) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "Cells")
+	fmt.Fprintln(tw, "GATEWAY\tGEN\tCELL\tBANDS\tFIRST SEEN\tLAST SEEN\tSAMPLES\tRSRP\tRSRQ\tSINR")
+	for _, c := range cells {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%.1f\t%.1f\t%.1f\n",
+			gateway(c.Label), c.Generation, CellName(c.Generation, c.Enbid, c.Gnbid, c.Cid), c.Bands,
+			c.FirstSeen.Format(timeFormat), c.LastSeen.Format(timeFormat), c.SampleCount, c.RsrpAvg, c.RsrqAvg, c.SinrAvg)
+	}
+
+	fmt.Fprintln(tw, "\nHandoffs")
+	fmt.Fprintln(tw, "GATEWAY\tGEN\tFROM\tTO\tCOUNT\tLAST")
+	for _, h := range handoffs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n", gateway(h.Label), h.Generation, h.From, h.To, h.Count, h.Last.Format(timeFormat))
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,467 @@
+package fakegw
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"local/tmo/api"
+	"log"
+	mathrand "math/rand/v2"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// BasePath is the path the real gateway serves its API under
+const BasePath = "/TMI/v1"
+
+// Faults configures how often the simulator misbehaves, rates are between 0 and 1
+type Faults struct {
+	// UnauthorizedRate is the fraction of gateway requests rejected with 401 even with a valid token
+	UnauthorizedRate float64
+	// ServerErrorRate is the fraction of requests answered with 500
+	ServerErrorRate float64
+	// TimeoutRate is the fraction of requests that hang for Timeout before answering with 504
+	TimeoutRate float64
+	Timeout     time.Duration
+}
+
+// Config holds the simulator configuration
+type Config struct {
+	Username string
+	Password string
+	TokenTTL time.Duration
+	Source   Source
+	// Clients is served by the clients endpoint, DefaultClients if unset
+	Clients api.ClientsResponse
+	// Telemetry and SIM are served by the telemetry endpoints, DefaultTelemetry and DefaultSIM if unset
+	Telemetry api.CellTelemetryResponse
+	SIM       api.SIMResponse
+	// AccessPoints is the initial Wi-Fi configuration, DefaultAccessPoints if it has no SSIDs
+	AccessPoints api.AccessPoints
+	Faults       Faults
+	Seed         uint64
+	Logger       *log.Logger
+}
+
+// Server simulates the /TMI/v1 API of a T-Mobile gateway
+type Server struct {
+	config  Config
+	started time.Time
+
+	mu     sync.Mutex
+	rand   *mathrand.Rand
+	tokens map[string]time.Time
+	// accessPoints is the current Wi-Fi configuration, reboots counts the reboot requests
+	accessPoints api.AccessPoints
+	reboots      int
+}
+
+// NewServer creates a new simulated gateway
+func NewServer(config Config) *Server {
+	if config.Logger == nil {
+		config.Logger = log.New(os.Stdout, "", log.LstdFlags)
+	}
+
+	if config.TokenTTL == 0 {
+		config.TokenTTL = 5 * time.Minute
+	}
+
+	if config.Source == nil {
+		config.Source = NewRandomWalk(DefaultGateway(), config.Seed)
+	}
+
+	if config.Clients.Clients == nil {
+		config.Clients = DefaultClients()
+	}
+
+	if config.Telemetry == (api.CellTelemetryResponse{}) {
+		config.Telemetry = DefaultTelemetry()
+	}
+
+	if config.SIM == (api.SIMResponse{}) {
+		config.SIM = DefaultSIM()
+	}
+
+	if len(config.AccessPoints.SSIDs) == 0 {
+		config.AccessPoints = DefaultAccessPoints()
+	}
+
+	return &Server{
+		config:  config,
+		started: time.Now(),
+		rand:    mathrand.New(mathrand.NewPCG(config.Seed, config.Seed)),
+		tokens:  make(map[string]time.Time),
+
+		accessPoints: config.AccessPoints,
+	}
+}
+
+// ServeHTTP routes requests to the simulated endpoints
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.config.Logger.Printf("%s %s", r.Method, r.URL)
+
+	switch r.URL.Path {
+	case BasePath + "/auth/login":
+		s.handleLogin(w, r)
+	case BasePath + "/gateway/", BasePath + "/gateway":
+		s.handleGateway(w, r)
+	case BasePath + "/network/telemetry/", BasePath + "/network/telemetry":
+		s.handleTelemetry(w, r)
+	case BasePath + "/gateway/reset":
+		s.handleReboot(w, r)
+	case BasePath + "/network/configuration/v2":
+		s.handleAccessPoints(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handleLogin checks the credentials and issues a new token
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var loginRequest struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&loginRequest)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if s.injectFault(w, r, false) {
+		return
+	}
+
+	if loginRequest.Username != s.config.Username || loginRequest.Password != s.config.Password {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	token := newToken()
+	expiration := time.Now().Add(s.config.TokenTTL)
+
+	s.mu.Lock()
+	s.tokens[token] = expiration
+	s.mu.Unlock()
+
+	writeJSON(w, map[string]any{
+		"auth": map[string]any{
+			"expiration":       expiration.Unix(),
+			"refreshCountLeft": 4,
+			"refreshCountMax":  4,
+			"token":            token,
+		},
+	})
+}
+
+// handleGateway returns the next response from the signal source, or the clients
+func (s *Server) handleGateway(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	get := r.URL.Query().Get("get")
+	if get != "all" && get != "clients" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if !s.authorized(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if s.injectFault(w, r, true) {
+		return
+	}
+
+	if get == "clients" {
+		writeJSON(w, s.config.Clients)
+		return
+	}
+
+	s.mu.Lock()
+	gateway, err := s.config.Source.Next()
+	started := s.started
+	s.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	if gateway.Time.LocalTime == 0 {
+		gateway.Time.LocalTime = int(now.Unix())
+	}
+	if gateway.Time.UpTime == 0 {
+		gateway.Time.UpTime = int(now.Sub(started).Seconds())
+	}
+
+	writeJSON(w, gateway)
+}
+
+// handleTelemetry returns the cell or SIM telemetry
+func (s *Server) handleTelemetry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	get := r.URL.Query().Get("get")
+	if get != "cell" && get != "sim" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if !s.authorized(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if s.injectFault(w, r, true) {
+		return
+	}
+
+	if get == "sim" {
+		writeJSON(w, s.config.SIM)
+		return
+	}
+	writeJSON(w, s.config.Telemetry)
+}
+
+// handleReboot restarts the simulated gateway, which resets its uptime and forgets every token
+func (s *Server) handleReboot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.URL.Query().Get("set") != "reboot" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if !s.authorized(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	s.mu.Lock()
+	s.started = time.Now()
+	s.tokens = make(map[string]time.Time)
+	s.reboots++
+	s.mu.Unlock()
+
+	writeJSON(w, map[string]any{})
+}
+
+// handleAccessPoints returns or replaces the Wi-Fi configuration
+func (s *Server) handleAccessPoints(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	get := r.Method == http.MethodGet && query.Get("get") == "ap"
+	set := r.Method == http.MethodPost && query.Get("set") == "ap"
+	if !get && !set {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if !s.authorized(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if s.injectFault(w, r, true) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if get {
+		writeJSON(w, s.accessPoints)
+		return
+	}
+
+	var accessPoints api.AccessPoints
+	err := json.NewDecoder(r.Body).Decode(&accessPoints)
+	if err != nil || len(accessPoints.SSIDs) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.accessPoints = accessPoints
+	writeJSON(w, map[string]any{})
+}
+
+// Reboots returns the number of reboots requested
+func (s *Server) Reboots() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reboots
+}
+
+// authorized checks the bearer token was issued by this server and has not expired
+func (s *Server) authorized(r *http.Request) bool {
+	const prefix = "Bearer "
+	header := r.Header.Get("Authorization")
+	if len(header) <= len(prefix) || header[:len(prefix)] != prefix {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiration, ok := s.tokens[header[len(prefix):]]
+	return ok && time.Now().Before(expiration)
+}
+
+// injectFault writes a configured fault response, returning true if the request was handled
+func (s *Server) injectFault(w http.ResponseWriter, r *http.Request, allowUnauthorized bool) bool {
+	s.mu.Lock()
+	roll := s.rand.Float64()
+	s.mu.Unlock()
+
+	faults := s.config.Faults
+	switch {
+	case roll < faults.TimeoutRate:
+		select {
+		case <-time.After(faults.Timeout):
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(http.StatusGatewayTimeout)
+	case roll < faults.TimeoutRate+faults.ServerErrorRate:
+		w.WriteHeader(http.StatusInternalServerError)
+	case allowUnauthorized && roll < faults.TimeoutRate+faults.ServerErrorRate+faults.UnauthorizedRate:
+		w.WriteHeader(http.StatusUnauthorized)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// DefaultGateway returns the gateway response documented in the README
+func DefaultGateway() api.GatewayResponse {
+	return api.GatewayResponse{
+		Device: api.Device{
+			FriendlyName:    "5G Gateway",
+			HardwareVersion: "R02",
+			Index:           1,
+			IsEnabled:       true,
+			IsMeshSupported: true,
+			MacID:           "bc:12:34:56:78:90",
+			Manufacturer:    "Sercomm",
+			ManufacturerOUI: "00C002",
+			Model:           "TMO-G4SE",
+			Name:            "5G Gateway",
+			Role:            "gateway",
+			Serial:          "FAKEGW001",
+			SoftwareVersion: "1.03.20",
+			Type:            "HSID",
+			UpdateState:     "latest",
+		},
+		Signal: api.Signal{
+			FourG: api.SignalStats{
+				AntennaUsed: "Internal_directional",
+				Bands:       []string{"b66"},
+				Bars:        3,
+				Cid:         32,
+				ENBID:       23270,
+				Rsrp:        -102,
+				Rsrq:        -7,
+				Rssi:        -94,
+				Sinr:        15,
+			},
+			FiveG: api.SignalStats{
+				AntennaUsed: "Internal_directional",
+				Bands:       []string{"n41"},
+				Bars:        3,
+				Cid:         32,
+				GNBID:       23270,
+				Rsrp:        -105,
+				Rsrq:        -10,
+				Rssi:        -93,
+				Sinr:        13,
+			},
+			Generic: api.Generic{
+				Apn:          "FBB.HOME",
+				HasIPv6:      true,
+				Registration: "registered",
+				Roaming:      false,
+			},
+		},
+		Time: api.Time{
+			LocalTimeZone: "-05:00",
+		},
+	}
+}
+
+// DefaultClients returns a phone and a laptop on Wi-Fi and a console on ethernet
+func DefaultClients() api.ClientsResponse {
+	return api.ClientsResponse{
+		Clients: map[string][]api.ConnectedClient{
+			"2.4ghz": {
+				{Connected: true, IPv4: "192.168.12.180", MAC: "3c:22:fb:00:00:01", Name: "phone", Signal: -61},
+			},
+			"5.0ghz": {
+				{Connected: true, IPv4: "192.168.12.181", IPv6: []string{"fe80::1"}, MAC: "3c:22:fb:00:00:02", Name: "laptop", Signal: -48},
+			},
+			"ethernet": {
+				{Connected: true, IPv4: "192.168.12.182", MAC: "3c:22:fb:00:00:03", Name: "console"},
+			},
+		},
+	}
+}
+
+// DefaultTelemetry returns the channels of the cells of DefaultGateway
+func DefaultTelemetry() api.CellTelemetryResponse {
+	var telemetry api.CellTelemetryResponse
+	telemetry.Cell.FourG = api.CellTelemetry{
+		Bandwidth: "20M", Cqi: 9, Earfcn: 66786, Mcc: "310", Mnc: "260", Pci: 218, Plmn: "310260", Tac: "11801", Status: true,
+	}
+	telemetry.Cell.FiveG = api.CellTelemetry{
+		Bandwidth: "100M", Cqi: 11, NRArfcn: 520110, Mcc: "310", Mnc: "260", Pci: 403, Plmn: "310260", Tac: "11801", Status: true,
+	}
+	return telemetry
+}
+
+// DefaultSIM returns the SIM of DefaultGateway
+func DefaultSIM() api.SIMResponse {
+	return api.SIMResponse{SIM: api.SIM{ICCID: "8901260000000000001", IMEI: "350000000000001", IMSI: "310260000000001", Status: true}}
+}
+
+// DefaultAccessPoints returns one SSID broadcast on both radios
+func DefaultAccessPoints() api.AccessPoints {
+	radio := api.Radio{
+		AirtimeFairness: true, Channel: "Auto", ChannelBandwidth: "Auto", IsMUMIMOEnabled: true, IsRadioEnabled: true,
+		IsWMMEnabled: true, MaxClients: 128, Mode: "auto", TransmissionPower: "100%",
+	}
+	accessPoints := api.AccessPoints{
+		TwoFourGHz: radio,
+		FiveGHz:    radio,
+		SSIDs: []api.SSID{{
+			TwoFourGHz: true, FiveGHz: true, EncryptionMode: "AES", EncryptionVersion: "WPA2/WPA3",
+			IsBroadcastEnabled: true, Name: "FakeGateway", Key: "fakepassword",
+		}},
+	}
+	accessPoints.BandSteering.IsEnabled = true
+	return accessPoints
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,267 @@
+package fakegw
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"local/tmo/api"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupServer(t *testing.T, config Config) (*httptest.Server, *api.Client) {
+	config.Username = "testuser"
+	config.Password = "testpassword"
+	config.Logger = log.New(io.Discard, "", 0)
+
+	srv := httptest.NewServer(NewServer(config))
+	t.Cleanup(srv.Close)
+
+	client := api.NewClientWithConfig(api.ClientConfig{
+		BaseURL:  srv.URL + BasePath,
+		Username: "testuser",
+		Password: "testpassword",
+		Logger:   log.New(io.Discard, "", 0),
+	}, nil)
+
+	return srv, client
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Random Walk With Carrier Aggregation", func(t *testing.T) {
+		base := DefaultGateway()
+		base.Signal.FiveG.Bands = []string{"n41", "n25"}
+		_, client := setupServer(t, Config{Source: NewRandomWalk(base, 1)})
+
+		for range 10 {
+			gateway, err := client.GetGateway(ctx)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if len(gateway.Signal.FiveG.Bands) != 2 {
+				t.Fatalf("Expected 2 5G bands, got %v", gateway.Signal.FiveG.Bands)
+			}
+			if gateway.Signal.FiveG.Rsrp > -44 || gateway.Signal.FiveG.Rsrp < -140 {
+				t.Errorf("Unexpected 5G RSRP %d", gateway.Signal.FiveG.Rsrp)
+			}
+			if gateway.Time.LocalTime == 0 {
+				t.Error("Expected local time to be set")
+			}
+		}
+	})
+
+	t.Run("Clients", func(t *testing.T) {
+		_, client := setupServer(t, Config{})
+		clients, err := client.GetClients(ctx)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if len(clients.Clients) != 3 || clients.Clients["5.0ghz"][0].Name != "laptop" {
+			t.Errorf("Expected the default clients, got %+v", clients)
+		}
+	})
+
+	t.Run("Telemetry", func(t *testing.T) {
+		_, client := setupServer(t, Config{})
+		telemetry, err := client.GetCellTelemetry(ctx)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if telemetry.Cell.FiveG.Arfcn() != 520110 || telemetry.Cell.FourG.Pci != 218 {
+			t.Errorf("Expected the default telemetry, got %+v", telemetry)
+		}
+		sim, err := client.GetSIM(ctx)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if sim.SIM.ICCID != DefaultSIM().SIM.ICCID {
+			t.Errorf("Expected the default SIM, got %+v", sim)
+		}
+	})
+
+	t.Run("Reboot", func(t *testing.T) {
+		srv := NewServer(Config{Username: "testuser", Password: "testpassword", Logger: log.New(io.Discard, "", 0)})
+		srv.started = time.Now().Add(-time.Hour)
+		httpSrv := httptest.NewServer(srv)
+		defer httpSrv.Close()
+		client := api.NewClientWithConfig(api.ClientConfig{
+			BaseURL: httpSrv.URL + BasePath, Username: "testuser", Password: "testpassword", Logger: log.New(io.Discard, "", 0),
+		}, nil)
+
+		err := client.Reboot(ctx)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if srv.Reboots() != 1 {
+			t.Errorf("Expected 1 reboot, got %d", srv.Reboots())
+		}
+
+		// The token is forgotten by the reboot, so the client logs in again
+		gateway, err := client.GetGateway(ctx)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if gateway.Time.UpTime > 60 {
+			t.Errorf("Expected the uptime to restart, got %d", gateway.Time.UpTime)
+		}
+	})
+
+	t.Run("Access Points", func(t *testing.T) {
+		_, client := setupServer(t, Config{})
+		accessPoints, err := client.GetAccessPoints(ctx)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if len(accessPoints.SSIDs) != 1 || accessPoints.SSIDs[0].Name != "FakeGateway" {
+			t.Fatalf("Expected the default access points, got %+v", accessPoints)
+		}
+
+		accessPoints.FiveGHz.IsRadioEnabled = false
+		accessPoints.SSIDs[0].Name = "Renamed"
+		err = client.SetAccessPoints(ctx, accessPoints)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		updated, err := client.GetAccessPoints(ctx)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if updated.FiveGHz.IsRadioEnabled || updated.SSIDs[0].Name != "Renamed" || !updated.TwoFourGHz.IsRadioEnabled {
+			t.Errorf("Unexpected updated access points: %+v", updated)
+		}
+	})
+
+	t.Run("Invalid Credentials", func(t *testing.T) {
+		srv, _ := setupServer(t, Config{})
+		client := api.NewClientWithConfig(api.ClientConfig{
+			BaseURL:  srv.URL + BasePath,
+			Username: "testuser",
+			Password: "wrong",
+			Logger:   log.New(io.Discard, "", 0),
+		}, nil)
+		if err := client.Login(ctx); err == nil {
+			t.Error("Expected error for invalid password")
+		}
+	})
+
+	t.Run("Expired Token Rejected", func(t *testing.T) {
+		srv, _ := setupServer(t, Config{TokenTTL: time.Millisecond})
+
+		// The api client always refreshes a token this short lived, so log in by hand
+		resp, err := http.Post(srv.URL+BasePath+"/auth/login", "application/json",
+			strings.NewReader(`{"username":"testuser","password":"testpassword"}`))
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		var login struct {
+			Auth struct {
+				Token string `json:"token"`
+			} `json:"auth"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&login)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+BasePath+"/gateway/?get=all", nil)
+		req.Header.Set("Authorization", "Bearer "+login.Auth.Token)
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected 401 for expired token, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Server Error Fault", func(t *testing.T) {
+		_, client := setupServer(t, Config{Faults: Faults{ServerErrorRate: 1}})
+		_, err := client.GetGateway(ctx)
+		if err == nil || !strings.Contains(err.Error(), "500") {
+			t.Errorf("Expected 500 error, got %v", err)
+		}
+	})
+
+	t.Run("Unauthorized Fault", func(t *testing.T) {
+		_, client := setupServer(t, Config{Faults: Faults{UnauthorizedRate: 1}})
+		if err := client.Login(ctx); err != nil {
+			t.Fatalf("Expected login to succeed, got %s", err)
+		}
+		_, err := client.GetGateway(ctx)
+		if err == nil || !strings.Contains(err.Error(), "401") {
+			t.Errorf("Expected 401 error, got %v", err)
+		}
+	})
+
+	t.Run("Timeout Fault", func(t *testing.T) {
+		_, client := setupServer(t, Config{Faults: Faults{TimeoutRate: 1, Timeout: time.Minute}})
+		timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, err := client.GetGateway(timeoutCtx)
+		if err == nil {
+			t.Error("Expected timeout error")
+		}
+	})
+}
+
+func TestScript(t *testing.T) {
+	csv := "5g_bands,5g_rsrp,5g_sinr,registration\n" +
+		"n41 n25,-90,20,registered\n" +
+		"n41,-120,-3,searching\n"
+
+	script, err := NewScript(strings.NewReader(csv), DefaultGateway())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	first, _ := script.Next()
+	if len(first.Signal.FiveG.Bands) != 2 || first.Signal.FiveG.Rsrp != -90 || first.Signal.FiveG.Sinr != 20 {
+		t.Errorf("Unexpected first row: %+v", first.Signal.FiveG)
+	}
+	if first.Signal.FourG.Rsrp != DefaultGateway().Signal.FourG.Rsrp {
+		t.Error("Expected columns missing from the script to keep the base value")
+	}
+
+	second, _ := script.Next()
+	if second.Signal.Generic.Registration != "searching" {
+		t.Errorf("Expected registration 'searching', got '%s'", second.Signal.Generic.Registration)
+	}
+
+	third, _ := script.Next()
+	if third.Signal.FiveG.Rsrp != -90 {
+		t.Error("Expected script to start over after the last row")
+	}
+
+	_, err = NewScript(strings.NewReader("5g_unknown\n1\n"), DefaultGateway())
+	if err == nil {
+		t.Error("Expected error for unknown column")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	captured := `{"signal":{"5g":{"bands":["n41"],"rsrp":-100}}}
+{"signal":{"5g":{"bands":["n71"],"rsrp":-110}}}
+`
+	replay, err := NewReplay(strings.NewReader(captured))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for _, expected := range []int{-100, -110, -100} {
+		gateway, _ := replay.Next()
+		if gateway.Signal.FiveG.Rsrp != expected {
+			t.Errorf("Expected RSRP %d, got %d", expected, gateway.Signal.FiveG.Rsrp)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package fakegw
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"local/tmo/api"
+	mathrand "math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Source produces the gateway response returned for each request
+type Source interface {
+	Next() (api.GatewayResponse, error)
+}
+
+// RandomWalk drifts the signal metrics of a base response by a small random step on every request
+type RandomWalk struct {
+	current api.GatewayResponse
+	rand    *mathrand.Rand
+}
+
+// NewRandomWalk creates a RandomWalk starting at the base response
+func NewRandomWalk(base api.GatewayResponse, seed uint64) *RandomWalk {
+	return &RandomWalk{
+		current: base,
+		rand:    mathrand.New(mathrand.NewPCG(seed, seed+1)),
+	}
+}
+
+// Next returns the current response and takes one step
+func (w *RandomWalk) Next() (api.GatewayResponse, error) {
+	gateway := copyGateway(w.current)
+	w.step(&w.current.Signal.FourG)
+	w.step(&w.current.Signal.FiveG)
+	return gateway, nil
+}
+
+// step moves each metric by up to 2 in either direction, staying within the range the gateway reports
+func (w *RandomWalk) step(stats *api.SignalStats) {
+	walk := func(value, lo, hi int) int {
+		return clamp(value+w.rand.IntN(5)-2, lo, hi)
+	}
+
+	stats.Rsrp = walk(stats.Rsrp, -140, -44)
+	stats.Rsrq = walk(stats.Rsrq, -20, -3)
+	stats.Rssi = walk(stats.Rssi, -110, -50)
+	stats.Sinr = walk(stats.Sinr, -10, 30)
+	stats.Bars = float64(clamp((stats.Rsrp+130)/10, 0, 5))
+}
+
+// Script plays back rows of a CSV file in order, starting over after the last row.
+//
+// The first row is a header naming the columns, any column not present keeps the value of the base response:
+// uptime, registration, roaming, apn, 4g_bands, 4g_cid, 4g_enbid, 4g_rsrp, 4g_rsrq, 4g_rssi, 4g_sinr, 4g_bars,
+// 5g_bands, 5g_cid, 5g_gnbid, 5g_rsrp, 5g_rsrq, 5g_rssi, 5g_sinr and 5g_bars.
+// Bands are separated by spaces, e.g. "n41 n25" for carrier aggregation.
+type Script struct {
+	rows []api.GatewayResponse
+	next int
+}
+
+// NewScript parses a CSV script applied on top of the base response
+func NewScript(r io.Reader, base api.GatewayResponse) (*Script, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read script: %w", err)
+	}
+
+	if len(records) < 2 {
+		return nil, errors.New("script must have a header and at least one row")
+	}
+
+	header := records[0]
+	script := &Script{}
+	for i, record := range records[1:] {
+		gateway := copyGateway(base)
+		for j, column := range header {
+			err := setColumn(&gateway, strings.TrimSpace(column), strings.TrimSpace(record[j]))
+			if err != nil {
+				return nil, fmt.Errorf("script row %d: %w", i+1, err)
+			}
+		}
+		script.rows = append(script.rows, gateway)
+	}
+
+	return script, nil
+}
+
+// Next returns the next scripted response
+func (s *Script) Next() (api.GatewayResponse, error) {
+	gateway := copyGateway(s.rows[s.next])
+	s.next = (s.next + 1) % len(s.rows)
+	return gateway, nil
+}
+
+// setColumn sets the response field named by a script column
+func setColumn(gateway *api.GatewayResponse, column, value string) error {
+	generic := &gateway.Signal.Generic
+	switch column {
+	case "uptime":
+		return setInt(&gateway.Time.UpTime, value)
+	case "registration":
+		generic.Registration = value
+		return nil
+	case "roaming":
+		return setBool(&generic.Roaming, value)
+	case "apn":
+		generic.Apn = value
+		return nil
+	}
+
+	generation, metric, ok := strings.Cut(column, "_")
+	var stats *api.SignalStats
+	switch {
+	case ok && generation == "4g":
+		stats = &gateway.Signal.FourG
+	case ok && generation == "5g":
+		stats = &gateway.Signal.FiveG
+	default:
+		return fmt.Errorf("unknown column %q", column)
+	}
+
+	switch metric {
+	case "bands":
+		stats.Bands = strings.Fields(value)
+		return nil
+	case "cid":
+		return setInt(&stats.Cid, value)
+	case "enbid":
+		return setInt(&stats.ENBID, value)
+	case "gnbid":
+		return setInt(&stats.GNBID, value)
+	case "rsrp":
+		return setInt(&stats.Rsrp, value)
+	case "rsrq":
+		return setInt(&stats.Rsrq, value)
+	case "rssi":
+		return setInt(&stats.Rssi, value)
+	case "sinr":
+		return setInt(&stats.Sinr, value)
+	case "bars":
+		bars, err := strconv.ParseFloat(value, 64)
+		stats.Bars = bars
+		return err
+	default:
+		return fmt.Errorf("unknown column %q", column)
+	}
+}
+
+// Replay plays back captured gateway responses in order, starting over after the last one
+type Replay struct {
+	responses []api.GatewayResponse
+	next      int
+}
+
+// NewReplay reads a stream of captured JSON gateway responses, one per line.
+// Lines may also be recordings written by api.Recorder, in which case the recorded body is replayed.
+func NewReplay(r io.Reader) (*Replay, error) {
+	replay := &Replay{}
+	decoder := json.NewDecoder(r)
+	for {
+		var line json.RawMessage
+		err := decoder.Decode(&line)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode response %d: %w", len(replay.responses)+1, err)
+		}
+
+		var gateway api.GatewayResponse
+		var recording api.Recording
+		err = json.Unmarshal(line, &recording)
+		if err == nil && len(recording.Body) > 0 {
+			gateway, err = recording.Gateway()
+		} else {
+			err = json.Unmarshal(line, &gateway)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode response %d: %w", len(replay.responses)+1, err)
+		}
+		replay.responses = append(replay.responses, gateway)
+	}
+
+	if len(replay.responses) == 0 {
+		return nil, errors.New("no responses to replay")
+	}
+
+	return replay, nil
+}
+
+// Next returns the next captured response
+func (r *Replay) Next() (api.GatewayResponse, error) {
+	gateway := copyGateway(r.responses[r.next])
+	r.next = (r.next + 1) % len(r.responses)
+	return gateway, nil
+}
+
+// copyGateway copies a response so the band slices are not shared
+func copyGateway(gateway api.GatewayResponse) api.GatewayResponse {
+	gateway.Signal.FourG.Bands = slices.Clone(gateway.Signal.FourG.Bands)
+	gateway.Signal.FiveG.Bands = slices.Clone(gateway.Signal.FiveG.Bands)
+	return gateway
+}
+
+func setInt(dst *int, value string) error {
+	v, err := strconv.Atoi(value)
+	*dst = v
+	return err
+}
+
+func setBool(dst *bool, value string) error {
+	v, err := strconv.ParseBool(value)
+	*dst = v
+	return err
+}
+
+func clamp(value, lo, hi int) int {
+	if value < lo {
+		return lo
+	}
+	if value > hi {
+		return hi
+	}
+	return value
+}
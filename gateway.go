@@ -0,0 +1,363 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"local/tmo/api"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const gatewayUsage = `Usage: tmo gateway [flags] <command>
+
+Commands:
+  reboot                        restart the gateway
+  wifi                          show the radios and SSIDs
+  wifi set [flags]              change an SSID, run "tmo gateway wifi set -h" for its flags
+  radio 2.4ghz|5.0ghz on|off    enable or disable a radio
+
+Changes are printed and confirmed before they are sent to the gateway.
+
+Flags:
+`
+
+// configFlags are the gateway command flags passed on to loadConfig
+var configFlags = []string{"config", "url", "username", "password", "driver"}
+
+// gatewayCommand administers one gateway from the command line
+type gatewayCommand struct {
+	client  *api.Client
+	gateway GatewayConfig
+	dryRun  bool
+	yes     bool
+	stdin   *bufio.Reader
+	stdout  io.Writer
+}
+
+// runGateway runs the tmo gateway command with its arguments, the gateway settings are loaded like the poller's
+func runGateway(args []string, getenv func(string) string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("tmo gateway", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	flags.Usage = func() {
+		fmt.Fprint(stdout, gatewayUsage)
+		flags.PrintDefaults()
+	}
+	flags.String("config", "", "YAML config file, defaults to $GATEWAY_CONFIG")
+	flags.String("url", "", "gateway API URL, when no gateways are listed in the config file")
+	flags.String("username", "", "gateway username")
+	flags.String("password", "", "gateway password")
+	flags.String("driver", "", "gateway API, auto, tmi or nokia")
+	label := flags.String("gateway", "", "label of the gateway to administer, required if several are configured")
+	dryRun := flags.Bool("dry-run", false, "print the changes without sending them")
+	yes := flags.Bool("yes", false, "send the changes without asking for confirmation")
+	showKeys := flags.Bool("show-keys", false, "show the Wi-Fi passwords")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+
+	var configArgs []string
+	flags.Visit(func(f *flag.Flag) {
+		if slices.Contains(configFlags, f.Name) {
+			configArgs = append(configArgs, "-"+f.Name+"="+f.Value.String())
+		}
+	})
+	config, err := loadConfig(configArgs, getenv)
+	if err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	gateway, err := selectGateway(config.Gateways, *label)
+	if err != nil {
+		return err
+	}
+
+	cmd := &gatewayCommand{
+		client: api.NewClientWithConfig(api.ClientConfig{
+			BaseURL:  gateway.URL,
+			Username: gateway.Username,
+			Password: gateway.Password,
+			Driver:   gateway.Driver,
+			Logger:   log.New(io.Discard, "", 0),
+		}, &http.Client{Timeout: gateway.RequestTimeout}),
+		gateway: gateway,
+		dryRun:  *dryRun,
+		yes:     *yes,
+		stdin:   bufio.NewReader(stdin),
+		stdout:  stdout,
+	}
+
+	ctx := context.Background()
+	command := flags.Args()
+	switch {
+	case command[0] == "reboot" && len(command) == 1:
+		return cmd.reboot(ctx)
+	case command[0] == "wifi" && len(command) == 1:
+		return cmd.showWifi(ctx, *showKeys)
+	case command[0] == "wifi" && command[1] == "set":
+		return cmd.setWifi(ctx, command[2:])
+	case command[0] == "radio" && len(command) == 3:
+		return cmd.setRadio(ctx, command[1], command[2])
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown command: %s", strings.Join(command, " "))
+	}
+}
+
+// selectGateway returns the gateway with the given label, or the only gateway if label is empty
+func selectGateway(gateways []GatewayConfig, label string) (GatewayConfig, error) {
+	if label == "" {
+		if len(gateways) != 1 {
+			return GatewayConfig{}, fmt.Errorf("%d gateways are configured, choose one with -gateway", len(gateways))
+		}
+		return gateways[0], nil
+	}
+
+	for _, gateway := range gateways {
+		if gateway.Label == label {
+			return gateway, nil
+		}
+	}
+	return GatewayConfig{}, fmt.Errorf("no gateway labeled %s", label)
+}
+
+// name describes the gateway in prompts
+func (g *gatewayCommand) name() string {
+	if g.gateway.Label == "" {
+		return g.gateway.URL
+	}
+	return fmt.Sprintf("%s (%s)", g.gateway.Label, g.gateway.URL)
+}
+
+// confirm prints the changes and reports whether to send them, asking unless -yes is set
+func (g *gatewayCommand) confirm(action string, changes []string) (bool, error) {
+	fmt.Fprintf(g.stdout, "%s gateway %s\n", action, g.name())
+	for _, change := range changes {
+		fmt.Fprintf(g.stdout, "  %s\n", change)
+	}
+
+	if g.dryRun {
+		fmt.Fprintln(g.stdout, "Dry run, nothing was sent")
+		return false, nil
+	}
+	if g.yes {
+		return true, nil
+	}
+
+	fmt.Fprint(g.stdout, "Proceed? [y/N] ")
+	answer, err := g.stdin.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, fmt.Errorf("error reading confirmation: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		fmt.Fprintln(g.stdout, "Aborted")
+		return false, nil
+	}
+}
+
+// reboot restarts the gateway
+func (g *gatewayCommand) reboot(ctx context.Context) error {
+	ok, err := g.confirm("Reboot", nil)
+	if !ok || err != nil {
+		return err
+	}
+
+	err = g.client.Reboot(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(g.stdout, "Rebooting, the gateway is back in a few minutes")
+	return nil
+}
+
+// showWifi prints the radios and SSIDs
+func (g *gatewayCommand) showWifi(ctx context.Context, showKeys bool) error {
+	accessPoints, err := g.client.GetAccessPoints(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, band := range []string{api.Band24GHz, api.Band5GHz} {
+		radio := accessPoints.Radio(band)
+		fmt.Fprintf(g.stdout, "Radio %s: %s, channel %s, width %s, power %s\n",
+			band, onOff(radio.IsRadioEnabled), radio.Channel, radio.ChannelBandwidth, radio.TransmissionPower)
+	}
+	fmt.Fprintf(g.stdout, "Band steering: %s\n", onOff(accessPoints.BandSteering.IsEnabled))
+
+	for _, ssid := range accessPoints.SSIDs {
+		key := "********"
+		if showKeys {
+			key = ssid.Key
+		}
+		fmt.Fprintf(g.stdout, "SSID %q: bands %s, broadcast %t, guest %t, %s %s, key %s\n",
+			ssid.Name, ssidBands(ssid), ssid.IsBroadcastEnabled, ssid.Guest, ssid.EncryptionVersion, ssid.EncryptionMode, key)
+	}
+	return nil
+}
+
+// setWifi changes the name, key, broadcast or bands of an SSID
+func (g *gatewayCommand) setWifi(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("tmo gateway wifi set", flag.ContinueOnError)
+	flags.SetOutput(g.stdout)
+	ssidName := flags.String("ssid", "", "SSID to change, required if the gateway has several")
+	name := flags.String("name", "", "new SSID name")
+	key := flags.String("key", "", "new Wi-Fi password, 8 to 63 characters")
+	broadcast := flags.String("broadcast", "", "broadcast the SSID, true or false")
+	bands := flags.String("bands", "", "comma separated bands to enable the SSID on, 2.4ghz and 5.0ghz")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	accessPoints, err := g.client.GetAccessPoints(ctx)
+	if err != nil {
+		return err
+	}
+
+	ssid, err := findSSID(&accessPoints, *ssidName)
+	if err != nil {
+		return err
+	}
+
+	var changes []string
+	if *name != "" && *name != ssid.Name {
+		if len(*name) > 32 {
+			return fmt.Errorf("SSID names are at most 32 bytes, got %d", len(*name))
+		}
+		changes = append(changes, fmt.Sprintf("SSID %q: name %q -> %q", ssid.Name, ssid.Name, *name))
+	}
+	if *key != "" && *key != ssid.Key {
+		if len(*key) < 8 || len(*key) > 63 {
+			return fmt.Errorf("Wi-Fi passwords are 8 to 63 characters, got %d", len(*key))
+		}
+		changes = append(changes, fmt.Sprintf("SSID %q: key changed", ssid.Name))
+		ssid.Key = *key
+	}
+	if *broadcast != "" {
+		enabled, err := strconv.ParseBool(*broadcast)
+		if err != nil {
+			return fmt.Errorf("invalid -broadcast: %w", err)
+		}
+		if enabled != ssid.IsBroadcastEnabled {
+			changes = append(changes, fmt.Sprintf("SSID %q: broadcast %t -> %t", ssid.Name, ssid.IsBroadcastEnabled, enabled))
+			ssid.IsBroadcastEnabled = enabled
+		}
+	}
+	if *bands != "" {
+		list := strings.Split(*bands, ",")
+		for _, band := range list {
+			if accessPoints.Radio(band) == nil {
+				return fmt.Errorf("unknown band %q, expected %s or %s", band, api.Band24GHz, api.Band5GHz)
+			}
+		}
+		before := ssidBands(*ssid)
+		ssid.TwoFourGHz = slices.Contains(list, api.Band24GHz)
+		ssid.FiveGHz = slices.Contains(list, api.Band5GHz)
+		if after := ssidBands(*ssid); after != before {
+			changes = append(changes, fmt.Sprintf("SSID %q: bands %s -> %s", ssid.Name, before, after))
+		}
+	}
+	if *name != "" {
+		ssid.Name = *name
+	}
+
+	return g.apply(ctx, accessPoints, changes)
+}
+
+// setRadio enables or disables the radio of a band
+func (g *gatewayCommand) setRadio(ctx context.Context, band, state string) error {
+	if state != "on" && state != "off" {
+		return fmt.Errorf("expected on or off, got %q", state)
+	}
+
+	accessPoints, err := g.client.GetAccessPoints(ctx)
+	if err != nil {
+		return err
+	}
+
+	radio := accessPoints.Radio(band)
+	if radio == nil {
+		return fmt.Errorf("unknown band %q, expected %s or %s", band, api.Band24GHz, api.Band5GHz)
+	}
+
+	var changes []string
+	enabled := state == "on"
+	if radio.IsRadioEnabled != enabled {
+		changes = append(changes, fmt.Sprintf("Radio %s: %s -> %s", band, onOff(radio.IsRadioEnabled), state))
+		radio.IsRadioEnabled = enabled
+	}
+
+	return g.apply(ctx, accessPoints, changes)
+}
+
+// apply sends the Wi-Fi configuration once the changes to it are confirmed
+func (g *gatewayCommand) apply(ctx context.Context, accessPoints api.AccessPoints, changes []string) error {
+	if len(changes) == 0 {
+		fmt.Fprintln(g.stdout, "Nothing to change")
+		return nil
+	}
+
+	ok, err := g.confirm("Change the Wi-Fi of", append(changes, "The Wi-Fi restarts, disconnecting every client"))
+	if !ok || err != nil {
+		return err
+	}
+
+	err = g.client.SetAccessPoints(ctx, accessPoints)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(g.stdout, "Wi-Fi updated")
+	return nil
+}
+
+// findSSID returns the SSID with the given name, or the only SSID if name is empty
+func findSSID(accessPoints *api.AccessPoints, name string) (*api.SSID, error) {
+	if name != "" {
+		ssid := accessPoints.SSID(name)
+		if ssid == nil {
+			return nil, fmt.Errorf("no SSID named %q", name)
+		}
+		return ssid, nil
+	}
+
+	if len(accessPoints.SSIDs) != 1 {
+		return nil, errors.New("the gateway has several SSIDs, choose one with -ssid")
+	}
+	return &accessPoints.SSIDs[0], nil
+}
+
+// ssidBands lists the bands an SSID is enabled on
+func ssidBands(ssid api.SSID) string {
+	var bands []string
+	if ssid.TwoFourGHz {
+		bands = append(bands, api.Band24GHz)
+	}
+	if ssid.FiveGHz {
+		bands = append(bands, api.Band5GHz)
+	}
+	if len(bands) == 0 {
+		return "none"
+	}
+	return strings.Join(bands, ",")
+}
+
+func onOff(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+	return "off"
+}
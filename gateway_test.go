@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"local/tmo/api"
+	"local/tmo/fakegw"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// setupGateway starts a simulated gateway and returns it with the flags that point the gateway command at it
+func setupGateway(t *testing.T) (*fakegw.Server, []string) {
+	srv := fakegw.NewServer(fakegw.Config{Username: "admin", Password: "secret", Logger: log.New(io.Discard, "", 0)})
+	httpSrv := httptest.NewServer(srv)
+	t.Cleanup(httpSrv.Close)
+	return srv, []string{"-url=" + httpSrv.URL + fakegw.BasePath, "-username=admin", "-password=secret"}
+}
+
+// accessPoints reads the Wi-Fi configuration of the simulated gateway behind the flags
+func accessPoints(t *testing.T, args []string) api.AccessPoints {
+	client := api.NewClientWithConfig(api.ClientConfig{
+		BaseURL:  strings.TrimPrefix(args[0], "-url="),
+		Username: "admin",
+		Password: "secret",
+		Logger:   log.New(io.Discard, "", 0),
+	}, nil)
+	accessPoints, err := client.GetAccessPoints(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	return accessPoints
+}
+
+func TestGatewayCommand(t *testing.T) {
+	noEnv := env(nil)
+
+	t.Run("Reboot Confirmed", func(t *testing.T) {
+		srv, args := setupGateway(t)
+		var out bytes.Buffer
+		err := runGateway(append(args, "reboot"), noEnv, strings.NewReader("y\n"), &out)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if srv.Reboots() != 1 || !strings.Contains(out.String(), "Proceed? [y/N]") {
+			t.Errorf("Expected a confirmed reboot, got %d reboots and %q", srv.Reboots(), out.String())
+		}
+	})
+
+	t.Run("Reboot Declined", func(t *testing.T) {
+		srv, args := setupGateway(t)
+		var out bytes.Buffer
+		err := runGateway(append(args, "reboot"), noEnv, strings.NewReader("\n"), &out)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if srv.Reboots() != 0 || !strings.Contains(out.String(), "Aborted") {
+			t.Errorf("Expected the reboot to be aborted, got %d reboots and %q", srv.Reboots(), out.String())
+		}
+	})
+
+	t.Run("Dry Run", func(t *testing.T) {
+		srv, args := setupGateway(t)
+		var out bytes.Buffer
+		err := runGateway(append(args, "-dry-run", "-yes", "radio", "5.0ghz", "off"), noEnv, strings.NewReader(""), &out)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if !strings.Contains(out.String(), "Radio 5.0ghz: on -> off") || !strings.Contains(out.String(), "Dry run") {
+			t.Errorf("Expected the change to be printed, got %q", out.String())
+		}
+		if !accessPoints(t, args).FiveGHz.IsRadioEnabled || srv.Reboots() != 0 {
+			t.Error("Expected nothing to be sent")
+		}
+	})
+
+	t.Run("Radio", func(t *testing.T) {
+		_, args := setupGateway(t)
+		err := runGateway(append(args, "-yes", "radio", "5.0ghz", "off"), noEnv, strings.NewReader(""), io.Discard)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		updated := accessPoints(t, args)
+		if updated.FiveGHz.IsRadioEnabled || !updated.TwoFourGHz.IsRadioEnabled {
+			t.Errorf("Expected only the 5GHz radio to be off, got %+v", updated)
+		}
+
+		var out bytes.Buffer
+		err = runGateway(append(args, "-yes", "radio", "5.0ghz", "off"), noEnv, strings.NewReader(""), &out)
+		if err != nil || !strings.Contains(out.String(), "Nothing to change") {
+			t.Errorf("Expected nothing to change, got %q (%v)", out.String(), err)
+		}
+	})
+
+	t.Run("Wi-Fi Set", func(t *testing.T) {
+		_, args := setupGateway(t)
+		var out bytes.Buffer
+		command := append(args, "wifi", "set", "-name=Renamed", "-key=newpassword", "-broadcast=false", "-bands=5.0ghz")
+		err := runGateway(command, noEnv, strings.NewReader("yes\n"), &out)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if strings.Contains(out.String(), "newpassword") {
+			t.Errorf("Expected the key to be hidden, got %q", out.String())
+		}
+
+		ssid := accessPoints(t, args).SSIDs[0]
+		if ssid.Name != "Renamed" || ssid.Key != "newpassword" || ssid.IsBroadcastEnabled || ssid.TwoFourGHz || !ssid.FiveGHz {
+			t.Errorf("Unexpected SSID: %+v", ssid)
+		}
+
+		out.Reset()
+		err = runGateway(append(args, "wifi"), noEnv, strings.NewReader(""), &out)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if !strings.Contains(out.String(), `SSID "Renamed": bands 5.0ghz, broadcast false`) || strings.Contains(out.String(), "newpassword") {
+			t.Errorf("Unexpected Wi-Fi listing: %q", out.String())
+		}
+	})
+
+	t.Run("Invalid Changes", func(t *testing.T) {
+		_, args := setupGateway(t)
+		for _, command := range [][]string{
+			{"wifi", "set", "-key=short"},
+			{"wifi", "set", "-ssid=Missing", "-name=New"},
+			{"wifi", "set", "-bands=6ghz"},
+			{"radio", "6ghz", "on"},
+			{"radio", "5.0ghz", "maybe"},
+			{"shutdown"},
+		} {
+			err := runGateway(append(args, command...), noEnv, strings.NewReader("y\n"), io.Discard)
+			if err == nil {
+				t.Errorf("Expected %v to fail", command)
+			}
+		}
+		if accessPoints(t, args).SSIDs[0].Name != "FakeGateway" {
+			t.Error("Expected the Wi-Fi to be unchanged")
+		}
+	})
+
+	t.Run("Usage", func(t *testing.T) {
+		err := runGateway(nil, noEnv, strings.NewReader(""), io.Discard)
+		if !errors.Is(err, flag.ErrHelp) {
+			t.Errorf("Expected flag.ErrHelp, got %v", err)
+		}
+	})
+}
+
+func TestSelectGateway(t *testing.T) {
+	gateways := []GatewayConfig{{Label: "home"}, {Label: "lab"}}
+
+	gateway, err := selectGateway(gateways, "lab")
+	if err != nil || gateway.Label != "lab" {
+		t.Errorf("Expected the lab gateway, got %+v (%v)", gateway, err)
+	}
+	_, err = selectGateway(gateways, "")
+	if err == nil {
+		t.Error("Expected an error choosing between several gateways")
+	}
+	_, err = selectGateway(gateways, "office")
+	if err == nil {
+		t.Error("Expected an error for an unknown label")
+	}
+	gateway, err = selectGateway(gateways[:1], "")
+	if err != nil || gateway.Label != "home" {
+		t.Errorf("Expected the only gateway, got %+v (%v)", gateway, err)
+	}
+}
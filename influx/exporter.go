@@ -0,0 +1,278 @@
+package influx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"local/tmo/api"
+	"local/tmo/backoff"
+	"log"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// Defaults of the batching and spooling settings
+const (
+	DefaultBatchSize     = 500
+	DefaultFlushInterval = 10 * time.Second
+	DefaultMaxSpoolBytes = 64 << 20
+)
+
+// shutdownTimeout limits how long the last flush may take once the exporter is stopped
+const shutdownTimeout = 5 * time.Second
+
+// retryBackoff spaces out the writes while the sink is failing, spooled batches wait until the next attempt
+var retryBackoff = backoff.Policy{Initial: 10 * time.Second, Max: 5 * time.Minute, Multiplier: 2, Jitter: 0.2}
+
+// Config holds the settings of the exporter, it is enabled when URL or File is set
+type Config struct {
+	// URL is the write endpoint batches are posted to
+	URL string `yaml:"url"`
+	// Token authorizes the posts as "Token <token>", InfluxDB 1.8 accepts "username:password"
+	Token string `yaml:"token"`
+	// File is appended to instead of posting to a URL
+	File string `yaml:"file"`
+	// BatchSize is how many lines are written at once at most, a full batch is written before the flush interval
+	BatchSize int `yaml:"batch_size"`
+	// FlushInterval is how long lines wait to be written at most
+	FlushInterval time.Duration `yaml:"flush_interval"`
+	// SpoolDir keeps the batches that failed to be written until the sink is back, they are dropped if it is empty
+	SpoolDir string `yaml:"spool_dir"`
+	// MaxSpoolBytes is the size of the spool the oldest batches are dropped at, 0 never drops them
+	MaxSpoolBytes int64 `yaml:"max_spool_bytes"`
+}
+
+// Enabled reports whether the exporter has a sink to write to
+func (c Config) Enabled() bool {
+	return c.URL != "" || c.File != ""
+}
+
+// Validate checks the settings of an enabled exporter, reporting every problem together
+func (c Config) Validate() error {
+	var errs []error
+	if c.URL != "" && c.File != "" {
+		errs = append(errs, errors.New("url and file are mutually exclusive"))
+	}
+	if c.URL != "" {
+		u, err := url.Parse(c.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("url must be an http or https URL, got %q", c.URL))
+		}
+	}
+	if c.BatchSize <= 0 {
+		errs = append(errs, errors.New("batch size must be positive"))
+	}
+	if c.FlushInterval <= 0 {
+		errs = append(errs, errors.New("flush interval must be positive"))
+	}
+	if c.MaxSpoolBytes < 0 {
+		errs = append(errs, errors.New("max spool bytes must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// Exporter batches the lines of every successful poll and writes them to a sink, spooling the batches it fails to
+// write and retrying them in order once the sink is back
+type Exporter struct {
+	config Config
+	sink   Sink
+	spool  *spool
+	logger *log.Logger
+	// target is where the lines are written for logs, without the query of the URL that may hold credentials
+	target string
+	// full is signaled when a batch is ready to be written before the flush interval
+	full chan struct{}
+
+	mu      sync.Mutex
+	pending []byte
+	lines   int
+
+	// failures counts the failed writes in a row, no write is attempted before retryAt
+	failures int
+	retryAt  time.Time
+}
+
+// NewExporter creates an exporter writing to the URL or file of the config
+func NewExporter(config Config, logger *log.Logger) (*Exporter, error) {
+	e := &Exporter{
+		config: config,
+		logger: logger,
+		full:   make(chan struct{}, 1),
+	}
+	if config.URL != "" {
+		e.sink = NewHTTPSink(config.URL, config.Token)
+		u, err := url.Parse(config.URL)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing url: %w", err)
+		}
+		u.User, u.RawQuery = nil, ""
+		e.target = u.String()
+	} else {
+		e.sink = NewFileSink(config.File)
+		e.target = config.File
+	}
+	if config.SpoolDir != "" {
+		var err error
+		e.spool, err = newSpool(config.SpoolDir, config.MaxSpoolBytes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// ObservePoll queues the signal of a successful poll, failed polls are not exported
+func (e *Exporter) ObservePoll(label string, gateway api.GatewayResponse, duration time.Duration, err error) {
+	if err != nil {
+		return
+	}
+	at := time.Now()
+	if gateway.Time.LocalTime != 0 {
+		at = time.Unix(int64(gateway.Time.LocalTime), 0)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	before := len(e.pending)
+	e.pending = AppendLines(e.pending, label, gateway, at)
+	for _, c := range e.pending[before:] {
+		if c == '\n' {
+			e.lines++
+		}
+	}
+	if e.lines >= e.config.BatchSize {
+		select {
+		case e.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Run writes the queued lines every flush interval, or as soon as a batch is full, until the context is done. The
+// lines left are then written, or spooled.
+func (e *Exporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-e.full:
+		case <-ctx.Done():
+			ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+			e.Flush(ctx)
+			return
+		}
+		e.Flush(ctx)
+	}
+}
+
+// Flush writes the spooled batches oldest first, then the queued lines in batches. While the sink is failing, the
+// lines are spooled without trying to write them until the backoff has passed. It is not safe to call concurrently.
+func (e *Exporter) Flush(ctx context.Context) {
+	batches := e.take()
+
+	if time.Now().Before(e.retryAt) {
+		e.keep(batches)
+		return
+	}
+
+	err := e.drainSpool(ctx)
+	for len(batches) > 0 && err == nil {
+		err = e.write(ctx, batches[0])
+		if err == nil {
+			batches = batches[1:]
+		}
+	}
+
+	if err != nil {
+		e.keep(batches)
+		e.failures++
+		e.retryAt = time.Now().Add(retryBackoff.Delay(e.failures))
+		e.logger.Printf("Failed to export to %s, retrying in %s: %v", e.target, time.Until(e.retryAt).Round(time.Second), err)
+		return
+	}
+	if e.failures > 0 {
+		e.logger.Printf("Exporting to %s again", e.target)
+	}
+	e.failures = 0
+}
+
+// take removes the queued lines, split into batches of at most the batch size
+func (e *Exporter) take() [][]byte {
+	e.mu.Lock()
+	pending := e.pending
+	e.pending, e.lines = nil, 0
+	e.mu.Unlock()
+
+	var batches [][]byte
+	for len(pending) > 0 {
+		end, lines := 0, 0
+		for end < len(pending) && lines < e.config.BatchSize {
+			if pending[end] == '\n' {
+				lines++
+			}
+			end++
+		}
+		batches = append(batches, pending[:end])
+		pending = pending[end:]
+	}
+	return batches
+}
+
+// write writes a batch, a rejected batch is dropped as writing it again would not help
+func (e *Exporter) write(ctx context.Context, batch []byte) error {
+	err := e.sink.Write(ctx, batch)
+	if errors.Is(err, ErrRejected) {
+		e.logger.Printf("Dropping a batch rejected by %s: %v", e.target, err)
+		return nil
+	}
+	return err
+}
+
+// drainSpool writes the spooled batches oldest first, stopping at the first failure
+func (e *Exporter) drainSpool(ctx context.Context) error {
+	if e.spool == nil {
+		return nil
+	}
+	files, _, err := e.spool.files()
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		batch, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("error reading spooled batch: %w", err)
+		}
+		err = e.write(ctx, batch)
+		if err != nil {
+			return err
+		}
+		err = os.Remove(file)
+		if err != nil {
+			return fmt.Errorf("error removing spooled batch: %w", err)
+		}
+	}
+	return nil
+}
+
+// keep spools the batches, or drops them if there is no spool
+func (e *Exporter) keep(batches [][]byte) {
+	for _, batch := range batches {
+		if e.spool == nil {
+			e.logger.Printf("Dropping a batch of %d bytes, no spool directory is set", len(batch))
+			continue
+		}
+		removed, err := e.spool.add(batch)
+		if err != nil {
+			e.logger.Printf("Dropping a batch of %d bytes: %v", len(batch), err)
+		}
+		if removed > 0 {
+			e.logger.Printf("Dropped the %d oldest spooled batches, the spool is over %d bytes", removed, e.config.MaxSpoolBytes)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package influx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"local/tmo/api"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// influxStandIn accepts writes like InfluxDB, answering with status while it is not 204
+type influxStandIn struct {
+	*httptest.Server
+	mu      sync.Mutex
+	status  int
+	batches []string
+	headers http.Header
+}
+
+func newInfluxStandIn(t *testing.T) *influxStandIn {
+	s := &influxStandIn{status: http.StatusNoContent}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v2/write" {
+			http.NotFound(w, r)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.headers = r.Header
+		if s.status != http.StatusNoContent {
+			http.Error(w, `{"code":"unavailable"}`, s.status)
+			return
+		}
+		s.batches = append(s.batches, string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// setStatus makes the stand-in answer every write with the status
+func (s *influxStandIn) setStatus(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+// written returns the batches accepted so far
+func (s *influxStandIn) written() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.batches...)
+}
+
+// gatewayAt returns a response with both generations taken at the minute
+func gatewayAt(minute int) api.GatewayResponse {
+	return api.GatewayResponse{
+		Device: api.Device{Serial: "ABC123"},
+		Time:   api.Time{LocalTime: 1743465600 + minute*60},
+		Signal: api.Signal{
+			FourG: api.SignalStats{AntennaUsed: "Internal_directional", Bands: []string{"b66"}, Bars: 4, Cid: 12, ENBID: 310, Rsrp: -95, Rsrq: -8, Rssi: -70, Sinr: 10},
+			FiveG: api.SignalStats{AntennaUsed: "Internal_directional", Bands: []string{"n41"}, Bars: 3.5, Cid: 34, GNBID: 567, Rsrp: -90 - minute, Rsrq: -11, Rssi: -80, Sinr: 15},
+		},
+	}
+}
+
+func TestAppendLines(t *testing.T) {
+	at := time.Unix(1743465600, 0)
+	lines := string(AppendLines(nil, "home", gatewayAt(0), at))
+	expected := "signal_4g,antenna=Internal_directional,band=b66,cell=12,gateway=home,node=310,serial=ABC123 bars=4,rsrp=-95i,rsrq=-8i,rssi=-70i,sinr=10i 1743465600000000000\n" +
+		"signal_5g,antenna=Internal_directional,band=n41,cell=34,gateway=home,node=567,serial=ABC123 bars=3.5,rsrp=-90i,rsrq=-11i,rssi=-80i,sinr=15i 1743465600000000000\n"
+	if lines != expected {
+		t.Errorf("Expected %q, got %q", expected, lines)
+	}
+
+	t.Run("Escaping", func(t *testing.T) {
+		gateway := api.GatewayResponse{Signal: api.Signal{FiveG: api.SignalStats{AntennaUsed: "External, 2x2=MIMO", Bands: []string{"n41"}}}}
+		lines := string(AppendLines(nil, "", gateway, at))
+		expected := `signal_5g,antenna=External\,\ 2x2\=MIMO,band=n41,cell=0,node=0 bars=0,rsrp=0i,rsrq=0i,rssi=0i,sinr=0i 1743465600000000000` + "\n"
+		if lines != expected {
+			t.Errorf("Expected only 5G without a gateway tag, got %q", lines)
+		}
+	})
+}
+
+func TestExporter(t *testing.T) {
+	influx := newInfluxStandIn(t)
+	exporter, err := NewExporter(Config{
+		URL: influx.URL + "/api/v2/write?org=home&bucket=tmo", Token: "secret", BatchSize: 3, FlushInterval: time.Minute,
+	}, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if exporter.target != influx.URL+"/api/v2/write" {
+		t.Errorf("Expected the query to be left out of logs, got %s", exporter.target)
+	}
+
+	for minute := range 2 {
+		exporter.ObservePoll("home", gatewayAt(minute), time.Second, nil)
+	}
+	exporter.ObservePoll("home", gatewayAt(2), time.Second, errors.New("connection refused"))
+	select {
+	case <-exporter.full:
+	default:
+		t.Error("Expected a full batch to be signaled")
+	}
+	exporter.Flush(context.Background())
+
+	batches := influx.written()
+	if len(batches) != 2 || strings.Count(batches[0], "\n") != 3 || strings.Count(batches[1], "\n") != 1 {
+		t.Fatalf("Expected the 4 lines of the successful polls in batches of 3, got %q", batches)
+	}
+	if !strings.HasPrefix(batches[1], "signal_5g") || !strings.Contains(batches[1], "rsrp=-91i") {
+		t.Errorf("Expected the last 5G signal in the last batch, got %q", batches[1])
+	}
+	if influx.headers.Get("Authorization") != "Token secret" || influx.headers.Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Errorf("Unexpected headers: %v", influx.headers)
+	}
+
+	exporter.Flush(context.Background())
+	if len(influx.written()) != 2 {
+		t.Errorf("Expected nothing to be written without new polls, got %q", influx.written())
+	}
+}
+
+func TestSpool(t *testing.T) {
+	influx := newInfluxStandIn(t)
+	dir := filepath.Join(t.TempDir(), "spool")
+	exporter, err := NewExporter(Config{
+		URL: influx.URL + "/api/v2/write", BatchSize: 10, FlushInterval: time.Minute, SpoolDir: dir,
+	}, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	spooled := func() int {
+		files, _, err := exporter.spool.files()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		return len(files)
+	}
+
+	influx.setStatus(http.StatusServiceUnavailable)
+	exporter.ObservePoll("home", gatewayAt(0), time.Second, nil)
+	exporter.Flush(context.Background())
+	if spooled() != 1 || exporter.failures != 1 || time.Until(exporter.retryAt) < 5*time.Second {
+		t.Fatalf("Expected the batch to be spooled and retried later, got %d spooled, retry at %s", spooled(), exporter.retryAt)
+	}
+
+	// Nothing is sent until the backoff has passed, even once the sink is back
+	influx.setStatus(http.StatusNoContent)
+	exporter.ObservePoll("home", gatewayAt(1), time.Second, nil)
+	exporter.Flush(context.Background())
+	if spooled() != 2 || len(influx.written()) != 0 {
+		t.Fatalf("Expected 2 spooled batches and nothing written, got %d and %q", spooled(), influx.written())
+	}
+
+	exporter.retryAt = time.Time{}
+	exporter.ObservePoll("home", gatewayAt(2), time.Second, nil)
+	exporter.Flush(context.Background())
+	batches := influx.written()
+	if spooled() != 0 || exporter.failures != 0 || len(batches) != 3 {
+		t.Fatalf("Expected the spooled batches to be written, got %d spooled and %q", spooled(), batches)
+	}
+	for minute, batch := range batches {
+		if !strings.Contains(batch, fmt.Sprintf("rsrp=%di", -90-minute)) {
+			t.Errorf("Expected batch %d to be the poll of minute %d, got %q", minute, minute, batch)
+		}
+	}
+
+	t.Run("Rejected", func(t *testing.T) {
+		for _, status := range []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity} {
+			influx.setStatus(status)
+			exporter.ObservePoll("home", gatewayAt(3), time.Second, nil)
+			exporter.Flush(context.Background())
+			if spooled() != 0 || exporter.failures != 0 {
+				t.Errorf("Expected a batch rejected with %d to be dropped, got %d spooled", status, spooled())
+			}
+		}
+	})
+
+	t.Run("Unauthorized Or Missing Bucket", func(t *testing.T) {
+		for i, status := range []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound} {
+			influx.setStatus(status)
+			exporter.retryAt = time.Time{}
+			exporter.ObservePoll("home", gatewayAt(3), time.Second, nil)
+			exporter.Flush(context.Background())
+			if spooled() != i+1 {
+				t.Errorf("Expected a batch refused with %d to be spooled, got %d spooled", status, spooled())
+			}
+		}
+
+		influx.setStatus(http.StatusNoContent)
+		exporter.retryAt = time.Time{}
+		exporter.Flush(context.Background())
+		if spooled() != 0 || exporter.failures != 0 {
+			t.Errorf("Expected the spooled batches to be written once the endpoint is fixed, got %d spooled", spooled())
+		}
+	})
+
+	t.Run("Size Limit", func(t *testing.T) {
+		influx.setStatus(http.StatusInternalServerError)
+		exporter.spool.maxBytes = int64(len(AppendLines(nil, "home", gatewayAt(0), time.Now()))) * 2
+		for minute := range 4 {
+			exporter.retryAt = time.Time{}
+			exporter.ObservePoll("home", gatewayAt(minute), time.Second, nil)
+			exporter.Flush(context.Background())
+		}
+		files, size, _ := exporter.spool.files()
+		if len(files) != 2 || size > exporter.spool.maxBytes {
+			t.Fatalf("Expected the 2 newest batches to be kept, got %d of %d bytes", len(files), size)
+		}
+		newest, _ := os.ReadFile(files[1])
+		if !strings.Contains(string(newest), "rsrp=-93i") {
+			t.Errorf("Expected the newest batch to be kept, got %q", newest)
+		}
+	})
+}
+
+func TestHTTPSink(t *testing.T) {
+	influx := newInfluxStandIn(t)
+	sink := NewHTTPSink(influx.URL+"/api/v2/write", "secret")
+
+	tests := []struct {
+		status   int
+		rejected bool
+	}{
+		{http.StatusBadRequest, true},
+		{http.StatusRequestEntityTooLarge, true},
+		{http.StatusUnprocessableEntity, true},
+		{http.StatusUnauthorized, false},
+		{http.StatusForbidden, false},
+		{http.StatusNotFound, false},
+		{http.StatusTooManyRequests, false},
+		{http.StatusServiceUnavailable, false},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			influx.setStatus(tt.status)
+			err := sink.Write(context.Background(), []byte("tmo_5g rsrp=-90i\n"))
+			if err == nil || errors.Is(err, ErrRejected) != tt.rejected {
+				t.Errorf("Expected rejected %t, got %v", tt.rejected, err)
+			}
+		})
+	}
+}
+
+func TestNoSpool(t *testing.T) {
+	influx := newInfluxStandIn(t)
+	influx.setStatus(http.StatusServiceUnavailable)
+	exporter, err := NewExporter(Config{URL: influx.URL + "/api/v2/write", BatchSize: 10, FlushInterval: time.Minute}, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	exporter.ObservePoll("home", gatewayAt(0), time.Second, nil)
+	exporter.Flush(context.Background())
+	influx.setStatus(http.StatusNoContent)
+	exporter.retryAt = time.Time{}
+	exporter.Flush(context.Background())
+	if len(influx.written()) != 0 || exporter.failures != 0 {
+		t.Errorf("Expected the failed batch to be dropped, got %q", influx.written())
+	}
+}
+
+func TestRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tmo.lp")
+	exporter, err := NewExporter(Config{File: path, BatchSize: 2, FlushInterval: time.Hour}, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		exporter.Run(ctx)
+		close(done)
+	}()
+
+	// A full batch is written without waiting for the flush interval
+	exporter.ObservePoll("home", gatewayAt(0), time.Second, nil)
+	for range 100 {
+		if data, _ := os.ReadFile(path); strings.Count(string(data), "\n") == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The lines left are written when stopped
+	exporter.ObservePoll("home", gatewayAt(1), time.Second, nil)
+	exporter.ObservePoll("lab", api.GatewayResponse{Signal: api.Signal{FiveG: api.SignalStats{Bands: []string{"n71"}}}}, time.Second, nil)
+	cancel()
+	<-done
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 5 || !strings.HasPrefix(lines[4], "signal_5g,band=n71,cell=0,gateway=lab") {
+		t.Errorf("Expected every line to be appended, got %q", lines)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := Config{URL: "http://localhost:8086/api/v2/write", BatchSize: 1, FlushInterval: time.Second}
+	if err := valid.Validate(); err != nil || !valid.Enabled() {
+		t.Errorf("Expected a valid enabled config, got %v", err)
+	}
+	if (Config{}).Enabled() {
+		t.Error("Expected an empty config to be disabled")
+	}
+
+	invalid := Config{URL: "localhost:8086", File: "tmo.lp", MaxSpoolBytes: -1}
+	err := invalid.Validate()
+	for _, message := range []string{"mutually exclusive", "http or https", "batch size", "flush interval", "max spool bytes"} {
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("Expected %q to be reported, got %v", message, err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package influx
+
+import (
+	"local/tmo/api"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Measurements the signal of each generation is written to
+const (
+	MeasurementFourG = "signal_4g"
+	MeasurementFiveG = "signal_5g"
+)
+
+// tagEscaper escapes the characters line protocol gives a meaning to in tag keys and values
+var tagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// AppendLines appends a line for the signal of each generation in the gateway response, taken at the time, to dst.
+// Generations without a band are left out. The gateway tag holds the label and is left out if it is empty.
+func AppendLines(dst []byte, label string, gateway api.GatewayResponse, at time.Time) []byte {
+	generations := []struct {
+		measurement string
+		stats       api.SignalStats
+		node        int
+	}{
+		{MeasurementFourG, gateway.Signal.FourG, gateway.Signal.FourG.ENBID},
+		{MeasurementFiveG, gateway.Signal.FiveG, gateway.Signal.FiveG.GNBID},
+	}
+
+	for _, generation := range generations {
+		stats := generation.stats
+		if len(stats.Bands) == 0 {
+			continue
+		}
+
+		// Tags sorted by key, as InfluxDB recommends
+		dst = append(dst, generation.measurement...)
+		dst = appendTag(dst, "antenna", stats.AntennaUsed)
+		dst = appendTag(dst, "band", stats.Bands[0])
+		dst = appendTag(dst, "cell", strconv.Itoa(stats.Cid))
+		dst = appendTag(dst, "gateway", label)
+		dst = appendTag(dst, "node", strconv.Itoa(generation.node))
+		dst = appendTag(dst, "serial", gateway.Device.Serial)
+
+		dst = append(dst, " bars="...)
+		dst = strconv.AppendFloat(dst, stats.Bars, 'f', -1, 64)
+		dst = appendIntField(dst, "rsrp", stats.Rsrp)
+		dst = appendIntField(dst, "rsrq", stats.Rsrq)
+		dst = appendIntField(dst, "rssi", stats.Rssi)
+		dst = appendIntField(dst, "sinr", stats.Sinr)
+
+		dst = append(dst, ' ')
+		dst = strconv.AppendInt(dst, at.UnixNano(), 10)
+		dst = append(dst, '\n')
+	}
+	return dst
+}
+
+// appendTag appends a tag, tags with an empty value are not allowed and left out
+func appendTag(dst []byte, key, value string) []byte {
+	if value == "" {
+		return dst
+	}
+	dst = append(dst, ',')
+	dst = append(dst, key...)
+	dst = append(dst, '=')
+	return append(dst, tagEscaper.Replace(value)...)
+}
+
+// appendIntField appends an integer field following the first one
+func appendIntField(dst []byte, key string, value int) []byte {
+	dst = append(dst, ',')
+	dst = append(dst, key...)
+	dst = append(dst, '=')
+	dst = strconv.AppendInt(dst, int64(value), 10)
+	return append(dst, 'i')
+}
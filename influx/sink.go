@@ -0,0 +1,100 @@
+package influx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// requestTimeout limits how long writing a batch to the endpoint may take
+const requestTimeout = 10 * time.Second
+
+// ErrRejected is returned for a batch the sink refused, writing it again would fail the same way
+var ErrRejected = errors.New("batch rejected")
+
+// Sink writes batches of lines
+type Sink interface {
+	Write(ctx context.Context, lines []byte) error
+}
+
+// HTTPSink posts batches to a write endpoint, such as the InfluxDB /api/v2/write or /write endpoints, or any service
+// accepting line protocol
+type HTTPSink struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewHTTPSink creates a sink posting to the URL, authorizing with the token if it is not empty
+func NewHTTPSink(url, token string) *HTTPSink {
+	return &HTTPSink{url: url, token: token, client: &http.Client{Timeout: requestTimeout}}
+}
+
+// Write posts the lines. A 400, 413 or 422 status means the lines themselves were refused and is an ErrRejected,
+// other statuses, such as an expired token or a missing bucket, may succeed once the endpoint is fixed.
+func (s *HTTPSink) Write(ctx context.Context, lines []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(lines))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Token "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error writing batch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	err = fmt.Errorf("write endpoint returned status %d", resp.StatusCode)
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if message := strings.TrimSpace(string(body)); message != "" {
+		err = fmt.Errorf("%w: %s", err, message)
+	}
+	switch resp.StatusCode {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+		return fmt.Errorf("%w: %w", ErrRejected, err)
+	}
+	return err
+}
+
+// FileSink appends batches to a file, for tools that tail it or import it later
+type FileSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileSink creates a sink appending to the file at the path, creating it if needed
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+// Write appends the lines to the file
+func (s *FileSink) Write(ctx context.Context, lines []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %w", s.path, err)
+	}
+	_, err = f.Write(lines)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("error writing %s: %w", s.path, err)
+	}
+	return f.Close()
+}
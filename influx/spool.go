@@ -0,0 +1,96 @@
+package influx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// spoolExt is the extension of spooled batches, partly written ones have a .tmp extension until they are complete
+const spoolExt = ".lp"
+
+// spool keeps batches that could not be written in a directory, one file each, named so they sort oldest first
+type spool struct {
+	dir      string
+	maxBytes int64
+	sequence atomic.Uint64
+}
+
+// newSpool creates the directory if needed
+func newSpool(dir string, maxBytes int64) (*spool, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("error creating spool directory: %w", err)
+	}
+	return &spool{dir: dir, maxBytes: maxBytes}, nil
+}
+
+// files lists the spooled batches oldest first with their total size
+func (s *spool) files() ([]string, int64, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error listing spool directory: %w", err)
+	}
+
+	var files []string
+	var size int64
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), spoolExt) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, filepath.Join(s.dir, entry.Name()))
+		size += info.Size()
+	}
+	slices.Sort(files)
+	return files, size, nil
+}
+
+// add spools a batch, removing the oldest batches to keep the spool under its size limit. It returns how many
+// batches were removed.
+func (s *spool) add(lines []byte) (int, error) {
+	name := fmt.Sprintf("%020d-%06d", time.Now().UnixNano(), s.sequence.Add(1)%1000000)
+	path := filepath.Join(s.dir, name+spoolExt)
+
+	// Renamed once written, so a crash never leaves half a batch to be sent
+	err := os.WriteFile(path+".tmp", lines, 0o644)
+	if err != nil {
+		os.Remove(path + ".tmp")
+		return 0, fmt.Errorf("error spooling batch: %w", err)
+	}
+	err = os.Rename(path+".tmp", path)
+	if err != nil {
+		return 0, fmt.Errorf("error spooling batch: %w", err)
+	}
+
+	if s.maxBytes <= 0 {
+		return 0, nil
+	}
+	files, size, err := s.files()
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	// The batch just spooled is always kept
+	for _, file := range files[:len(files)-1] {
+		if size <= s.maxBytes {
+			break
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if os.Remove(file) == nil {
+			size -= info.Size()
+			removed++
+		}
+	}
+	return removed, nil
+}
@@ -3,149 +3,409 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
 	"local/tmo/api"
 	"local/tmo/db"
+	"local/tmo/events"
+	"local/tmo/influx"
+	"local/tmo/metrics"
+	"local/tmo/mqtt"
+	"local/tmo/policy"
+	"local/tmo/storage"
 	"log"
 	"os"
+	"os/signal"
+	"slices"
 	"strings"
+	"syscall"
 	"time"
-
-	_ "github.com/mattn/go-sqlite3"
 )
 
 // Config holds application configuration
 type Config struct {
-	DBDSN        string
-	GatewayURL   string
-	PollDuration time.Duration
-	Logger       *log.Logger
+	// DBBackend is the storage backend, sqlite or postgres
+	DBBackend string `yaml:"db_backend"`
+	DBDSN     string `yaml:"db_dsn"`
+	// Gateways are the gateways to poll, each one in its own goroutine. The defaults are polled if none are listed.
+	Gateways []GatewayConfig `yaml:"gateways"`
+	// GatewayDefaults fill in the settings left out of each gateway
+	GatewayDefaults GatewayConfig `yaml:"defaults"`
+	// MetricsAddr is the address to serve Prometheus metrics on, metrics are disabled if empty
+	MetricsAddr string `yaml:"metrics_addr"`
+	// DashboardAddr is the address to serve the web dashboard on, the dashboard is disabled if empty
+	DashboardAddr string `yaml:"dashboard_addr"`
+	// Influx exports every poll as InfluxDB line protocol, it is disabled unless its URL or file is set
+	Influx influx.Config `yaml:"influx"`
+	// MQTT publishes every poll to a broker with Home Assistant discovery, it is disabled unless its broker is set
+	MQTT mqtt.Config `yaml:"mqtt"`
+	// RecordDir is the directory raw gateway responses are archived to, recording is disabled if empty
+	RecordDir string `yaml:"record_dir"`
+	// ReplayPath is a recording file or directory to load instead of polling the gateway
+	ReplayPath string `yaml:"replay"`
+	// RetentionDays is how many days of raw snapshots are kept once rolled up, 0 keeps them forever
+	RetentionDays int `yaml:"retention_days"`
+	// InitTimeout limits how long connecting to the database may take at startup
+	InitTimeout time.Duration `yaml:"init_timeout"`
+	Logger      *log.Logger   `yaml:"-"`
 }
 
-// GatewayPoller handles the polling of the gateway and storing data
-type GatewayPoller struct {
-	config    Config
-	db        *sql.DB
-	apiClient api.IClient
-	queries   *db.Queries
+// GatewayConfig holds the connection settings of one gateway
+type GatewayConfig struct {
+	// Label identifies the gateway on its device and snapshot rows and in metrics
+	Label        string        `yaml:"label"`
+	URL          string        `yaml:"url"`
+	Username     string        `yaml:"username"`
+	Password     string        `yaml:"password"`
+	PollDuration time.Duration `yaml:"poll_frequency"`
+	// RequestTimeout limits how long each request to the gateway may take
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+	// ClientsPollDuration is how often the connected clients are recorded, with a gateway poll. 0 disables it,
+	// a gateway leaving it out uses the defaults.
+	ClientsPollDuration *time.Duration `yaml:"clients_poll_frequency"`
+	// CellTelemetry fetches the radio channels of the serving cells with every poll, a gateway leaving it out uses the defaults
+	CellTelemetry *bool `yaml:"cell_telemetry"`
+	// Policies are the rules acting on the gateway when its signal degrades, a gateway without any uses the defaults
+	Policies []policy.Rule `yaml:"policies"`
+	// PolicyDryRun logs and records the firings of every policy without taking their actions, a gateway leaving it out uses the defaults
+	PolicyDryRun *bool `yaml:"policy_dry_run"`
+	// Driver is the API the gateway speaks, tmi or nokia, auto probes for it
+	Driver string `yaml:"driver"`
+	// Night is the schedule used overnight, when the gateway is most likely to change cells
+	Night *NightSchedule `yaml:"night"`
 }
 
-// NewGatewayPoller creates a new GatewayPoller
-func NewGatewayPoller(config Config) *GatewayPoller {
-	if config.Logger == nil {
-		logger := log.New(os.Stdout, "", log.LstdFlags)
-		config.Logger = logger
+// clientsPollDuration returns how often the connected clients are recorded, 0 if never
+func (c GatewayConfig) clientsPollDuration() time.Duration {
+	if c.ClientsPollDuration == nil {
+		return 0
 	}
+	return *c.ClientsPollDuration
+}
 
-	return &GatewayPoller{
-		config: config,
-	}
+// cellTelemetry reports whether the radio channels of the serving cells are fetched with every poll
+func (c GatewayConfig) cellTelemetry() bool {
+	return c.CellTelemetry != nil && *c.CellTelemetry
 }
 
-// Initialize sets up the database connection and API client
-func (p *GatewayPoller) Initialize(ctx context.Context) error {
-	// Set up database
-	var err error
-	p.db, err = newDB(ctx, p.config.DBDSN)
-	if err != nil {
-		return fmt.Errorf("database initialization failed: %w", err)
-	}
+// policyDryRun reports whether policy firings are only logged and recorded
+func (c GatewayConfig) policyDryRun() bool {
+	return c.PolicyDryRun != nil && *c.PolicyDryRun
+}
 
-	// Set up API client
-	p.apiClient = api.NewClient(p.config.GatewayURL, p.config.Logger)
-	err = p.apiClient.Login(ctx)
-	if err != nil {
-		return fmt.Errorf("API login failed: %w", err)
+// NightSchedule polls more often between the Start and End hours of the day, it is disabled if they are equal
+type NightSchedule struct {
+	Start        int           `yaml:"start"`
+	End          int           `yaml:"end"`
+	PollDuration time.Duration `yaml:"poll_frequency"`
+}
+
+// contains reports whether the hour of the day is within the schedule, which may wrap around midnight
+func (n NightSchedule) contains(hour int) bool {
+	if n.Start <= n.End {
+		return hour >= n.Start && hour < n.End
 	}
+	return hour >= n.Start || hour < n.End
+}
 
-	// Set up queries
-	p.queries = db.New(p.db)
+const (
+	// recordMaxBytes is the size recording files are rotated at
+	recordMaxBytes = 64 << 20
+	// rollupInterval is how often the rollup tables are brought up to date
+	rollupInterval = time.Hour
+)
 
-	return nil
+// GatewayPoller handles the polling of one gateway and storing its data
+type GatewayPoller struct {
+	config    GatewayConfig
+	logger    *log.Logger
+	store     storage.Store
+	apiClient api.IClient
+	metrics   *metrics.Exporter
+	retry     retryPolicy
+	// state is the connectivity of the gateway events are detected from, restored from the store by the first poll
+	state    events.State
+	restored bool
+	// clientsPolled is when the clients were last recorded, clientsUnsupported is set once the gateway cannot list them
+	clientsPolled      time.Time
+	clientsUnsupported bool
+	// telemetryUnsupported is set once the gateway has no cell telemetry
+	telemetryUnsupported bool
+	// policies evaluates the policy rules of the gateway after each poll, nil if it has none
+	policies *policy.Engine
+	// observers are told the outcome of every poll, besides the metrics
+	observers []PollObserver
 }
 
-// Run starts the polling loop
-func (p *GatewayPoller) Run(ctx context.Context) error {
-	// Initial poll
-	err := p.Poll(ctx)
-	if err != nil {
-		return err
+// PollObserver is told the outcome of every poll of a gateway, with the response even if storing it failed
+type PollObserver interface {
+	ObservePoll(label string, gateway api.GatewayResponse, duration time.Duration, err error)
+}
+
+// NewGatewayPoller creates a new GatewayPoller saving to a store that may be shared with other gateways,
+// metrics are disabled if the exporter is nil
+func NewGatewayPoller(config GatewayConfig, apiClient api.IClient, store storage.Store, exporter *metrics.Exporter, logger *log.Logger) *GatewayPoller {
+	if logger == nil {
+		logger = log.New(os.Stdout, "", log.LstdFlags)
 	}
 
-	timer := time.NewTimer(p.chooseDuration())
+	poller := &GatewayPoller{
+		config:    config,
+		logger:    logger,
+		store:     store,
+		apiClient: apiClient,
+		metrics:   exporter,
+		retry:     defaultRetryPolicy(),
+	}
+	if len(config.Policies) > 0 {
+		poller.policies = policy.NewEngine(config.Policies, config.policyDryRun())
+	}
+	return poller
+}
+
+// Run polls the gateway until the context is done, backing off after failed polls. It only returns early
+// for storage errors that retrying cannot fix.
+func (p *GatewayPoller) Run(ctx context.Context) error {
+	failures := 0
+	timer := time.NewTimer(0)
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-timer.C:
-			err := p.Poll(ctx)
-			if err != nil {
-				if strings.Contains(err.Error(), "network is unreachable") {
-					p.config.Logger.Println("Network unreachable, is the Gateway down?")
-				} else {
-					return err
-				}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		err := p.Poll(ctx)
+		if err == nil {
+			if failures > 0 {
+				p.logger.Printf("Poll succeeded after %d failures", failures)
 			}
+			failures = 0
 			timer.Reset(p.chooseDuration())
-		case <-ctx.Done():
+			continue
+		}
+
+		if ctx.Err() != nil {
 			return ctx.Err()
 		}
+
+		failures++
+		delay, ok := p.retry.delay(err, failures)
+		if !ok {
+			return err
+		}
+		p.logger.Printf("Poll failed, retrying in %s: %v", delay.Round(time.Second), err)
+		timer.Reset(delay)
+	}
+}
+
+// Replay stores every recording from the replay client, returning once all of them have been loaded. Recordings that
+// cannot be decoded are logged and skipped.
+func (p *GatewayPoller) Replay(ctx context.Context) error {
+	// Policies act on the gateway as it is now, not on its recordings
+	p.policies = nil
+
+	count, skipped := 0, 0
+	for {
+		err := p.Poll(ctx)
+		if errors.Is(err, api.ErrReplayDone) {
+			p.logger.Printf("Replayed %d recordings, skipped %d", count, skipped)
+			return nil
+		}
+		// A corrupt recording only loses itself
+		if errors.Is(err, api.ErrDecode) {
+			p.logger.Printf("Skipping recording: %v", err)
+			skipped++
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		count++
 	}
 }
 
 // Poll fetches data from the gateway and stores it in the database
 func (p *GatewayPoller) Poll(ctx context.Context) error {
+	start := time.Now()
+	gateway, err := p.poll(ctx)
+	duration := time.Since(start)
+	if p.metrics != nil {
+		p.metrics.ObservePoll(p.config.Label, gateway, duration, err)
+	}
+	for _, observer := range p.observers {
+		observer.ObservePoll(p.config.Label, gateway, duration, err)
+	}
+	return err
+}
+
+// poll fetches data from the gateway, stores it and the events it caused in the database and returns the gateway response
+func (p *GatewayPoller) poll(ctx context.Context) (api.GatewayResponse, error) {
+	if !p.restored {
+		err := p.restore(ctx)
+		if err != nil {
+			return api.GatewayResponse{}, fmt.Errorf("%w: %w", errStorage, err)
+		}
+	}
+
 	gateway, err := p.apiClient.GetGateway(ctx)
 	if err != nil {
-		return fmt.Errorf("error getting gateway from API: %w", err)
+		err = fmt.Errorf("error getting gateway from API: %w", err)
+		if errors.Is(err, api.ErrNetwork) {
+			state, unreachable := p.state.ObserveUnreachable(p.config.Label, time.Now(), err)
+			saveErr := p.saveEvents(ctx, unreachable)
+			if saveErr != nil {
+				return gateway, errors.Join(err, fmt.Errorf("%w: %w", errStorage, saveErr))
+			}
+			p.state = state
+			p.observePolicies(ctx, policy.Sample{At: time.Now()})
+		}
+		return gateway, err
+	}
+	sample := policy.Sample{At: time.Now(), Reachable: true, Gateway: gateway}
+
+	state, changes := p.state.Observe(p.config.Label, snapshotTime(gateway.Time), gateway)
+
+	telemetry := p.pollTelemetry(ctx)
+
+	err = p.save(ctx, gateway, telemetry, changes)
+	if err != nil {
+		return gateway, fmt.Errorf("%w: %w", errStorage, err)
+	}
+	p.state = state
+
+	p.pollClients(ctx, snapshotTime(gateway.Time))
+	p.observePolicies(ctx, sample)
+
+	return gateway, nil
+}
+
+// restore loads the connectivity state of the gateway from its latest events and snapshot
+func (p *GatewayPoller) restore(ctx context.Context) error {
+	last, err := p.store.ListLastEvents(ctx, p.config.Label)
+	if err != nil {
+		return fmt.Errorf("error loading last events: %w", err)
+	}
+
+	latest, err := p.store.GetLatestSnapshot(ctx, p.config.Label)
+	if err == sql.ErrNoRows {
+		p.state = events.Restore(last, nil)
+	} else if err != nil {
+		return fmt.Errorf("error loading latest snapshot: %w", err)
+	} else {
+		p.state = events.Restore(last, &latest)
+	}
+
+	if p.policies != nil {
+		err = p.restorePolicies(ctx)
+		if err != nil {
+			return fmt.Errorf("error loading recent policy events: %w", err)
+		}
 	}
 
-	tx, err := p.db.BeginTx(ctx, nil)
+	p.restored = true
+	return nil
+}
+
+// saveEvents saves events to the database in a single transaction
+func (p *GatewayPoller) saveEvents(ctx context.Context, changes []db.CreateEventParams) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	tx, err := p.store.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("error starting transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	queries := p.queries.WithTx(tx)
+	err = p.loadEvents(ctx, tx, changes)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// save saves a gateway response, its cell telemetry if fetched and the events it caused to the database in a single transaction
+func (p *GatewayPoller) save(ctx context.Context, gateway api.GatewayResponse, telemetry *api.CellTelemetryResponse, changes []db.CreateEventParams) error {
+	tx, err := p.store.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-	device, err := p.loadDevice(ctx, queries, gateway.Device)
+	device, err := p.loadDevice(ctx, tx, gateway.Device)
 	if err != nil {
 		return fmt.Errorf("error loading device: %w", err)
 	}
 
-	snapshot, err := p.loadSnapshot(ctx, queries, device, gateway.Time)
+	snapshot, err := p.loadSnapshot(ctx, tx, device, gateway.Time, gateway.Signal.Generic)
 	if err != nil {
 		return fmt.Errorf("error loading snapshot: %w", err)
 	}
 
-	err = p.loadSignal(ctx, queries, snapshot, "4G", gateway.Signal.FourG)
-	if err != nil {
-		return fmt.Errorf("error loading 4G signal: %w", err)
+	var fourGChannel, fiveGChannel *api.CellTelemetry
+	if telemetry != nil {
+		fourGChannel, fiveGChannel = &telemetry.Cell.FourG, &telemetry.Cell.FiveG
+	}
+
+	// A generation without a band, as reported by a gateway that is not registered on it, is left out of the snapshot
+	for _, generation := range []struct {
+		name    string
+		stats   api.SignalStats
+		channel *api.CellTelemetry
+	}{{"4G", gateway.Signal.FourG, fourGChannel}, {"5G", gateway.Signal.FiveG, fiveGChannel}} {
+		if len(generation.stats.Bands) == 0 {
+			continue
+		}
+
+		err = p.loadSignal(ctx, tx, snapshot, generation.name, generation.stats, generation.channel)
+		if err != nil {
+			return fmt.Errorf("error loading %s signal: %w", generation.name, err)
+		}
+
+		handoff, err := p.loadCell(ctx, tx, snapshot.CreatedAt, generation.name, generation.stats)
+		if err != nil {
+			return fmt.Errorf("error loading %s cell: %w", generation.name, err)
+		}
+		if handoff != nil {
+			changes = append(changes, *handoff)
+		}
 	}
 
-	err = p.loadSignal(ctx, queries, snapshot, "5G", gateway.Signal.FiveG)
+	err = p.loadEvents(ctx, tx, changes)
 	if err != nil {
-		return fmt.Errorf("error loading 5G signal: %w", err)
+		return err
 	}
 
 	return tx.Commit()
 }
 
-// loadDevice returns the existing device or creates a new one
-func (p *GatewayPoller) loadDevice(ctx context.Context, queries *db.Queries, apiDevice api.Device) (db.Device, error) {
+// loadDevice returns the existing device or creates a new one, keeping its label up to date
+func (p *GatewayPoller) loadDevice(ctx context.Context, queries storage.Tx, apiDevice api.Device) (db.Device, error) {
 	device, err := queries.GetDevice(ctx, db.GetDeviceParams{
 		Serial:          apiDevice.Serial,
 		SoftwareVersion: apiDevice.SoftwareVersion,
 	})
 
 	if err == nil {
-		return device, nil
+		if device.Label != p.config.Label {
+			p.logger.Printf("Relabeling device from %q to %q", device.Label, p.config.Label)
+			err = queries.UpdateDeviceLabel(ctx, db.UpdateDeviceLabelParams{Label: p.config.Label, ID: device.ID})
+			device.Label = p.config.Label
+		}
+		return device, err
 	}
 
 	if err != sql.ErrNoRows {
 		return db.Device{}, err
 	}
 
-	p.config.Logger.Println("Creating new device")
+	p.logger.Println("Creating new device")
 	return queries.CreateDevice(ctx, db.CreateDeviceParams{
 		FriendlyName:    apiDevice.FriendlyName,
 		HardwareVersion: apiDevice.HardwareVersion,
@@ -161,29 +421,37 @@ func (p *GatewayPoller) loadDevice(ctx context.Context, queries *db.Queries, api
 		SoftwareVersion: apiDevice.SoftwareVersion,
 		Type:            apiDevice.Type,
 		UpdateState:     apiDevice.UpdateState,
+		Label:           p.config.Label,
 	})
 }
 
-// loadSnapshot inserts a new snapshot record into the database
-func (p *GatewayPoller) loadSnapshot(ctx context.Context, queries *db.Queries, device db.Device, apiTime api.Time) (db.Snapshot, error) {
+// loadSnapshot inserts a new snapshot record, with the registration state of the gateway at the time, into the database
+func (p *GatewayPoller) loadSnapshot(ctx context.Context, queries storage.Tx, device db.Device, apiTime api.Time, generic api.Generic) (db.Snapshot, error) {
 	return queries.CreateSnapshot(ctx, db.CreateSnapshotParams{
-		Deviceid:  device.ID,
-		CreatedAt: time.Unix(int64(apiTime.LocalTime), 0),
-		Uptime:    int64(apiTime.UpTime),
+		Deviceid:     device.ID,
+		CreatedAt:    snapshotTime(apiTime),
+		Uptime:       int64(apiTime.UpTime),
+		Label:        p.config.Label,
+		Apn:          generic.Apn,
+		HasIpv6:      generic.HasIPv6,
+		Registration: generic.Registration,
+		Roaming:      generic.Roaming,
 	})
 }
 
-// loadSignal inserts a new signal record into the database
-func (p *GatewayPoller) loadSignal(ctx context.Context, queries *db.Queries, snapshot db.Snapshot, statName string, stats api.SignalStats) error {
+// loadSignal inserts a new signal record and its component carrier bands into the database.
+// The first band reported by the gateway is the primary carrier, any others are secondary
+// carriers from carrier aggregation. The radio channel is stored next to the signal if given.
+func (p *GatewayPoller) loadSignal(ctx context.Context, queries storage.Tx, snapshot db.Snapshot, statName string, stats api.SignalStats, channel *api.CellTelemetry) error {
 	if statName != "4G" && statName != "5G" {
 		return fmt.Errorf("invalid statName: %s", statName)
 	}
 
-	if len(stats.Bands) != 1 {
-		return fmt.Errorf("expected 1 band, got %+v", stats.Bands)
+	if len(stats.Bands) == 0 {
+		return fmt.Errorf("expected at least 1 band, got %+v", stats.Bands)
 	}
 
-	_, err := queries.CreateSignal(ctx, db.CreateSignalParams{
+	signal, err := queries.CreateSignal(ctx, db.CreateSignalParams{
 		Snapshotid:  snapshot.ID,
 		Generation:  statName,
 		AntennaUsed: stats.AntennaUsed,
@@ -197,81 +465,187 @@ func (p *GatewayPoller) loadSignal(ctx context.Context, queries *db.Queries, sna
 		Rssi:        int64(stats.Rssi),
 		Sinr:        int64(stats.Sinr),
 	})
+	if err != nil {
+		return err
+	}
 
-	return err
-}
-
-// chooseDuration determines polling frequency based on time of day
-func (p *GatewayPoller) chooseDuration() time.Duration {
-	midnightDuration := 1 * time.Minute
-	if p.config.PollDuration < midnightDuration {
-		return p.config.PollDuration
+	for i, band := range stats.Bands {
+		_, err = queries.CreateSignalBand(ctx, db.CreateSignalBandParams{
+			Signalid:  signal.ID,
+			Band:      band,
+			IsPrimary: i == 0,
+		})
+		if err != nil {
+			return fmt.Errorf("error loading band %s: %w", band, err)
+		}
 	}
 
-	now := time.Now()
-	if now.Hour() >= 23 || now.Hour() < 3 {
-		return midnightDuration
+	if channel != nil {
+		err = loadChannel(ctx, queries, signal, *channel)
+		if err != nil {
+			return fmt.Errorf("error loading channel: %w", err)
+		}
 	}
 
-	return p.config.PollDuration
+	return nil
 }
 
-// newDB initializes and tests a database connection
-func newDB(ctx context.Context, dsn string) (*sql.DB, error) {
-	sqlDb, err := sql.Open("sqlite3", dsn)
+// loadCell adds a sample of the serving cell to the cell inventory, creating the cell the first time it is seen.
+// It returns a handoff event if the gateway was last seen on another cell of the generation.
+func (p *GatewayPoller) loadCell(ctx context.Context, queries storage.Tx, at time.Time, generation string, stats api.SignalStats) (*db.CreateEventParams, error) {
+	serving, err := queries.GetServingCell(ctx, db.GetServingCellParams{Label: p.config.Label, Generation: generation})
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	hasServing := err == nil
+
+	cell, err := queries.GetCell(ctx, db.GetCellParams{
+		Label:      p.config.Label,
+		Generation: generation,
+		Enbid:      int64(stats.ENBID),
+		Gnbid:      int64(stats.GNBID),
+		Cid:        int64(stats.Cid),
+	})
+	if err == sql.ErrNoRows {
+		cell, err = queries.CreateCell(ctx, db.CreateCellParams{
+			Label:       p.config.Label,
+			Generation:  generation,
+			Enbid:       int64(stats.ENBID),
+			Gnbid:       int64(stats.GNBID),
+			Cid:         int64(stats.Cid),
+			Bands:       stats.Bands[0],
+			FirstSeen:   at,
+			LastSeen:    at,
+			SampleCount: 1,
+			RsrpAvg:     float64(stats.Rsrp),
+			RsrqAvg:     float64(stats.Rsrq),
+			RssiAvg:     float64(stats.Rssi),
+			SinrAvg:     float64(stats.Sinr),
+		})
+	} else if err == nil {
+		bands := strings.Split(cell.Bands, ",")
+		if !slices.Contains(bands, stats.Bands[0]) {
+			bands = append(bands, stats.Bands[0])
+		}
+
+		n := float64(cell.SampleCount + 1)
+		cell, err = queries.UpdateCell(ctx, db.UpdateCellParams{
+			ID:          cell.ID,
+			Bands:       strings.Join(bands, ","),
+			LastSeen:    latest(cell.LastSeen, at),
+			SampleCount: cell.SampleCount + 1,
+			RsrpAvg:     cell.RsrpAvg + (float64(stats.Rsrp)-cell.RsrpAvg)/n,
+			RsrqAvg:     cell.RsrqAvg + (float64(stats.Rsrq)-cell.RsrqAvg)/n,
+			RssiAvg:     cell.RssiAvg + (float64(stats.Rssi)-cell.RssiAvg)/n,
+			SinrAvg:     cell.SinrAvg + (float64(stats.Sinr)-cell.SinrAvg)/n,
+		})
+	}
 	if err != nil {
-		return nil, fmt.Errorf("error opening database: %w", err)
+		return nil, err
+	}
+
+	// Snapshots older than the serving cell, such as replayed recordings, cannot be handoffs
+	if !hasServing || serving.ID == cell.ID || at.Before(serving.LastSeen) {
+		return nil, nil
 	}
 
-	sqlDb.SetMaxOpenConns(1)
-	sqlDb.SetMaxIdleConns(1)
+	return &db.CreateEventParams{
+		Label:     p.config.Label,
+		Type:      events.Handoff,
+		CreatedAt: at,
+		OldValue:  events.CellName(generation, serving.Enbid, serving.Gnbid, serving.Cid),
+		NewValue:  events.CellName(generation, cell.Enbid, cell.Gnbid, cell.Cid),
+		Detail:    generation,
+	}, nil
+}
+
+// latest returns the later of two times
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
 
-	if err = sqlDb.PingContext(ctx); err != nil {
-		return nil, fmt.Errorf("error connecting to database: %w", err)
+// loadEvents inserts event records into the database
+func (p *GatewayPoller) loadEvents(ctx context.Context, queries storage.Tx, changes []db.CreateEventParams) error {
+	for _, change := range changes {
+		switch {
+		case change.OldValue != "":
+			p.logger.Printf("Event %s: %s to %s", change.Type, change.OldValue, change.NewValue)
+		case change.Detail != "":
+			p.logger.Printf("Event %s: %s", change.Type, change.Detail)
+		default:
+			p.logger.Printf("Event %s", change.Type)
+		}
+		_, err := queries.CreateEvent(ctx, change)
+		if err != nil {
+			return fmt.Errorf("error loading %s event: %w", change.Type, err)
+		}
 	}
+	return nil
+}
 
-	return sqlDb, nil
+// snapshotTime is the time a gateway response was taken by the gateway clock
+func snapshotTime(apiTime api.Time) time.Time {
+	return time.Unix(int64(apiTime.LocalTime), 0)
 }
 
-// defaultPollDuration retrieves the polling duration from environment or uses default
-func defaultPollDuration() time.Duration {
-	s := os.Getenv("GATEWAY_POLL_FREQ")
-	if s == "" {
-		s = "5m"
+// chooseDuration determines polling frequency based on time of day
+func (p *GatewayPoller) chooseDuration() time.Duration {
+	night := p.config.Night
+	if night == nil || p.config.PollDuration < night.PollDuration {
+		return p.config.PollDuration
 	}
 
-	duration, err := time.ParseDuration(s)
-	if err != nil {
-		log.Fatal("Invalid GATEWAY_POLL_FREQ")
+	if night.contains(time.Now().Hour()) {
+		return night.PollDuration
 	}
 
-	return duration
+	return p.config.PollDuration
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "gateway" {
+		err := runGateway(os.Args[2:], os.Getenv, os.Stdin, os.Stdout)
+		if err != nil && !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	logger := log.New(os.Stdout, "", log.LstdFlags)
 
-	config := Config{
-		DBDSN:        "file:tmo.db?cache=shared&mode=rwc&_journal_mode=WAL&_synchronous=NORMAL",
-		GatewayURL:   "http://192.168.12.1/TMI/v1",
-		PollDuration: defaultPollDuration(),
-		Logger:       logger,
+	config, err := loadConfig(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
 	}
+	if err != nil {
+		logger.Fatalf("Invalid configuration:\n%v", err)
+	}
+	config.Logger = logger
 
-	ctx := context.Background()
+	// Stop polling on an interrupt, so what is still buffered, such as the export, is written before exiting
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	poller := NewGatewayPoller(config)
+	supervisor := NewSupervisor(config)
 
-	timeoutCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	timeoutCtx, cancel := context.WithTimeout(ctx, config.InitTimeout)
 	defer cancel()
 
-	err := poller.Initialize(timeoutCtx)
+	err = supervisor.Initialize(timeoutCtx)
 	if err != nil {
 		logger.Fatalf("Failed to initialize poller: %v", err)
 	}
 
-	err = poller.Run(ctx)
-	if err != nil {
+	if config.ReplayPath != "" {
+		err = supervisor.Replay(ctx)
+	} else {
+		err = supervisor.Run(ctx)
+	}
+	if err != nil && ctx.Err() == nil {
 		logger.Fatalf("Poller exited with error: %v", err)
 	}
 }
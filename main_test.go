@@ -1,41 +1,127 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"io"
 	"local/tmo/api"
+	"local/tmo/backoff"
 	"local/tmo/db"
+	"local/tmo/events"
+	"local/tmo/influx"
+	"local/tmo/migrations"
+	"local/tmo/mqtt"
+	"local/tmo/policy"
+	"local/tmo/storage"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
 // MockAPIClient implements a mock version of the API client for testing
 type MockAPIClient struct {
 	gateway api.GatewayResponse
 	delay   time.Duration
+	// err is returned by every GetGateway call, if set
+	err error
+	// clients is returned by GetClients, clientsErr instead if set
+	clients     api.ClientsResponse
+	clientsErr  error
+	clientCalls int
+	// telemetry is returned by GetCellTelemetry, telemetryErr instead if set
+	telemetry      api.CellTelemetryResponse
+	telemetryErr   error
+	telemetryCalls int
+	// reboots counts the Reboot calls, which fail with rebootErr if set
+	reboots   int
+	rebootErr error
 }
 
 func (m *MockAPIClient) GetGateway(ctx context.Context) (api.GatewayResponse, error) {
 	time.Sleep(m.delay)
+	if m.err != nil {
+		return api.GatewayResponse{}, m.err
+	}
 	return m.gateway, nil
 }
 
+func (m *MockAPIClient) GetClients(ctx context.Context) (api.ClientsResponse, error) {
+	m.clientCalls++
+	if m.clientsErr != nil {
+		return api.ClientsResponse{}, m.clientsErr
+	}
+	return m.clients, nil
+}
+
+func (m *MockAPIClient) GetCellTelemetry(ctx context.Context) (api.CellTelemetryResponse, error) {
+	m.telemetryCalls++
+	if m.telemetryErr != nil {
+		return api.CellTelemetryResponse{}, m.telemetryErr
+	}
+	return m.telemetry, nil
+}
+
+func (m *MockAPIClient) Reboot(ctx context.Context) error {
+	m.reboots++
+	return m.rebootErr
+}
+
 func (m *MockAPIClient) Login(ctx context.Context) error {
 	return nil
 }
 
-// setupTestDatabase creates a temporary SQLite database for benchmarking
-func setupTestDatabase(b *testing.B) (*sql.DB, string) {
+// FaultyAPIClient fails GetGateway with each of its faults in turn before returning the gateway of the mock
+type FaultyAPIClient struct {
+	*MockAPIClient
+	faults []error
+	calls  int
+}
+
+func (f *FaultyAPIClient) GetGateway(ctx context.Context) (api.GatewayResponse, error) {
+	f.calls++
+	if len(f.faults) > 0 {
+		err := f.faults[0]
+		f.faults = f.faults[1:]
+		return api.GatewayResponse{}, err
+	}
+	return f.MockAPIClient.GetGateway(ctx)
+}
+
+// FaultyStore fails Begin with each of its faults in turn, a nil fault starts a transaction on the wrapped store
+type FaultyStore struct {
+	storage.Store
+	faults []error
+}
+
+func (f *FaultyStore) Begin(ctx context.Context) (storage.Tx, error) {
+	if len(f.faults) > 0 {
+		err := f.faults[0]
+		f.faults = f.faults[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	return f.Store.Begin(ctx)
+}
+
+// setupTestDatabase creates a temporary SQLite database for tests and benchmarks
+func setupTestDatabase(tb testing.TB) (*sql.DB, string) {
 	// Create a temporary file for the SQLite database
 	tmpFile, err := os.CreateTemp("", "benchmark-*.db")
 	if err != nil {
-		b.Fatalf("Failed to create temp file: %v", err)
+		tb.Fatalf("Failed to create temp file: %v", err)
 	}
 	tmpFile.Close()
 
@@ -44,13 +130,13 @@ func setupTestDatabase(b *testing.B) (*sql.DB, string) {
 	// Connect to the SQLite database
 	sqlDB, err := sql.Open("sqlite3", dsn)
 	if err != nil {
-		b.Fatalf("Failed to open database: %v", err)
+		tb.Fatalf("Failed to open database: %v", err)
 	}
 
 	// Create the schema
 	err = createSchema(sqlDB)
 	if err != nil {
-		b.Fatalf("Failed to create schema: %v", err)
+		tb.Fatalf("Failed to create schema: %v", err)
 	}
 
 	return sqlDB, dsn
@@ -58,18 +144,18 @@ func setupTestDatabase(b *testing.B) (*sql.DB, string) {
 
 // createSchema sets up the database schema for testing
 func createSchema(db *sql.DB) error {
-	schema, err := os.ReadFile("schema.sql")
+	migrator, err := migrations.New(db)
 	if err != nil {
-		return fmt.Errorf("Failed to read schema file: %w", err)
+		return fmt.Errorf("Failed to load migrations: %w", err)
 	}
-	_, err = db.Exec(string(schema))
+	_, err = migrator.Up(context.Background())
 	return err
 }
 
-// setupBenchmark creates a GatewayPoller with a real SQLite database
-func setupBenchmark(b *testing.B) (*GatewayPoller, context.Context, func()) {
+// setupPoller creates a GatewayPoller with a real SQLite database
+func setupPoller(tb testing.TB) (*GatewayPoller, context.Context, func()) {
 	// Create a real SQLite database
-	sqlDB, dbDsn := setupTestDatabase(b)
+	sqlDB, _ := setupTestDatabase(tb)
 
 	// Create a mock API client with test data
 	mockAPIClient := &MockAPIClient{
@@ -119,22 +205,20 @@ func setupBenchmark(b *testing.B) (*GatewayPoller, context.Context, func()) {
 					Rssi:        -75,
 					Sinr:        20,
 				},
+				Generic: api.Generic{
+					Apn:          "FBB.HOME",
+					Registration: events.Registered,
+				},
 			},
 		},
 	}
 
 	// Create the GatewayPoller
-	poller := &GatewayPoller{
-		config: Config{
-			DBDSN:        dbDsn,
-			GatewayURL:   "http://localhost",
-			PollDuration: 5 * time.Minute,
-			Logger:       log.New(io.Discard, "", 0), // Silent logger for benchmarks
-		},
-		db:        sqlDB,
-		apiClient: mockAPIClient,
-		queries:   db.New(sqlDB),
-	}
+	poller := NewGatewayPoller(GatewayConfig{
+		Label:        "home",
+		URL:          "http://localhost",
+		PollDuration: 5 * time.Minute,
+	}, mockAPIClient, storage.NewSQLite(sqlDB), nil, log.New(io.Discard, "", 0)) // Silent logger for benchmarks
 
 	// Return cleanup function
 	cleanup := func() {
@@ -145,9 +229,876 @@ func setupBenchmark(b *testing.B) (*GatewayPoller, context.Context, func()) {
 	return poller, context.Background(), cleanup
 }
 
+func TestPollCarrierAggregation(t *testing.T) {
+	poller, ctx, cleanup := setupPoller(t)
+	defer cleanup()
+
+	mockClient := poller.apiClient.(*MockAPIClient)
+	mockClient.gateway.Signal.FiveG.Bands = []string{"n41", "n25", "n71"}
+
+	err := poller.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+
+	var signalID int64
+	err = poller.store.(*storage.SQLite).DB.QueryRowContext(ctx, "SELECT id FROM signal WHERE generation = '5G'").Scan(&signalID)
+	if err != nil {
+		t.Fatalf("Failed to find 5G signal: %v", err)
+	}
+
+	bands, err := poller.store.ListSignalBands(ctx, signalID)
+	if err != nil {
+		t.Fatalf("Failed to list signal bands: %v", err)
+	}
+
+	if len(bands) != 3 {
+		t.Fatalf("Expected 3 bands, got %d", len(bands))
+	}
+	for i, band := range bands {
+		expected := mockClient.gateway.Signal.FiveG.Bands[i]
+		if band.Band != expected {
+			t.Errorf("Expected band %d to be %s, got %s", i, expected, band.Band)
+		}
+		if band.IsPrimary != (i == 0) {
+			t.Errorf("Expected band %s primary=%t, got %t", band.Band, i == 0, band.IsPrimary)
+		}
+	}
+}
+
+func TestPollGeneric(t *testing.T) {
+	poller, ctx, cleanup := setupPoller(t)
+	defer cleanup()
+	poller.apiClient.(*MockAPIClient).gateway.Signal.Generic.HasIPv6 = true
+
+	err := poller.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+
+	var apn, registration string
+	var hasIPv6, roaming bool
+	err = poller.store.(*storage.SQLite).DB.QueryRowContext(ctx, "SELECT apn, has_ipv6, registration, roaming FROM snapshot").
+		Scan(&apn, &hasIPv6, &registration, &roaming)
+	if err != nil {
+		t.Fatalf("Failed to read snapshot: %v", err)
+	}
+	if apn != "FBB.HOME" || !hasIPv6 || registration != events.Registered || roaming {
+		t.Errorf("Unexpected generic values: apn=%q ipv6=%t registration=%q roaming=%t", apn, hasIPv6, registration, roaming)
+	}
+}
+
+func TestPollLabels(t *testing.T) {
+	poller, ctx, cleanup := setupPoller(t)
+	defer cleanup()
+	sqlDB := poller.store.(*storage.SQLite).DB
+
+	err := poller.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+
+	var deviceLabel, snapshotLabel string
+	err = sqlDB.QueryRowContext(ctx, "SELECT device.label, snapshot.label FROM snapshot JOIN device ON device.id = snapshot.deviceid").
+		Scan(&deviceLabel, &snapshotLabel)
+	if err != nil {
+		t.Fatalf("Failed to read labels: %v", err)
+	}
+	if deviceLabel != "home" || snapshotLabel != "home" {
+		t.Errorf("Expected device and snapshot labels home, got %q and %q", deviceLabel, snapshotLabel)
+	}
+
+	t.Run("Relabel", func(t *testing.T) {
+		poller.config.Label = "lab"
+		err := poller.Poll(ctx)
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+
+		var devices int
+		err = sqlDB.QueryRowContext(ctx, "SELECT COUNT(*), label FROM device").Scan(&devices, &deviceLabel)
+		if err != nil {
+			t.Fatalf("Failed to read device: %v", err)
+		}
+		if devices != 1 || deviceLabel != "lab" {
+			t.Errorf("Expected 1 device labeled lab, got %d labeled %q", devices, deviceLabel)
+		}
+	})
+}
+
+func TestPollEvents(t *testing.T) {
+	poller, ctx, cleanup := setupPoller(t)
+	defer cleanup()
+	mockClient := poller.apiClient.(*MockAPIClient)
+
+	err := poller.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+
+	// A gateway that loses its registration may not report any 5G bands
+	mockClient.gateway.Signal.Generic.Registration = "searching"
+	mockClient.gateway.Signal.FiveG.Bands = nil
+	err = poller.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	if types := eventTypes(t, poller); !slices.Equal(types, []string{events.Registration, events.OutageStart}) {
+		t.Errorf("Unexpected events: %v", types)
+	}
+
+	t.Run("Restored After Restart", func(t *testing.T) {
+		restarted := NewGatewayPoller(poller.config, poller.apiClient, poller.store, nil, poller.logger)
+		mockClient.gateway.Signal.Generic.Registration = events.Registered
+		mockClient.gateway.Signal.FiveG.Bands = []string{"n41"}
+		mockClient.gateway.Time.LocalTime++
+
+		err := restarted.Poll(ctx)
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+		if types := eventTypes(t, poller); !slices.Equal(types, []string{
+			events.Registration, events.OutageStart, events.Registration, events.OutageEnd,
+		}) {
+			t.Errorf("Unexpected events: %v", types)
+		}
+	})
+
+	t.Run("Reboot And Firmware Update", func(t *testing.T) {
+		restarted := NewGatewayPoller(poller.config, poller.apiClient, poller.store, nil, poller.logger)
+		mockClient.gateway.Time.LocalTime += 600
+		mockClient.gateway.Time.UpTime = 60
+		mockClient.gateway.Device.SoftwareVersion = "1.1.0"
+
+		err := restarted.Poll(ctx)
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+		if types := eventTypes(t, poller); !slices.Equal(types[4:], []string{events.Reboot, events.Firmware}) {
+			t.Errorf("Unexpected events: %v", types)
+		}
+	})
+}
+
+func TestPollCells(t *testing.T) {
+	poller, ctx, cleanup := setupPoller(t)
+	defer cleanup()
+	mockClient := poller.apiClient.(*MockAPIClient)
+
+	// Hand off to a second 5G cell and back, one minute apart
+	polls := []struct {
+		cid  int
+		rsrp int
+	}{
+		{54321, -80},
+		{54321, -90},
+		{11111, -120},
+		{54321, -100},
+	}
+	for _, p := range polls {
+		mockClient.gateway.Time.LocalTime += 60
+		mockClient.gateway.Signal.FiveG.Cid = p.cid
+		mockClient.gateway.Signal.FiveG.Rsrp = p.rsrp
+		if p.cid == 11111 {
+			mockClient.gateway.Signal.FiveG.Bands = []string{"n71"}
+		}
+
+		err := poller.Poll(ctx)
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+	}
+
+	cells, err := poller.store.ListCells(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list cells: %v", err)
+	}
+	if len(cells) != 3 {
+		t.Fatalf("Expected 3 cells, got %+v", cells)
+	}
+	if cells[0].Generation != "4G" || cells[0].SampleCount != 4 {
+		t.Errorf("Expected 4 samples of the 4G cell, got %+v", cells[0])
+	}
+	home := cells[1]
+	if home.Cid != 54321 || home.SampleCount != 3 || home.RsrpAvg != -90 || home.Bands != "n41,n71" {
+		t.Errorf("Unexpected 5G cell: %+v", home)
+	}
+	if !home.LastSeen.After(home.FirstSeen) || cells[2].Cid != 11111 || cells[2].SampleCount != 1 {
+		t.Errorf("Unexpected 5G cells: %+v", cells[1:])
+	}
+
+	rows, err := poller.store.ListEventsBetween(ctx, db.ListEventsBetweenParams{End: time.Now()})
+	if err != nil {
+		t.Fatalf("Failed to list events: %v", err)
+	}
+	handoffs := events.CountHandoffs(rows)
+	if len(handoffs) != 2 || handoffs[0].Count != 1 || handoffs[0].From != "gNB 98765 cell 11111" || handoffs[1].To != "gNB 98765 cell 11111" {
+		t.Errorf("Unexpected handoffs: %+v", handoffs)
+	}
+}
+
+func TestPollClients(t *testing.T) {
+	poller, ctx, cleanup := setupPoller(t)
+	defer cleanup()
+	poller.config.ClientsPollDuration = ptr(time.Hour)
+	mockClient := poller.apiClient.(*MockAPIClient)
+	mockClient.clients = api.ClientsResponse{Clients: map[string][]api.ConnectedClient{
+		"5.0ghz": {
+			{Connected: true, IPv4: "192.168.12.2", IPv6: []string{"fe80::1", "fe80::2"}, MAC: "AA:BB:CC:00:00:01", Name: "laptop", Signal: -50},
+			{Connected: false, MAC: "aa:bb:cc:00:00:02", Name: "phone"},
+		},
+		"ethernet": {
+			{Connected: true, IPv4: "192.168.12.3", MAC: "aa:bb:cc:00:00:03", Name: "console"},
+			{Connected: true, Name: "no mac"},
+		},
+	}}
+
+	t.Run("Recorded", func(t *testing.T) {
+		err := poller.Poll(ctx)
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+
+		devices, err := poller.store.ListClientDevices(ctx)
+		if err != nil {
+			t.Fatalf("Failed to list client devices: %v", err)
+		}
+		if len(devices) != 3 || devices[0].Mac != "aa:bb:cc:00:00:01" || devices[0].Name != "laptop" {
+			t.Fatalf("Unexpected client devices: %+v", devices)
+		}
+
+		counts, err := poller.store.CountClientsBetween(ctx, db.CountClientsBetweenParams{End: time.Now()})
+		if err != nil {
+			t.Fatalf("Failed to count clients: %v", err)
+		}
+		if len(counts) != 1 || counts[0].Connected != 2 || counts[0].Wifi != 1 || counts[0].Label != "home" {
+			t.Errorf("Expected 2 connected clients with 1 on Wi-Fi, got %+v", counts)
+		}
+
+		var ipv6 string
+		var signal int
+		err = poller.store.(*storage.SQLite).DB.QueryRowContext(ctx,
+			"SELECT ipv6, signal FROM client_observation WHERE connection = '5.0ghz' AND connected").Scan(&ipv6, &signal)
+		if err != nil {
+			t.Fatalf("Failed to find the laptop observation: %v", err)
+		}
+		if ipv6 != "fe80::1,fe80::2" || signal != -50 {
+			t.Errorf("Unexpected laptop observation: %s %d", ipv6, signal)
+		}
+	})
+
+	t.Run("Not Due", func(t *testing.T) {
+		mockClient.gateway.Time.LocalTime += 60
+		err := poller.Poll(ctx)
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+		if mockClient.clientCalls != 1 {
+			t.Errorf("Expected 1 clients request within the cadence, got %d", mockClient.clientCalls)
+		}
+	})
+
+	t.Run("Renamed", func(t *testing.T) {
+		poller.clientsPolled = time.Time{}
+		mockClient.gateway.Time.LocalTime += 60
+		mockClient.clients.Clients["5.0ghz"][0].Name = ""
+		mockClient.clients.Clients["5.0ghz"][1].Name = "pixel"
+		err := poller.Poll(ctx)
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+
+		devices, err := poller.store.ListClientDevices(ctx)
+		if err != nil {
+			t.Fatalf("Failed to list client devices: %v", err)
+		}
+		names := map[string]string{}
+		for _, device := range devices {
+			names[device.Mac] = device.Name
+			if device.Mac != "aa:bb:cc:00:00:01" {
+				continue
+			}
+			if !device.LastSeen.After(device.FirstSeen) {
+				t.Errorf("Expected the laptop to be seen again, got %+v", device)
+			}
+		}
+		if len(devices) != 3 || names["aa:bb:cc:00:00:01"] != "laptop" || names["aa:bb:cc:00:00:02"] != "pixel" {
+			t.Errorf("Unexpected client devices: %+v", devices)
+		}
+	})
+
+	t.Run("Failure Does Not Fail Poll", func(t *testing.T) {
+		poller.clientsPolled = time.Time{}
+		mockClient.gateway.Time.LocalTime += 60
+		mockClient.clientsErr = &api.StatusError{StatusCode: http.StatusInternalServerError}
+		err := poller.Poll(ctx)
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+		err = poller.Poll(ctx)
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+		if mockClient.clientCalls != 4 {
+			t.Errorf("Expected the failed clients request to be retried, got %d calls", mockClient.clientCalls)
+		}
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		mockClient.clientCalls = 0
+		mockClient.clientsErr = fmt.Errorf("%w: no clients", errors.ErrUnsupported)
+		for range 2 {
+			poller.clientsPolled = time.Time{}
+			err := poller.Poll(ctx)
+			if err != nil {
+				t.Fatalf("Poll failed: %v", err)
+			}
+		}
+		if mockClient.clientCalls != 1 || !poller.clientsUnsupported {
+			t.Errorf("Expected clients to be requested once, got %d calls", mockClient.clientCalls)
+		}
+	})
+}
+
+func TestPollTelemetry(t *testing.T) {
+	poller, ctx, cleanup := setupPoller(t)
+	defer cleanup()
+	mockClient := poller.apiClient.(*MockAPIClient)
+	mockClient.telemetry.Cell.FourG = api.CellTelemetry{Bandwidth: "20M", Earfcn: 66786, Pci: 218, Tac: "11801", Plmn: "310260"}
+	mockClient.telemetry.Cell.FiveG = api.CellTelemetry{Bandwidth: "100M", NRArfcn: 520110, Pci: 403, Cqi: 11}
+
+	// channels counts the stored channels of each generation
+	channels := func(t *testing.T) map[string]int {
+		rows, err := poller.store.(*storage.SQLite).DB.QueryContext(ctx,
+			"SELECT signal.generation, COUNT(*) FROM signal_channel JOIN signal ON signal.id = signal_channel.signalid GROUP BY signal.generation")
+		if err != nil {
+			t.Fatalf("Failed to count channels: %v", err)
+		}
+		defer rows.Close()
+		counts := make(map[string]int)
+		for rows.Next() {
+			var generation string
+			var count int
+			err = rows.Scan(&generation, &count)
+			if err != nil {
+				t.Fatalf("Failed to count channels: %v", err)
+			}
+			counts[generation] = count
+		}
+		return counts
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		err := poller.Poll(ctx)
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+		if mockClient.telemetryCalls != 0 || len(channels(t)) != 0 {
+			t.Errorf("Expected no cell telemetry, got %d calls", mockClient.telemetryCalls)
+		}
+	})
+
+	t.Run("Stored With Signals", func(t *testing.T) {
+		poller.config.CellTelemetry = ptr(true)
+		mockClient.gateway.Time.LocalTime += 60
+		err := poller.Poll(ctx)
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+
+		var pci, arfcn int
+		var bandwidth string
+		err = poller.store.(*storage.SQLite).DB.QueryRowContext(ctx,
+			"SELECT pci, arfcn, bandwidth FROM signal_channel JOIN signal ON signal.id = signal_channel.signalid WHERE signal.generation = '5G'").Scan(&pci, &arfcn, &bandwidth)
+		if err != nil {
+			t.Fatalf("Failed to find the 5G channel: %v", err)
+		}
+		if pci != 403 || arfcn != 520110 || bandwidth != "100M" {
+			t.Errorf("Unexpected 5G channel: pci=%d arfcn=%d bandwidth=%s", pci, arfcn, bandwidth)
+		}
+
+		rows, err := poller.store.ListChannelsBetween(ctx, db.ListChannelsBetweenParams{End: time.Now()})
+		if err != nil {
+			t.Fatalf("Failed to list channels: %v", err)
+		}
+		if len(rows) != 2 || rows[0].Arfcn != 66786 || rows[0].Band != "B2" || rows[1].Pci != 403 || rows[1].RsrpAvg != -85 {
+			t.Errorf("Unexpected channels: %+v", rows)
+		}
+	})
+
+	t.Run("Generation Without Channel", func(t *testing.T) {
+		mockClient.gateway.Time.LocalTime += 60
+		fiveG := mockClient.telemetry.Cell.FiveG
+		mockClient.telemetry.Cell.FiveG = api.CellTelemetry{}
+		err := poller.Poll(ctx)
+		mockClient.telemetry.Cell.FiveG = fiveG
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+		if counts := channels(t); counts["4G"] != 2 || counts["5G"] != 1 {
+			t.Errorf("Expected 2 4G and 1 5G channels, got %v", counts)
+		}
+	})
+
+	t.Run("Failure Saves Signals", func(t *testing.T) {
+		mockClient.gateway.Time.LocalTime += 60
+		mockClient.telemetryErr = &api.StatusError{StatusCode: http.StatusInternalServerError}
+		err := poller.Poll(ctx)
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+		if counts := channels(t); counts["4G"] != 2 {
+			t.Errorf("Expected no new channels, got %v", counts)
+		}
+		if poller.telemetryUnsupported {
+			t.Error("Expected a failed request to be retried")
+		}
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		mockClient.telemetryCalls = 0
+		mockClient.telemetryErr = fmt.Errorf("%w: no telemetry", errors.ErrUnsupported)
+		for range 2 {
+			mockClient.gateway.Time.LocalTime += 60
+			err := poller.Poll(ctx)
+			if err != nil {
+				t.Fatalf("Poll failed: %v", err)
+			}
+		}
+		if mockClient.telemetryCalls != 1 {
+			t.Errorf("Expected cell telemetry to be requested once, got %d calls", mockClient.telemetryCalls)
+		}
+	})
+}
+
+func TestPollPolicies(t *testing.T) {
+	poller, ctx, cleanup := setupPoller(t)
+	defer cleanup()
+	mockClient := poller.apiClient.(*MockAPIClient)
+
+	var hooks atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hooks.Add(1)
+	}))
+	defer srv.Close()
+
+	config := poller.config
+	config.Policies = []policy.Rule{{
+		Name:       "no-5g",
+		Condition:  policy.NoFiveG,
+		Actions:    []string{policy.ActionReboot, policy.ActionWebhook},
+		WebhookURL: srv.URL,
+	}}
+	poller = NewGatewayPoller(config, mockClient, poller.store, nil, poller.logger)
+
+	err := poller.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	if mockClient.reboots != 0 || hooks.Load() != 0 {
+		t.Fatal("Expected no action while the gateway is healthy")
+	}
+
+	t.Run("Fires", func(t *testing.T) {
+		mockClient.gateway.Signal.FiveG.Bands = nil
+		err := poller.Poll(ctx)
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+		if mockClient.reboots != 1 || hooks.Load() != 1 {
+			t.Errorf("Expected a reboot and a webhook, got %d and %d", mockClient.reboots, hooks.Load())
+		}
+		if types := eventTypes(t, poller); !slices.Contains(types, events.Policy) {
+			t.Errorf("Expected a policy event, got %v", types)
+		}
+
+		poller.Poll(ctx)
+		if mockClient.reboots != 1 {
+			t.Errorf("Expected the cooldown to hold the next reboot, got %d reboots", mockClient.reboots)
+		}
+	})
+
+	t.Run("Restored After Restart", func(t *testing.T) {
+		restarted := NewGatewayPoller(config, mockClient, poller.store, nil, poller.logger)
+		restarted.Poll(ctx)
+		if mockClient.reboots != 1 {
+			t.Errorf("Expected the cooldown to be restored, got %d reboots", mockClient.reboots)
+		}
+	})
+
+	t.Run("Dry Run", func(t *testing.T) {
+		dryRun := config
+		dryRun.PolicyDryRun = ptr(true)
+		dryRun.Policies = []policy.Rule{{Name: "no-5g-dry", Condition: policy.NoFiveG, Actions: []string{policy.ActionReboot}}}
+		restarted := NewGatewayPoller(dryRun, mockClient, poller.store, nil, poller.logger)
+		restarted.Poll(ctx)
+		if mockClient.reboots != 1 {
+			t.Errorf("Expected no reboot in a dry run, got %d reboots", mockClient.reboots)
+		}
+
+		rows, err := poller.store.ListEventsBetween(ctx, db.ListEventsBetweenParams{End: time.Now().Add(time.Hour)})
+		if err != nil {
+			t.Fatalf("Failed to list events: %v", err)
+		}
+		last := rows[len(rows)-1]
+		if last.Type != events.Policy || last.NewValue != "no-5g-dry" || !strings.HasSuffix(last.Detail, "(dry run)") {
+			t.Errorf("Unexpected policy event: %+v", last)
+		}
+	})
+}
+
+// recordingObserver records the errors of the polls it is told about
+type recordingObserver struct {
+	errs []error
+}
+
+func (r *recordingObserver) ObservePoll(label string, gateway api.GatewayResponse, duration time.Duration, err error) {
+	r.errs = append(r.errs, err)
+}
+
+func TestPollObservers(t *testing.T) {
+	poller, ctx, cleanup := setupPoller(t)
+	defer cleanup()
+	client := &FaultyAPIClient{MockAPIClient: poller.apiClient.(*MockAPIClient)}
+	poller.apiClient = client
+	observer := &recordingObserver{}
+	poller.observers = []PollObserver{observer}
+
+	poller.Poll(ctx)
+	client.faults = []error{fmt.Errorf("%w: unexpected end of JSON input", api.ErrDecode)}
+	poller.Poll(ctx)
+
+	if len(observer.errs) != 2 || observer.errs[0] != nil || !errors.Is(observer.errs[1], api.ErrDecode) {
+		t.Errorf("Expected a successful and a failed poll to be observed, got %v", observer.errs)
+	}
+}
+
+func TestSupervisorIsolatesFailures(t *testing.T) {
+	healthy, _, cleanup := setupPoller(t)
+	defer cleanup()
+
+	failing := NewGatewayPoller(GatewayConfig{Label: "broken", PollDuration: 5 * time.Minute},
+		&MockAPIClient{err: &api.StatusError{StatusCode: http.StatusUnauthorized}}, healthy.store, nil, healthy.logger)
+	failing.retry = testRetryPolicy()
+
+	supervisor := &Supervisor{
+		config:  Config{Logger: healthy.logger},
+		store:   healthy.store,
+		pollers: []*GatewayPoller{failing, healthy},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	err := supervisor.Run(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the healthy gateway to keep running until the deadline, got %v", err)
+	}
+
+	var snapshots int
+	err = healthy.store.(*storage.SQLite).DB.QueryRow("SELECT COUNT(*) FROM snapshot WHERE label = 'home'").Scan(&snapshots)
+	if err != nil {
+		t.Fatalf("Failed to count snapshots: %v", err)
+	}
+	if snapshots != 1 {
+		t.Errorf("Expected 1 snapshot from the healthy gateway, got %d", snapshots)
+	}
+}
+
+func TestSupervisorClosesRecorders(t *testing.T) {
+	poller, _, cleanup := setupPoller(t)
+	defer cleanup()
+
+	recorder, err := api.NewRecorder(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("Failed to create recorder: %v", err)
+	}
+	supervisor := &Supervisor{
+		config:    Config{Logger: poller.logger},
+		store:     poller.store,
+		recorders: []*api.Recorder{recorder},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = supervisor.Run(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the canceled context, got %v", err)
+	}
+	if err := recorder.Record(time.Now(), 0, []byte("{}")); err == nil {
+		t.Error("Expected the recorder to be closed once Run returned")
+	}
+}
+
+func TestSupervisorReplaysWithoutExports(t *testing.T) {
+	sqlDB, dsn := setupTestDatabase(t)
+	sqlDB.Close()
+
+	dir := t.TempDir()
+	recorder, err := api.NewRecorder(dir, 0)
+	if err != nil {
+		t.Fatalf("Failed to create recorder: %v", err)
+	}
+	body, err := os.ReadFile("api/testdata/sercomm_gateway.json")
+	if err != nil {
+		t.Fatalf("Failed to read gateway response: %v", err)
+	}
+	recorder.Record(time.Now(), time.Second, body)
+	recorder.Close()
+
+	supervisor := NewSupervisor(Config{
+		DBBackend:  storage.BackendSQLite,
+		DBDSN:      dsn,
+		ReplayPath: dir,
+		Influx:     influx.Config{URL: "http://localhost:8086/api/v2/write", BatchSize: 10, FlushInterval: time.Minute},
+		MQTT:       mqtt.Config{Broker: "tcp://localhost:1883"},
+		Logger:     log.New(io.Discard, "", 0),
+	})
+	err = supervisor.Initialize(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer supervisor.store.Close()
+
+	if supervisor.influx != nil || supervisor.mqtt != nil || len(supervisor.pollers[0].observers) != 0 {
+		t.Fatal("Expected the export and the MQTT publisher to be left out of a replay")
+	}
+	err = supervisor.Replay(context.Background())
+	if err != nil {
+		t.Errorf("Replay failed: %v", err)
+	}
+}
+
+func TestReplaySkipsCorruptRecordings(t *testing.T) {
+	poller, ctx, cleanup := setupPoller(t)
+	defer cleanup()
+
+	dir := t.TempDir()
+	recorder, err := api.NewRecorder(dir, 0)
+	if err != nil {
+		t.Fatalf("Failed to create recorder: %v", err)
+	}
+	body, err := os.ReadFile("api/testdata/sercomm_gateway.json")
+	if err != nil {
+		t.Fatalf("Failed to read gateway response: %v", err)
+	}
+	recorder.Record(time.Now(), time.Second, body)
+	// A body that is not JSON is recorded as a string that does not decode as a gateway response
+	recorder.Record(time.Now(), time.Second, []byte("<html>Service Unavailable</html>"))
+	recorder.Record(time.Now(), time.Second, body)
+	recorder.Close()
+
+	// A line cut short in the middle of the archive
+	archive := filepath.Join(dir, "gateway.jsonl")
+	lines, err := os.ReadFile(archive)
+	if err != nil {
+		t.Fatalf("Failed to read archive: %v", err)
+	}
+	first, rest, _ := bytes.Cut(lines, []byte("\n"))
+	corrupt := append(append(first, '\n'), `{"time":"2025-04-01T12:00:00Z","body":{"devi`+"\n"...)
+	err = os.WriteFile(archive, append(corrupt, rest...), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+
+	client, err := api.NewReplayClient(dir)
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+	defer client.Close()
+	poller.apiClient = client
+
+	err = poller.Replay(ctx)
+	if err != nil {
+		t.Fatalf("Expected the corrupt recordings to be skipped, got %v", err)
+	}
+	var snapshots int
+	err = poller.store.(*storage.SQLite).DB.QueryRow("SELECT COUNT(*) FROM snapshot").Scan(&snapshots)
+	if err != nil {
+		t.Fatalf("Failed to count snapshots: %v", err)
+	}
+	if snapshots != 2 {
+		t.Errorf("Expected the 2 valid recordings to be stored, got %d", snapshots)
+	}
+}
+
+// testRetryPolicy retries every class of error after a millisecond
+func testRetryPolicy() retryPolicy {
+	p := backoff.Policy{Initial: time.Millisecond, Max: time.Millisecond, Multiplier: 2}
+	return retryPolicy{Network: p, Auth: p, Status: p, Decode: p, Storage: p}
+}
+
+// eventTypes returns the types of the events stored by a poller set up by setupPoller, in order
+func eventTypes(tb testing.TB, poller *GatewayPoller) []string {
+	rows, err := poller.store.ListEventsBetween(context.Background(), db.ListEventsBetweenParams{End: time.Now().Add(time.Hour)})
+	if err != nil {
+		tb.Fatalf("Failed to list events: %v", err)
+	}
+	var types []string
+	for _, row := range rows {
+		types = append(types, row.Type)
+	}
+	return types
+}
+
+// countSnapshots returns the number of snapshots stored by a poller set up by setupPoller
+func countSnapshots(tb testing.TB, poller *GatewayPoller) int {
+	var snapshots int
+	err := poller.store.(*FaultyStore).Store.(*storage.SQLite).DB.QueryRow("SELECT COUNT(*) FROM snapshot").Scan(&snapshots)
+	if err != nil {
+		tb.Fatalf("Failed to count snapshots: %v", err)
+	}
+	return snapshots
+}
+
+func TestRun(t *testing.T) {
+	setup := func(t *testing.T, apiFaults, storeFaults []error) (*GatewayPoller, *FaultyAPIClient) {
+		poller, _, cleanup := setupPoller(t)
+		t.Cleanup(cleanup)
+
+		client := &FaultyAPIClient{MockAPIClient: poller.apiClient.(*MockAPIClient), faults: apiFaults}
+		poller.apiClient = client
+		poller.store = &FaultyStore{Store: poller.store, faults: storeFaults}
+		poller.retry = testRetryPolicy()
+		return poller, client
+	}
+
+	t.Run("Retries Every Error Class", func(t *testing.T) {
+		poller, client := setup(t, []error{
+			fmt.Errorf("%w: connect: network is unreachable", api.ErrNetwork),
+			&api.StatusError{StatusCode: http.StatusServiceUnavailable},
+			&api.StatusError{StatusCode: http.StatusUnauthorized},
+			fmt.Errorf("%w: unexpected end of JSON input", api.ErrDecode),
+			errors.New("unclassified"),
+		}, []error{nil, sqlite3.Error{Code: sqlite3.ErrBusy}})
+		// Order the events recorded by the poller clock before those recorded by the gateway clock
+		client.gateway.Time.LocalTime = int(time.Now().Add(time.Minute).Unix())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		err := poller.Run(ctx)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected polling to continue until the deadline, got %v", err)
+		}
+		// Five gateway faults, a poll failing to store and a successful poll
+		if client.calls != 7 {
+			t.Errorf("Expected 7 polls, got %d", client.calls)
+		}
+		if snapshots := countSnapshots(t, poller); snapshots != 1 {
+			t.Errorf("Expected 1 snapshot, got %d", snapshots)
+		}
+		if types := eventTypes(t, poller); !slices.Equal(types, []string{
+			events.UnreachableStart, events.OutageStart, events.UnreachableEnd, events.OutageEnd,
+		}) {
+			t.Errorf("Unexpected events: %v", types)
+		}
+	})
+
+	t.Run("First Poll Failure Is Retried", func(t *testing.T) {
+		poller, client := setup(t, []error{fmt.Errorf("%w: connection refused", api.ErrNetwork)}, nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		err := poller.Run(ctx)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected polling to continue until the deadline, got %v", err)
+		}
+		if client.calls != 2 {
+			t.Errorf("Expected 2 polls, got %d", client.calls)
+		}
+	})
+
+	t.Run("Stops On Unrecoverable Storage Error", func(t *testing.T) {
+		poller, client := setup(t, nil, []error{sqlite3.Error{Code: sqlite3.ErrCorrupt}})
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		err := poller.Run(ctx)
+		if !errors.Is(err, errStorage) {
+			t.Errorf("Expected storage error, got %v", err)
+		}
+		if client.calls != 1 {
+			t.Errorf("Expected 1 poll, got %d", client.calls)
+		}
+	})
+
+	t.Run("4G-Only Snapshot Is Stored", func(t *testing.T) {
+		poller, client := setup(t, nil, nil)
+		client.gateway.Signal.FiveG.Bands = nil
+
+		err := poller.Poll(context.Background())
+		if err != nil {
+			t.Fatalf("Expected a 4G-only poll to succeed, got %v", err)
+		}
+		if snapshots := countSnapshots(t, poller); snapshots != 1 {
+			t.Errorf("Expected 1 snapshot, got %d", snapshots)
+		}
+
+		rows, err := poller.store.(*FaultyStore).Store.(*storage.SQLite).DB.Query("SELECT generation FROM signal")
+		if err != nil {
+			t.Fatalf("Failed to query signals: %v", err)
+		}
+		defer rows.Close()
+		var generations []string
+		for rows.Next() {
+			var generation string
+			if err := rows.Scan(&generation); err != nil {
+				t.Fatalf("Failed to scan signal: %v", err)
+			}
+			generations = append(generations, generation)
+		}
+		if !slices.Equal(generations, []string{"4G"}) {
+			t.Errorf("Expected only a 4G signal, got %v", generations)
+		}
+	})
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := func(d time.Duration) backoff.Policy {
+		return backoff.Policy{Initial: d, Max: time.Hour, Multiplier: 2}
+	}
+	r := retryPolicy{
+		Network: policy(time.Second),
+		Auth:    policy(2 * time.Second),
+		Status:  policy(3 * time.Second),
+		Decode:  policy(4 * time.Second),
+		Storage: policy(5 * time.Second),
+	}
+
+	tests := []struct {
+		name     string
+		err      error
+		expected time.Duration
+		ok       bool
+	}{
+		{"Network", fmt.Errorf("error getting gateway from API: %w", fmt.Errorf("%w: timeout", api.ErrNetwork)), time.Second, true},
+		{"Auth", &api.StatusError{StatusCode: http.StatusUnauthorized}, 2 * time.Second, true},
+		{"Status", &api.StatusError{StatusCode: http.StatusInternalServerError}, 3 * time.Second, true},
+		{"Decode", fmt.Errorf("%w: bad JSON", api.ErrDecode), 4 * time.Second, true},
+		{"Transient Storage", fmt.Errorf("%w: %w", errStorage, sqlite3.Error{Code: sqlite3.ErrLocked}), 5 * time.Second, true},
+		{"Unrecoverable Storage", fmt.Errorf("%w: %w", errStorage, errors.New("no such table: snapshot")), 0, false},
+		{"Unclassified", errors.New("unknown"), time.Second, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, ok := r.delay(tt.err, 2)
+			if ok != tt.ok {
+				t.Fatalf("Expected retry %t, got %t", tt.ok, ok)
+			}
+			if ok && delay != 2*tt.expected {
+				t.Errorf("Expected second retry after %s, got %s", 2*tt.expected, delay)
+			}
+		})
+	}
+}
+
 // BenchmarkPoll benchmarks the Poll method
 func BenchmarkPoll(b *testing.B) {
-	poller, ctx, cleanup := setupBenchmark(b)
+	poller, ctx, cleanup := setupPoller(b)
 	defer cleanup()
 
 	for b.Loop() {
@@ -160,7 +1111,7 @@ func BenchmarkPoll(b *testing.B) {
 
 // BenchmarkWithVariableData tests Poll with different data each time
 func BenchmarkPollWithVariableData(b *testing.B) {
-	poller, ctx, cleanup := setupBenchmark(b)
+	poller, ctx, cleanup := setupPoller(b)
 	defer cleanup()
 
 	// Get original gateway for modification
@@ -191,7 +1142,7 @@ func BenchmarkPollWithVariableData(b *testing.B) {
 func BenchmarkPollParallel(b *testing.B) {
 	b.RunParallel(func(pb *testing.PB) {
 		// Each goroutine needs its own setup
-		poller, ctx, cleanup := setupBenchmark(b)
+		poller, ctx, cleanup := setupPoller(b)
 		defer cleanup()
 
 		counter := 0
@@ -213,11 +1164,11 @@ func BenchmarkPollParallel(b *testing.B) {
 
 // BenchmarkTransactionOnly benchmarks just the database transaction overhead
 func BenchmarkTransactionOnly(b *testing.B) {
-	poller, ctx, cleanup := setupBenchmark(b)
+	poller, ctx, cleanup := setupPoller(b)
 	defer cleanup()
 
 	for b.Loop() {
-		tx, err := poller.db.BeginTx(ctx, nil)
+		tx, err := poller.store.(*storage.SQLite).DB.BeginTx(ctx, nil)
 		if err != nil {
 			b.Fatalf("Failed to begin transaction: %v", err)
 		}
@@ -244,7 +1195,7 @@ type DelayedAPIClient struct {
 
 // BenchmarkRealWorldScenario simulates a more realistic polling scenario
 func BenchmarkRealWorldScenario(b *testing.B) {
-	poller, ctx, cleanup := setupBenchmark(b)
+	poller, ctx, cleanup := setupPoller(b)
 	defer cleanup()
 
 	// Create a delayed API client
@@ -285,7 +1236,7 @@ func BenchmarkInsertionVolume(b *testing.B) {
 		b.N = 10
 	}
 
-	poller, ctx, cleanup := setupBenchmark(b)
+	poller, ctx, cleanup := setupPoller(b)
 	defer cleanup()
 
 	// Pre-populate the database with many entries
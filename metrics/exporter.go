@@ -0,0 +1,213 @@
+package metrics
+
+import (
+	"local/tmo/api"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "tmo"
+
+// gatewayLabel identifies the gateway a metric was observed on, by its configured label
+const gatewayLabel = "gateway"
+
+// signalLabels identify the gateway and cell a signal gauge was measured on
+var signalLabels = []string{gatewayLabel, "generation", "band", "cid", "node"}
+
+// Exporter exposes the latest gateway response and poller health as Prometheus metrics
+type Exporter struct {
+	registry *prometheus.Registry
+
+	rsrp *prometheus.GaugeVec
+	rsrq *prometheus.GaugeVec
+	rssi *prometheus.GaugeVec
+	sinr *prometheus.GaugeVec
+	bars *prometheus.GaugeVec
+
+	uptime       *prometheus.GaugeVec
+	registration *prometheus.GaugeVec
+	roaming      *prometheus.GaugeVec
+	apn          *prometheus.GaugeVec
+	ipv6         *prometheus.GaugeVec
+	clients      *prometheus.GaugeVec
+
+	pollSuccesses *prometheus.CounterVec
+	pollFailures  *prometheus.CounterVec
+	logins        *prometheus.CounterVec
+	lastPoll      *prometheus.GaugeVec
+	pollLatency   *prometheus.HistogramVec
+}
+
+// NewExporter creates a new Exporter with all metrics registered
+func NewExporter() *Exporter {
+	e := &Exporter{
+		registry: prometheus.NewRegistry(),
+		rsrp:     newSignalGauge("rsrp_dbm", "Reference signal received power"),
+		rsrq:     newSignalGauge("rsrq_db", "Reference signal received quality"),
+		rssi:     newSignalGauge("rssi_dbm", "Received signal strength indicator"),
+		sinr:     newSignalGauge("sinr_db", "Signal to interference plus noise ratio"),
+		bars:     newSignalGauge("bars", "Signal bars shown by the gateway"),
+		uptime: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "gateway_uptime_seconds",
+			Help:      "Gateway uptime as reported by the gateway",
+		}, []string{gatewayLabel}),
+		registration: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "gateway_registration",
+			Help:      "Network registration state, 1 for the current state",
+		}, []string{gatewayLabel, "state"}),
+		roaming: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "gateway_roaming",
+			Help:      "1 if the gateway is roaming",
+		}, []string{gatewayLabel}),
+		apn: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "gateway_apn",
+			Help:      "Access point name in use, 1 for the current APN",
+		}, []string{gatewayLabel, "apn"}),
+		ipv6: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "gateway_ipv6",
+			Help:      "1 if the gateway has an IPv6 address",
+		}, []string{gatewayLabel}),
+		clients: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "gateway_clients",
+			Help:      "Number of connected clients by connection, as of the last clients poll",
+		}, []string{gatewayLabel, "connection"}),
+		pollSuccesses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "poll_successes_total",
+			Help:      "Number of successful polls",
+		}, []string{gatewayLabel}),
+		pollFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "poll_failures_total",
+			Help:      "Number of failed polls",
+		}, []string{gatewayLabel}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Number of successful gateway logins",
+		}, []string{gatewayLabel}),
+		lastPoll: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_poll_timestamp_seconds",
+			Help:      "Unix time of the last successful poll",
+		}, []string{gatewayLabel}),
+		pollLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "poll_duration_seconds",
+			Help:      "Time taken to poll the gateway and store the result",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10},
+		}, []string{gatewayLabel}),
+	}
+
+	e.registry.MustRegister(
+		e.rsrp, e.rsrq, e.rssi, e.sinr, e.bars,
+		e.uptime, e.registration, e.roaming, e.apn, e.ipv6, e.clients,
+		e.pollSuccesses, e.pollFailures, e.logins, e.lastPoll, e.pollLatency,
+	)
+
+	return e
+}
+
+// Handler returns the HTTP handler serving the metrics
+func (e *Exporter) Handler() http.Handler {
+	return promhttp.HandlerFor(e.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveLogin records a successful login to the gateway with the given label
+func (e *Exporter) ObserveLogin(label string) {
+	e.logins.WithLabelValues(label).Inc()
+}
+
+// ObservePoll records the outcome of a poll of the gateway with the given label, updating its gauges on success
+func (e *Exporter) ObservePoll(label string, gateway api.GatewayResponse, duration time.Duration, err error) {
+	e.pollLatency.WithLabelValues(label).Observe(duration.Seconds())
+
+	// Create both counters on the first poll so each gateway reports them from zero
+	successes, failures := e.pollSuccesses.WithLabelValues(label), e.pollFailures.WithLabelValues(label)
+	if err != nil {
+		failures.Inc()
+		return
+	}
+
+	successes.Inc()
+	e.lastPoll.WithLabelValues(label).SetToCurrentTime()
+	e.observeGateway(label, gateway)
+}
+
+// ObserveClients replaces the connected client counts of the gateway with the given label
+func (e *Exporter) ObserveClients(label string, clients api.ClientsResponse) {
+	e.clients.DeletePartialMatch(prometheus.Labels{gatewayLabel: label})
+	for connection, list := range clients.Clients {
+		connected := 0
+		for _, client := range list {
+			if client.Connected {
+				connected++
+			}
+		}
+		e.clients.WithLabelValues(label, connection).Set(float64(connected))
+	}
+}
+
+// observeGateway replaces the gauges of one gateway with the values from its latest response
+func (e *Exporter) observeGateway(label string, gateway api.GatewayResponse) {
+	// Delete so a cell or band the gateway is no longer connected to stops being reported
+	for _, vec := range []*prometheus.GaugeVec{e.rsrp, e.rsrq, e.rssi, e.sinr, e.bars, e.registration, e.apn} {
+		vec.DeletePartialMatch(prometheus.Labels{gatewayLabel: label})
+	}
+
+	e.observeSignal(label, "4G", gateway.Signal.FourG, gateway.Signal.FourG.ENBID)
+	e.observeSignal(label, "5G", gateway.Signal.FiveG, gateway.Signal.FiveG.GNBID)
+
+	e.uptime.WithLabelValues(label).Set(float64(gateway.Time.UpTime))
+	e.registration.WithLabelValues(label, gateway.Signal.Generic.Registration).Set(1)
+	e.roaming.WithLabelValues(label).Set(boolToFloat(gateway.Signal.Generic.Roaming))
+	e.apn.WithLabelValues(label, gateway.Signal.Generic.Apn).Set(1)
+	e.ipv6.WithLabelValues(label).Set(boolToFloat(gateway.Signal.Generic.HasIPv6))
+}
+
+// observeSignal sets the signal gauges of one gateway for one generation
+func (e *Exporter) observeSignal(label string, generation string, stats api.SignalStats, node int) {
+	if len(stats.Bands) == 0 {
+		return
+	}
+
+	labels := prometheus.Labels{
+		gatewayLabel: label,
+		"generation": generation,
+		"band":       stats.Bands[0],
+		"cid":        strconv.Itoa(stats.Cid),
+		"node":       strconv.Itoa(node),
+	}
+
+	e.rsrp.With(labels).Set(float64(stats.Rsrp))
+	e.rsrq.With(labels).Set(float64(stats.Rsrq))
+	e.rssi.With(labels).Set(float64(stats.Rssi))
+	e.sinr.With(labels).Set(float64(stats.Sinr))
+	e.bars.With(labels).Set(stats.Bars)
+}
+
+func newSignalGauge(name, help string) *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "signal",
+		Name:      name,
+		Help:      help,
+	}, signalLabels)
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
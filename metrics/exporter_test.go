@@ -0,0 +1,163 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"local/tmo/api"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func scrape(t *testing.T, e *Exporter) string {
+	rec := httptest.NewRecorder()
+	e.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatalf("Failed to read metrics: %v", err)
+	}
+	return string(body)
+}
+
+func TestObservePoll(t *testing.T) {
+	gateway := api.GatewayResponse{
+		Signal: api.Signal{
+			FourG: api.SignalStats{Bands: []string{"b66"}, Cid: 32, ENBID: 23270, Rsrp: -102},
+			FiveG: api.SignalStats{Bands: []string{"n41", "n25"}, Cid: 12, GNBID: 4567, Rsrp: -105, Sinr: 13},
+			Generic: api.Generic{
+				Apn:          "FBB.HOME",
+				HasIPv6:      true,
+				Registration: "registered",
+			},
+		},
+		Time: api.Time{UpTime: 27653},
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		e := NewExporter()
+		e.ObserveLogin("home")
+		e.ObservePoll("home", gateway, 100*time.Millisecond, nil)
+
+		body := scrape(t, e)
+		expected := []string{
+			`tmo_signal_rsrp_dbm{band="b66",cid="32",gateway="home",generation="4G",node="23270"} -102`,
+			`tmo_signal_rsrp_dbm{band="n41",cid="12",gateway="home",generation="5G",node="4567"} -105`,
+			`tmo_signal_sinr_db{band="n41",cid="12",gateway="home",generation="5G",node="4567"} 13`,
+			`tmo_gateway_uptime_seconds{gateway="home"} 27653`,
+			`tmo_gateway_registration{gateway="home",state="registered"} 1`,
+			`tmo_gateway_roaming{gateway="home"} 0`,
+			`tmo_gateway_apn{apn="FBB.HOME",gateway="home"} 1`,
+			`tmo_gateway_ipv6{gateway="home"} 1`,
+			`tmo_poll_successes_total{gateway="home"} 1`,
+			`tmo_poll_failures_total{gateway="home"} 0`,
+			`tmo_logins_total{gateway="home"} 1`,
+			`tmo_poll_duration_seconds_count{gateway="home"} 1`,
+		}
+		for _, line := range expected {
+			if !strings.Contains(body, line) {
+				t.Errorf("Expected metrics to contain %q", line)
+			}
+		}
+	})
+
+	t.Run("Failure Keeps Last Values", func(t *testing.T) {
+		e := NewExporter()
+		e.ObservePoll("home", gateway, 100*time.Millisecond, nil)
+		e.ObservePoll("home", api.GatewayResponse{}, time.Second, errors.New("network is unreachable"))
+
+		body := scrape(t, e)
+		expected := []string{
+			`tmo_signal_rsrp_dbm{band="n41",cid="12",gateway="home",generation="5G",node="4567"} -105`,
+			`tmo_poll_successes_total{gateway="home"} 1`,
+			`tmo_poll_failures_total{gateway="home"} 1`,
+			`tmo_poll_duration_seconds_count{gateway="home"} 2`,
+		}
+		for _, line := range expected {
+			if !strings.Contains(body, line) {
+				t.Errorf("Expected metrics to contain %q", line)
+			}
+		}
+	})
+
+	t.Run("Stale Cell Removed", func(t *testing.T) {
+		e := NewExporter()
+		e.ObservePoll("home", gateway, 100*time.Millisecond, nil)
+
+		handoff := gateway
+		handoff.Signal.FiveG.Cid = 13
+		e.ObservePoll("home", handoff, 100*time.Millisecond, nil)
+
+		body := scrape(t, e)
+		if strings.Contains(body, `cid="12",gateway="home",generation="5G"`) {
+			t.Error("Expected previous 5G cell to be removed")
+		}
+		if !strings.Contains(body, `cid="13",gateway="home",generation="5G"`) {
+			t.Error("Expected new 5G cell to be reported")
+		}
+	})
+
+	t.Run("Stale APN Removed", func(t *testing.T) {
+		e := NewExporter()
+		e.ObservePoll("home", gateway, 100*time.Millisecond, nil)
+
+		changed := gateway
+		changed.Signal.Generic.Apn = "FAST.T-MOBILE.COM"
+		e.ObservePoll("home", changed, 100*time.Millisecond, nil)
+
+		body := scrape(t, e)
+		if strings.Contains(body, `apn="FBB.HOME"`) {
+			t.Error("Expected previous APN to be removed")
+		}
+		if !strings.Contains(body, `tmo_gateway_apn{apn="FAST.T-MOBILE.COM",gateway="home"} 1`) {
+			t.Error("Expected new APN to be reported")
+		}
+	})
+	t.Run("Gateways Are Independent", func(t *testing.T) {
+		e := NewExporter()
+		e.ObservePoll("home", gateway, 100*time.Millisecond, nil)
+
+		lab := gateway
+		lab.Signal.FiveG.Cid = 99
+		e.ObservePoll("lab", lab, 100*time.Millisecond, nil)
+
+		body := scrape(t, e)
+		expected := []string{
+			`cid="12",gateway="home",generation="5G"`,
+			`cid="99",gateway="lab",generation="5G"`,
+			`tmo_poll_successes_total{gateway="lab"} 1`,
+		}
+		for _, line := range expected {
+			if !strings.Contains(body, line) {
+				t.Errorf("Expected metrics to contain %q", line)
+			}
+		}
+	})
+}
+
+func TestObserveClients(t *testing.T) {
+	e := NewExporter()
+	e.ObserveClients("home", api.ClientsResponse{Clients: map[string][]api.ConnectedClient{
+		"2.4ghz":   {{Connected: true}, {Connected: false}},
+		"5.0ghz":   {{Connected: true}, {Connected: true}},
+		"ethernet": {{Connected: true}},
+	}})
+	e.ObserveClients("home", api.ClientsResponse{Clients: map[string][]api.ConnectedClient{
+		"2.4ghz": {{Connected: true}, {Connected: false}},
+		"5.0ghz": {{Connected: true}, {Connected: true}},
+	}})
+
+	body := scrape(t, e)
+	expected := []string{
+		`tmo_gateway_clients{connection="2.4ghz",gateway="home"} 1`,
+		`tmo_gateway_clients{connection="5.0ghz",gateway="home"} 2`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected metrics to contain %q", line)
+		}
+	}
+	if strings.Contains(body, `connection="ethernet"`) {
+		t.Error("Expected the stale connection to be removed")
+	}
+}
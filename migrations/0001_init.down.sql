@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS signal;
+
+DROP TABLE IF EXISTS snapshot;
+
+DROP TABLE IF EXISTS device;
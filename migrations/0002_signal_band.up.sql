@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS signal_band (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    signalid INT NOT NULL,
+    band VARCHAR(4) NOT NULL,
+    is_primary BOOLEAN NOT NULL,
+    FOREIGN KEY (signalid) REFERENCES signal (id)
+);
+
+CREATE INDEX IF NOT EXISTS ix_signal_band_signalid ON signal_band (signalid);
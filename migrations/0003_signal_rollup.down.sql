@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS ix_snapshot_created_at;
+
+DROP TABLE IF EXISTS signal_rollup_day;
+
+DROP TABLE IF EXISTS signal_rollup_hour;
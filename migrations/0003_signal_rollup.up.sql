@@ -0,0 +1,67 @@
+CREATE TABLE IF NOT EXISTS signal_rollup_hour (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    period_start TIMESTAMP NOT NULL,
+    generation VARCHAR(2) NOT NULL,
+    band VARCHAR(4) NOT NULL,
+    cid INT NOT NULL,
+    enbid INT NOT NULL,
+    gnbid INT NOT NULL,
+    sample_count INT NOT NULL,
+    rsrp_min INT NOT NULL,
+    rsrp_max INT NOT NULL,
+    rsrp_avg FLOAT NOT NULL,
+    rsrp_p5 INT NOT NULL,
+    rsrp_p95 INT NOT NULL,
+    rsrq_min INT NOT NULL,
+    rsrq_max INT NOT NULL,
+    rsrq_avg FLOAT NOT NULL,
+    rsrq_p5 INT NOT NULL,
+    rsrq_p95 INT NOT NULL,
+    rssi_min INT NOT NULL,
+    rssi_max INT NOT NULL,
+    rssi_avg FLOAT NOT NULL,
+    rssi_p5 INT NOT NULL,
+    rssi_p95 INT NOT NULL,
+    sinr_min INT NOT NULL,
+    sinr_max INT NOT NULL,
+    sinr_avg FLOAT NOT NULL,
+    sinr_p5 INT NOT NULL,
+    sinr_p95 INT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_signal_rollup_hour_cell ON signal_rollup_hour (period_start, generation, band, cid, enbid, gnbid);
+
+CREATE TABLE IF NOT EXISTS signal_rollup_day (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    period_start TIMESTAMP NOT NULL,
+    generation VARCHAR(2) NOT NULL,
+    band VARCHAR(4) NOT NULL,
+    cid INT NOT NULL,
+    enbid INT NOT NULL,
+    gnbid INT NOT NULL,
+    sample_count INT NOT NULL,
+    rsrp_min INT NOT NULL,
+    rsrp_max INT NOT NULL,
+    rsrp_avg FLOAT NOT NULL,
+    rsrp_p5 INT NOT NULL,
+    rsrp_p95 INT NOT NULL,
+    rsrq_min INT NOT NULL,
+    rsrq_max INT NOT NULL,
+    rsrq_avg FLOAT NOT NULL,
+    rsrq_p5 INT NOT NULL,
+    rsrq_p95 INT NOT NULL,
+    rssi_min INT NOT NULL,
+    rssi_max INT NOT NULL,
+    rssi_avg FLOAT NOT NULL,
+    rssi_p5 INT NOT NULL,
+    rssi_p95 INT NOT NULL,
+    sinr_min INT NOT NULL,
+    sinr_max INT NOT NULL,
+    sinr_avg FLOAT NOT NULL,
+    sinr_p5 INT NOT NULL,
+    sinr_p95 INT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_signal_rollup_day_cell ON signal_rollup_day (period_start, generation, band, cid, enbid, gnbid);
+
+CREATE INDEX IF NOT EXISTS ix_snapshot_created_at ON snapshot (created_at);
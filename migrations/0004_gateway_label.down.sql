@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS ux_signal_rollup_day_cell;
+
+ALTER TABLE signal_rollup_day DROP COLUMN label;
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_signal_rollup_day_cell ON signal_rollup_day (period_start, generation, band, cid, enbid, gnbid);
+
+DROP INDEX IF EXISTS ux_signal_rollup_hour_cell;
+
+ALTER TABLE signal_rollup_hour DROP COLUMN label;
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_signal_rollup_hour_cell ON signal_rollup_hour (period_start, generation, band, cid, enbid, gnbid);
+
+DROP INDEX IF EXISTS ix_snapshot_label_created_at;
+
+ALTER TABLE snapshot DROP COLUMN label;
+
+ALTER TABLE device DROP COLUMN label;
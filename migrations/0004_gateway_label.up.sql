@@ -0,0 +1,17 @@
+ALTER TABLE device ADD COLUMN label VARCHAR(100) NOT NULL DEFAULT '';
+
+ALTER TABLE snapshot ADD COLUMN label VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS ix_snapshot_label_created_at ON snapshot (label, created_at);
+
+ALTER TABLE signal_rollup_hour ADD COLUMN label VARCHAR(100) NOT NULL DEFAULT '';
+
+DROP INDEX IF EXISTS ux_signal_rollup_hour_cell;
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_signal_rollup_hour_cell ON signal_rollup_hour (period_start, label, generation, band, cid, enbid, gnbid);
+
+ALTER TABLE signal_rollup_day ADD COLUMN label VARCHAR(100) NOT NULL DEFAULT '';
+
+DROP INDEX IF EXISTS ux_signal_rollup_day_cell;
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_signal_rollup_day_cell ON signal_rollup_day (period_start, label, generation, band, cid, enbid, gnbid);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS ix_event_label_type;
+
+DROP INDEX IF EXISTS ix_event_created_at;
+
+DROP TABLE IF EXISTS event;
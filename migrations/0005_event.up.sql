@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS event (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    label VARCHAR(100) NOT NULL DEFAULT '',
+    type VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    old_value VARCHAR(100) NOT NULL DEFAULT '',
+    new_value VARCHAR(100) NOT NULL DEFAULT '',
+    detail TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS ix_event_created_at ON event (created_at);
+
+CREATE INDEX IF NOT EXISTS ix_event_label_type ON event (label, type);
@@ -0,0 +1,60 @@
+CREATE TABLE IF NOT EXISTS cell (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    label VARCHAR(100) NOT NULL DEFAULT '',
+    generation VARCHAR(2) NOT NULL,
+    enbid INT NOT NULL,
+    gnbid INT NOT NULL,
+    cid INT NOT NULL,
+    bands VARCHAR(100) NOT NULL,
+    first_seen TIMESTAMP NOT NULL,
+    last_seen TIMESTAMP NOT NULL,
+    sample_count INT NOT NULL,
+    rsrp_avg FLOAT NOT NULL,
+    rsrq_avg FLOAT NOT NULL,
+    rssi_avg FLOAT NOT NULL,
+    sinr_avg FLOAT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_cell ON cell (label, generation, enbid, gnbid, cid);
+
+CREATE INDEX IF NOT EXISTS ix_cell_label_generation_last_seen ON cell (label, generation, last_seen);
+
+INSERT INTO
+    cell (
+        label,
+        generation,
+        enbid,
+        gnbid,
+        cid,
+        bands,
+        first_seen,
+        last_seen,
+        sample_count,
+        rsrp_avg,
+        rsrq_avg,
+        rssi_avg,
+        sinr_avg
+    )
+SELECT
+    snapshot.label,
+    signal.generation,
+    signal.enbid,
+    signal.gnbid,
+    signal.cid,
+    GROUP_CONCAT(DISTINCT signal.band),
+    MIN(snapshot.created_at),
+    MAX(snapshot.created_at),
+    COUNT(*),
+    AVG(signal.rsrp),
+    AVG(signal.rsrq),
+    AVG(signal.rssi),
+    AVG(signal.sinr)
+FROM
+    signal
+    JOIN snapshot ON snapshot.id = signal.snapshotid
+GROUP BY
+    snapshot.label,
+    signal.generation,
+    signal.enbid,
+    signal.gnbid,
+    signal.cid;
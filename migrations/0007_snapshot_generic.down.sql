@@ -0,0 +1,7 @@
+ALTER TABLE snapshot DROP COLUMN roaming;
+
+ALTER TABLE snapshot DROP COLUMN registration;
+
+ALTER TABLE snapshot DROP COLUMN has_ipv6;
+
+ALTER TABLE snapshot DROP COLUMN apn;
@@ -0,0 +1,7 @@
+ALTER TABLE snapshot ADD COLUMN apn VARCHAR(100) NOT NULL DEFAULT '';
+
+ALTER TABLE snapshot ADD COLUMN has_ipv6 BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE snapshot ADD COLUMN registration VARCHAR(50) NOT NULL DEFAULT '';
+
+ALTER TABLE snapshot ADD COLUMN roaming BOOLEAN NOT NULL DEFAULT FALSE;
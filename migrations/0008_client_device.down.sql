@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS ix_client_observation_clientdeviceid;
+
+DROP INDEX IF EXISTS ix_client_observation_created_at;
+
+DROP TABLE IF EXISTS client_observation;
+
+DROP INDEX IF EXISTS ux_client_device;
+
+DROP TABLE IF EXISTS client_device;
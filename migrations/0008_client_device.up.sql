@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS client_device (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    label VARCHAR(100) NOT NULL DEFAULT '',
+    mac VARCHAR(17) NOT NULL,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    first_seen TIMESTAMP NOT NULL,
+    last_seen TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_client_device ON client_device (label, mac);
+
+CREATE TABLE IF NOT EXISTS client_observation (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    clientdeviceid INT NOT NULL,
+    label VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    connection VARCHAR(20) NOT NULL,
+    connected BOOLEAN NOT NULL,
+    ipv4 VARCHAR(15) NOT NULL DEFAULT '',
+    ipv6 TEXT NOT NULL DEFAULT '',
+    signal INT NOT NULL DEFAULT 0,
+    FOREIGN KEY (clientdeviceid) REFERENCES client_device (id)
+);
+
+CREATE INDEX IF NOT EXISTS ix_client_observation_created_at ON client_observation (created_at);
+
+CREATE INDEX IF NOT EXISTS ix_client_observation_clientdeviceid ON client_observation (clientdeviceid);
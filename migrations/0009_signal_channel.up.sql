@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS signal_channel (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    signalid INT NOT NULL,
+    pci INT NOT NULL,
+    arfcn INT NOT NULL,
+    bandwidth VARCHAR(10) NOT NULL DEFAULT '',
+    cqi INT NOT NULL DEFAULT 0,
+    tac VARCHAR(10) NOT NULL DEFAULT '',
+    plmn VARCHAR(6) NOT NULL DEFAULT '',
+    ecgi VARCHAR(20) NOT NULL DEFAULT '',
+    FOREIGN KEY (signalid) REFERENCES signal (id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_signal_channel_signalid ON signal_channel (signalid);
@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS rollup_watermark (
+    id INTEGER PRIMARY KEY NOT NULL CHECK (id = 1),
+    snapshotid INT NOT NULL
+);
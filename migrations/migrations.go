@@ -0,0 +1,335 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed *.sql
+var sqliteFiles embed.FS
+
+//go:embed postgres/*.sql
+var postgresFiles embed.FS
+
+// ErrOutOfDate is returned by Check when the database schema does not match the embedded migrations
+var ErrOutOfDate = errors.New("database schema is out of date")
+
+// dialect holds the migrations and schema_migrations statements of one database engine
+type dialect struct {
+	files       fs.FS
+	createTable string
+	// tableExists counts the schema_migrations tables, so reading the applied migrations never creates one
+	tableExists string
+	insert      string
+	delete      string
+}
+
+var sqlite = dialect{
+	files: sqliteFiles,
+	createTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    applied_at TIMESTAMP NOT NULL
+)`,
+	tableExists: "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'",
+	insert:      "INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+	delete:      "DELETE FROM schema_migrations WHERE version = ?",
+}
+
+var postgres = dialect{
+	files: mustSub(postgresFiles, "postgres"),
+	createTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL
+)`,
+	tableExists: "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = 'schema_migrations'",
+	insert:      "INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)",
+	delete:      "DELETE FROM schema_migrations WHERE version = $1",
+}
+
+// Migration is a numbered schema change loaded from <version>_<name>.up.sql and <version>_<name>.down.sql
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status describes whether a migration has been applied to the database
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator applies the embedded migrations to a database
+type Migrator struct {
+	db         *sql.DB
+	dialect    dialect
+	migrations []Migration
+}
+
+// New creates a Migrator for the embedded SQLite migrations
+func New(db *sql.DB) (*Migrator, error) {
+	return newMigrator(db, sqlite)
+}
+
+// NewPostgres creates a Migrator for the embedded PostgreSQL migrations
+func NewPostgres(db *sql.DB) (*Migrator, error) {
+	return newMigrator(db, postgres)
+}
+
+func newMigrator(db *sql.DB, d dialect) (*Migrator, error) {
+	migrations, err := load(d.files)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		dialect:    d,
+		migrations: migrations,
+	}, nil
+}
+
+// Load reads the embedded SQLite migrations ordered by version
+func Load() ([]Migration, error) {
+	return load(sqlite.files)
+}
+
+// load reads the migrations in files ordered by version
+func load(files fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(files, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(names))
+	for _, name := range names {
+		base := strings.TrimSuffix(name, ".up.sql")
+
+		versionStr, title, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name: %s", name)
+		}
+
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", name, err)
+		}
+
+		up, err := fs.ReadFile(files, name)
+		if err != nil {
+			return nil, err
+		}
+
+		down, err := fs.ReadFile(files, base+".down.sql")
+		if err != nil {
+			return nil, fmt.Errorf("missing down migration for %s: %w", name, err)
+		}
+
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    title,
+			Up:      string(up),
+			Down:    string(down),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d", migrations[i].Version)
+		}
+	}
+
+	return migrations, nil
+}
+
+// Status lists every known migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Version]
+		statuses = append(statuses, Status{
+			Migration: migration,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+	}
+
+	return statuses, nil
+}
+
+// Up applies every pending migration in order and returns the migrations applied
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	err := m.createTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		err := m.apply(ctx, migration.Up, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, m.dialect.insert, migration.Version, migration.Name, time.Now())
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("error applying migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Down reverts the n most recently applied migrations and returns the migrations reverted
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < n; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		err := m.apply(ctx, migration.Down, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, m.dialect.delete, migration.Version)
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("error reverting migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Check returns ErrOutOfDate unless exactly the embedded migrations have been applied
+func (m *Migrator) Check(ctx context.Context) error {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, strconv.Itoa(migration.Version))
+		}
+		delete(applied, migration.Version)
+	}
+
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: pending migrations %s", ErrOutOfDate, strings.Join(pending, ", "))
+	}
+
+	if len(applied) > 0 {
+		return fmt.Errorf("%w: database has migrations unknown to this build", ErrOutOfDate)
+	}
+
+	return nil
+}
+
+// apply runs a migration script and records the change in a single transaction
+func (m *Migrator) apply(ctx context.Context, script string, record func(*sql.Tx) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, script)
+	if err != nil {
+		return err
+	}
+
+	err = record(tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// createTable creates the schema_migrations table if it does not exist yet
+func (m *Migrator) createTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, m.dialect.createTable)
+	if err != nil {
+		return fmt.Errorf("error creating schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+// applied returns the applied migration versions and when they were applied, none if the
+// schema_migrations table does not exist yet
+func (m *Migrator) applied(ctx context.Context) (map[int]time.Time, error) {
+	var tables int
+	err := m.db.QueryRowContext(ctx, m.dialect.tableExists).Scan(&tables)
+	if err != nil {
+		return nil, fmt.Errorf("error looking up schema_migrations table: %w", err)
+	}
+	if tables == 0 {
+		return map[int]time.Time{}, nil
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("error reading schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// mustSub returns the subdirectory of an embedded file system, which only fails if dir is not a valid path
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
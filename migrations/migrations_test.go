@@ -0,0 +1,159 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupMigrator(t *testing.T) (*Migrator, *sql.DB) {
+	sqlDB, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator, err := New(sqlDB)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	return migrator, sqlDB
+}
+
+func tableExists(t *testing.T, sqlDB *sql.DB, name string) bool {
+	var count int
+	err := sqlDB.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&count)
+	if err != nil {
+		t.Fatalf("Failed to query sqlite_master: %v", err)
+	}
+	return count == 1
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("Expected embedded migrations")
+	}
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			t.Errorf("Expected migration %d to have version %d, got %d", i, i+1, migration.Version)
+		}
+		if migration.Up == "" || migration.Down == "" {
+			t.Errorf("Expected migration %d to have up and down scripts", migration.Version)
+		}
+	}
+}
+
+func TestLoadPostgres(t *testing.T) {
+	migrator, err := NewPostgres(nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(migrator.migrations) == 0 {
+		t.Fatal("Expected embedded PostgreSQL migrations")
+	}
+	if migrator.migrations[0].Version != 1 || migrator.migrations[0].Name != "init" {
+		t.Errorf("Expected 0001_init first, got %04d_%s", migrator.migrations[0].Version, migrator.migrations[0].Name)
+	}
+}
+
+func TestUpDown(t *testing.T) {
+	ctx := context.Background()
+	migrator, sqlDB := setupMigrator(t)
+
+	t.Run("Check Fails Before Up", func(t *testing.T) {
+		err := migrator.Check(ctx)
+		if !errors.Is(err, ErrOutOfDate) {
+			t.Errorf("Expected ErrOutOfDate, got %v", err)
+		}
+		if tableExists(t, sqlDB, "schema_migrations") {
+			t.Error("Expected the check not to create the schema_migrations table")
+		}
+	})
+
+	t.Run("Up", func(t *testing.T) {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if len(applied) != len(migrator.migrations) {
+			t.Errorf("Expected %d migrations applied, got %d", len(migrator.migrations), len(applied))
+		}
+		if err := migrator.Check(ctx); err != nil {
+			t.Errorf("Expected schema to be up to date, got %v", err)
+		}
+		if !tableExists(t, sqlDB, "signal") {
+			t.Error("Expected signal table to exist")
+		}
+	})
+
+	t.Run("Up Is Idempotent", func(t *testing.T) {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if len(applied) != 0 {
+			t.Errorf("Expected no migrations applied, got %d", len(applied))
+		}
+	})
+
+	t.Run("Down", func(t *testing.T) {
+		last := migrator.migrations[len(migrator.migrations)-1]
+		reverted, err := migrator.Down(ctx, 1)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if len(reverted) != 1 || reverted[0].Version != last.Version {
+			t.Fatalf("Expected migration %d reverted, got %+v", last.Version, reverted)
+		}
+
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		for _, status := range statuses {
+			if status.Applied == (status.Version == last.Version) {
+				t.Errorf("Unexpected applied=%t for migration %d", status.Applied, status.Version)
+			}
+		}
+		if !errors.Is(migrator.Check(ctx), ErrOutOfDate) {
+			t.Error("Expected schema to be out of date")
+		}
+	})
+
+	t.Run("Down All", func(t *testing.T) {
+		_, err := migrator.Down(ctx, len(migrator.migrations))
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if tableExists(t, sqlDB, "device") {
+			t.Error("Expected device table to be dropped")
+		}
+	})
+}
+
+func TestCheckUnknownMigration(t *testing.T) {
+	ctx := context.Background()
+	migrator, sqlDB := setupMigrator(t)
+
+	_, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	_, err = sqlDB.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (9999, 'future', CURRENT_TIMESTAMP)")
+	if err != nil {
+		t.Fatalf("Failed to insert migration: %v", err)
+	}
+
+	if !errors.Is(migrator.Check(ctx), ErrOutOfDate) {
+		t.Error("Expected ErrOutOfDate for a migration unknown to this build")
+	}
+}
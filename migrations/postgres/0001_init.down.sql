@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS signal_band;
+
+DROP TABLE IF EXISTS signal;
+
+DROP TABLE IF EXISTS snapshot;
+
+DROP TABLE IF EXISTS device;
@@ -0,0 +1,59 @@
+CREATE TABLE IF NOT EXISTS device (
+    id BIGSERIAL PRIMARY KEY,
+    friendly_name TEXT NOT NULL,
+    hardware_version TEXT NOT NULL,
+    isenabled BOOLEAN NOT NULL,
+    ismesh_supported BOOLEAN NOT NULL,
+    macid TEXT NOT NULL,
+    manufacturer TEXT NOT NULL,
+    manufacturer_oui TEXT NOT NULL,
+    model TEXT NOT NULL,
+    name TEXT NOT NULL,
+    role TEXT NOT NULL,
+    serial TEXT NOT NULL,
+    software_version TEXT NOT NULL,
+    type TEXT NOT NULL,
+    update_state TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_device_serial_software_version ON device (serial, software_version);
+
+CREATE TABLE IF NOT EXISTS snapshot (
+    id BIGSERIAL PRIMARY KEY,
+    deviceid BIGINT NOT NULL REFERENCES device (id),
+    created_at TIMESTAMPTZ NOT NULL,
+    uptime BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS ix_snapshot_created_at ON snapshot (created_at);
+
+CREATE TABLE IF NOT EXISTS signal (
+    id BIGSERIAL PRIMARY KEY,
+    snapshotid BIGINT NOT NULL REFERENCES snapshot (id),
+    antenna_used TEXT NOT NULL,
+    generation TEXT NOT NULL,
+    band TEXT NOT NULL,
+    bars DOUBLE PRECISION NOT NULL,
+    cid BIGINT NOT NULL,
+    enbid BIGINT NOT NULL,
+    gnbid BIGINT NOT NULL,
+    rsrp BIGINT NOT NULL,
+    rsrq BIGINT NOT NULL,
+    rssi BIGINT NOT NULL,
+    sinr BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS ix_signal_snapshotid ON signal (snapshotid);
+
+CREATE INDEX IF NOT EXISTS ix_signal_generation ON signal (generation);
+
+CREATE INDEX IF NOT EXISTS ix_signal_band ON signal (band);
+
+CREATE TABLE IF NOT EXISTS signal_band (
+    id BIGSERIAL PRIMARY KEY,
+    signalid BIGINT NOT NULL REFERENCES signal (id),
+    band TEXT NOT NULL,
+    is_primary BOOLEAN NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS ix_signal_band_signalid ON signal_band (signalid);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS ix_snapshot_label_created_at;
+
+ALTER TABLE snapshot DROP COLUMN label;
+
+ALTER TABLE device DROP COLUMN label;
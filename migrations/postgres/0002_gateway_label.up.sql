@@ -0,0 +1,5 @@
+ALTER TABLE device ADD COLUMN label TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE snapshot ADD COLUMN label TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS ix_snapshot_label_created_at ON snapshot (label, created_at);
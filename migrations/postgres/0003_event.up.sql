@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS event (
+    id BIGSERIAL PRIMARY KEY,
+    label TEXT NOT NULL DEFAULT '',
+    type TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    old_value TEXT NOT NULL DEFAULT '',
+    new_value TEXT NOT NULL DEFAULT '',
+    detail TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS ix_event_created_at ON event (created_at);
+
+CREATE INDEX IF NOT EXISTS ix_event_label_type ON event (label, type);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS ix_cell_label_generation_last_seen;
+
+DROP INDEX IF EXISTS ux_cell;
+
+DROP TABLE IF EXISTS cell;
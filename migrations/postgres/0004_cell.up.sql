@@ -0,0 +1,60 @@
+CREATE TABLE IF NOT EXISTS cell (
+    id BIGSERIAL PRIMARY KEY,
+    label TEXT NOT NULL DEFAULT '',
+    generation TEXT NOT NULL,
+    enbid BIGINT NOT NULL,
+    gnbid BIGINT NOT NULL,
+    cid BIGINT NOT NULL,
+    bands TEXT NOT NULL,
+    first_seen TIMESTAMPTZ NOT NULL,
+    last_seen TIMESTAMPTZ NOT NULL,
+    sample_count BIGINT NOT NULL,
+    rsrp_avg DOUBLE PRECISION NOT NULL,
+    rsrq_avg DOUBLE PRECISION NOT NULL,
+    rssi_avg DOUBLE PRECISION NOT NULL,
+    sinr_avg DOUBLE PRECISION NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_cell ON cell (label, generation, enbid, gnbid, cid);
+
+CREATE INDEX IF NOT EXISTS ix_cell_label_generation_last_seen ON cell (label, generation, last_seen);
+
+INSERT INTO
+    cell (
+        label,
+        generation,
+        enbid,
+        gnbid,
+        cid,
+        bands,
+        first_seen,
+        last_seen,
+        sample_count,
+        rsrp_avg,
+        rsrq_avg,
+        rssi_avg,
+        sinr_avg
+    )
+SELECT
+    snapshot.label,
+    signal.generation,
+    signal.enbid,
+    signal.gnbid,
+    signal.cid,
+    STRING_AGG(DISTINCT signal.band, ','),
+    MIN(snapshot.created_at),
+    MAX(snapshot.created_at),
+    COUNT(*),
+    AVG(signal.rsrp),
+    AVG(signal.rsrq),
+    AVG(signal.rssi),
+    AVG(signal.sinr)
+FROM
+    signal
+    JOIN snapshot ON snapshot.id = signal.snapshotid
+GROUP BY
+    snapshot.label,
+    signal.generation,
+    signal.enbid,
+    signal.gnbid,
+    signal.cid;
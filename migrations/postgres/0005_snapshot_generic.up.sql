@@ -0,0 +1,7 @@
+ALTER TABLE snapshot ADD COLUMN apn TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE snapshot ADD COLUMN has_ipv6 BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE snapshot ADD COLUMN registration TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE snapshot ADD COLUMN roaming BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS client_device (
+    id BIGSERIAL PRIMARY KEY,
+    label TEXT NOT NULL DEFAULT '',
+    mac TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    first_seen TIMESTAMPTZ NOT NULL,
+    last_seen TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_client_device ON client_device (label, mac);
+
+CREATE TABLE IF NOT EXISTS client_observation (
+    id BIGSERIAL PRIMARY KEY,
+    clientdeviceid BIGINT NOT NULL REFERENCES client_device (id),
+    label TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    connection TEXT NOT NULL,
+    connected BOOLEAN NOT NULL,
+    ipv4 TEXT NOT NULL DEFAULT '',
+    ipv6 TEXT NOT NULL DEFAULT '',
+    signal BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS ix_client_observation_created_at ON client_observation (created_at);
+
+CREATE INDEX IF NOT EXISTS ix_client_observation_clientdeviceid ON client_observation (clientdeviceid);
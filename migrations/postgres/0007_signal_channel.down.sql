@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS ux_signal_channel_signalid;
+
+DROP TABLE IF EXISTS signal_channel;
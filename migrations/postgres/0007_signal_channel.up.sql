@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS signal_channel (
+    id BIGSERIAL PRIMARY KEY,
+    signalid BIGINT NOT NULL REFERENCES signal (id),
+    pci BIGINT NOT NULL,
+    arfcn BIGINT NOT NULL,
+    bandwidth TEXT NOT NULL DEFAULT '',
+    cqi BIGINT NOT NULL DEFAULT 0,
+    tac TEXT NOT NULL DEFAULT '',
+    plmn TEXT NOT NULL DEFAULT '',
+    ecgi TEXT NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_signal_channel_signalid ON signal_channel (signalid);
@@ -0,0 +1,105 @@
+package mqtt
+
+import (
+	"local/tmo/api"
+	"strings"
+)
+
+// Topic levels of the signal of each generation
+const (
+	fourG = "4g"
+	fiveG = "5g"
+)
+
+// DiscoveryConfig is the Home Assistant discovery config of one sensor
+type DiscoveryConfig struct {
+	Name              string          `json:"name"`
+	UniqueID          string          `json:"unique_id"`
+	StateTopic        string          `json:"state_topic"`
+	ValueTemplate     string          `json:"value_template"`
+	Unit              string          `json:"unit_of_measurement,omitempty"`
+	DeviceClass       string          `json:"device_class,omitempty"`
+	StateClass        string          `json:"state_class,omitempty"`
+	Icon              string          `json:"icon,omitempty"`
+	AvailabilityTopic string          `json:"availability_topic"`
+	Device            DiscoveryDevice `json:"device"`
+}
+
+// DiscoveryDevice groups the sensors of a gateway under one device in Home Assistant
+type DiscoveryDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer,omitempty"`
+	Model        string   `json:"model,omitempty"`
+	SWVersion    string   `json:"sw_version,omitempty"`
+	HWVersion    string   `json:"hw_version,omitempty"`
+}
+
+// sensor describes one sensor announced for a gateway
+type sensor struct {
+	object      string
+	name        string
+	topic       string
+	field       string
+	unit        string
+	deviceClass string
+	stateClass  string
+	icon        string
+}
+
+// sensors lists the sensors of a gateway: the registration and uptime, then the RSRP, SINR, band and cell ID of each
+// generation
+func sensors() []sensor {
+	list := []sensor{
+		{object: "registration", name: "Registration", topic: "state", field: "registration", icon: "mdi:sim"},
+		{object: "uptime", name: "Uptime", topic: "state", field: "uptime", unit: "s", deviceClass: "duration", stateClass: "total_increasing"},
+	}
+	for _, generation := range []string{fourG, fiveG} {
+		title := strings.ToUpper(generation)
+		list = append(list,
+			sensor{object: generation + "_rsrp", name: title + " RSRP", topic: generation, field: "rsrp", unit: "dBm", deviceClass: "signal_strength", stateClass: "measurement"},
+			sensor{object: generation + "_sinr", name: title + " SINR", topic: generation, field: "sinr", unit: "dB", deviceClass: "signal_strength", stateClass: "measurement"},
+			sensor{object: generation + "_band", name: title + " band", topic: generation, field: "band", icon: "mdi:radio-tower"},
+			sensor{object: generation + "_cell_id", name: title + " cell ID", topic: generation, field: "cell_id", icon: "mdi:identifier"},
+		)
+	}
+	return list
+}
+
+// discovery returns the discovery configs of the sensors of a gateway by topic
+func (p *Publisher) discovery(id string, device api.Device) map[string]DiscoveryConfig {
+	identifier := "tmo_" + id
+	if device.Serial != "" {
+		identifier = "tmo_" + device.Serial
+	}
+	name := "T-Mobile gateway " + id
+	if device.FriendlyName != "" {
+		name = device.FriendlyName
+	}
+	discoveryDevice := DiscoveryDevice{
+		Identifiers:  []string{identifier},
+		Name:         name,
+		Manufacturer: device.Manufacturer,
+		Model:        device.Model,
+		SWVersion:    device.SoftwareVersion,
+		HWVersion:    device.HardwareVersion,
+	}
+
+	configs := make(map[string]DiscoveryConfig)
+	for _, s := range sensors() {
+		topic := p.config.DiscoveryPrefix + "/sensor/tmo_" + id + "/" + s.object + "/config"
+		configs[topic] = DiscoveryConfig{
+			Name:              s.name,
+			UniqueID:          identifier + "_" + s.object,
+			StateTopic:        p.config.TopicPrefix + "/" + id + "/" + s.topic,
+			ValueTemplate:     "{{ value_json." + s.field + " }}",
+			Unit:              s.unit,
+			DeviceClass:       s.deviceClass,
+			StateClass:        s.stateClass,
+			Icon:              s.icon,
+			AvailabilityTopic: p.StatusTopic(),
+			Device:            discoveryDevice,
+		}
+	}
+	return configs
+}
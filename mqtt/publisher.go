@@ -0,0 +1,307 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"local/tmo/api"
+	"log"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+// Defaults of the topic settings
+const (
+	DefaultClientID        = "tmo"
+	DefaultTopicPrefix     = "tmo"
+	DefaultDiscoveryPrefix = "homeassistant"
+)
+
+// Availability payloads of the status topic, offline is also the will published by the broker if the poller goes away
+const (
+	Online  = "online"
+	Offline = "offline"
+)
+
+// qos is the quality of service of every message, at least once
+const qos = 1
+
+// publishTimeout limits how long publishing the messages of a poll may hold up the poller
+const publishTimeout = 5 * time.Second
+
+// schemes are the broker URL schemes the client supports
+var schemes = []string{"tcp", "mqtt", "ssl", "tls", "mqtts", "ws", "wss"}
+
+// Config holds the settings of the publisher, it is enabled when Broker is set
+type Config struct {
+	// Broker is the URL of the broker, such as tcp://localhost:1883
+	Broker   string `yaml:"broker"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	ClientID string `yaml:"client_id"`
+	// TopicPrefix is the first level of every topic published to
+	TopicPrefix string `yaml:"topic_prefix"`
+	// DiscoveryPrefix is the Home Assistant discovery prefix, discovery is disabled if it is empty
+	DiscoveryPrefix string `yaml:"discovery_prefix"`
+}
+
+// Enabled reports whether the publisher has a broker to publish to
+func (c Config) Enabled() bool {
+	return c.Broker != ""
+}
+
+// Validate checks the settings of an enabled publisher, reporting every problem together
+func (c Config) Validate() error {
+	var errs []error
+	u, err := url.Parse(c.Broker)
+	if err != nil || !slices.Contains(schemes, u.Scheme) || u.Host == "" {
+		errs = append(errs, fmt.Errorf("broker must be a URL with a scheme of %s, got %q", strings.Join(schemes, ", "), c.Broker))
+	}
+	if c.ClientID == "" {
+		errs = append(errs, errors.New("client id is required"))
+	}
+	if c.TopicPrefix == "" || strings.ContainsAny(c.TopicPrefix, "+#") {
+		errs = append(errs, errors.New("topic prefix is required and must not hold wildcards"))
+	}
+	if strings.ContainsAny(c.DiscoveryPrefix, "+#") {
+		errs = append(errs, errors.New("discovery prefix must not hold wildcards"))
+	}
+	return errors.Join(errs...)
+}
+
+// Publisher publishes the latest poll of every gateway to retained topics, announcing their sensors to Home Assistant
+type Publisher struct {
+	config Config
+	client paho.Client
+	logger *log.Logger
+
+	mu sync.Mutex
+	// announced holds the topic IDs of the gateways whose sensors have been announced since Home Assistant started
+	announced map[string]bool
+	// skipped is set while polls are not published for the lack of a connection, to log it once
+	skipped bool
+}
+
+// NewPublisher creates a publisher, it connects once started
+func NewPublisher(config Config, logger *log.Logger) *Publisher {
+	p := &Publisher{
+		config:    config,
+		logger:    logger,
+		announced: make(map[string]bool),
+	}
+
+	options := paho.NewClientOptions().
+		AddBroker(config.Broker).
+		SetClientID(config.ClientID).
+		SetUsername(config.Username).
+		SetPassword(config.Password).
+		SetWill(p.StatusTopic(), Offline, qos, true).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(10 * time.Second).
+		SetMaxReconnectInterval(5 * time.Minute).
+		SetOrderMatters(false).
+		SetOnConnectHandler(p.onConnect).
+		SetConnectionLostHandler(func(client paho.Client, err error) {
+			p.logger.Printf("Lost the connection to the MQTT broker, reconnecting: %v", err)
+		})
+	p.client = paho.NewClient(options)
+	return p
+}
+
+// Start connects to the broker in the background, retrying until it succeeds
+func (p *Publisher) Start() {
+	p.client.Connect()
+}
+
+// Close marks the poller offline and disconnects
+func (p *Publisher) Close() {
+	if p.client.IsConnectionOpen() {
+		p.client.Publish(p.StatusTopic(), qos, true, Offline).WaitTimeout(publishTimeout)
+	}
+	p.client.Disconnect(250)
+}
+
+// StatusTopic is the availability topic of the poller
+func (p *Publisher) StatusTopic() string {
+	return p.config.TopicPrefix + "/status"
+}
+
+// onConnect marks the poller online and follows the status of Home Assistant, so the sensors are announced again after
+// it restarts
+func (p *Publisher) onConnect(client paho.Client) {
+	p.logger.Printf("Connected to the MQTT broker %s", p.config.Broker)
+
+	p.mu.Lock()
+	// The broker may have lost the retained discovery messages too
+	clear(p.announced)
+	p.skipped = false
+	p.mu.Unlock()
+
+	token := client.Publish(p.StatusTopic(), qos, true, Online)
+	if token.WaitTimeout(publishTimeout) && token.Error() != nil {
+		p.logger.Printf("Failed to publish the MQTT availability: %v", token.Error())
+	}
+
+	if p.config.DiscoveryPrefix == "" {
+		return
+	}
+	client.Subscribe(p.config.DiscoveryPrefix+"/status", qos, func(client paho.Client, message paho.Message) {
+		if string(message.Payload()) == Online {
+			p.mu.Lock()
+			clear(p.announced)
+			p.mu.Unlock()
+		}
+	})
+}
+
+// ObservePoll publishes the state of a successful poll, announcing the sensors of the gateway first if needed. Failed
+// polls leave the last state in place.
+func (p *Publisher) ObservePoll(label string, gateway api.GatewayResponse, duration time.Duration, err error) {
+	if err != nil {
+		return
+	}
+	if !p.client.IsConnectionOpen() {
+		p.mu.Lock()
+		if !p.skipped {
+			p.logger.Println("Not connected to the MQTT broker, polls are not published until it is back")
+			p.skipped = true
+		}
+		p.mu.Unlock()
+		return
+	}
+
+	id := TopicID(label, gateway.Device.Serial)
+	messages := make(map[string]any)
+	p.mu.Lock()
+	announce := p.config.DiscoveryPrefix != "" && !p.announced[id]
+	p.mu.Unlock()
+	if announce {
+		for topic, config := range p.discovery(id, gateway.Device) {
+			messages[topic] = config
+		}
+	}
+
+	messages[p.stateTopic(id)] = newGatewayState(gateway)
+	messages[p.generationTopic(id, fourG)] = newGenerationState(gateway.Signal.FourG, gateway.Signal.FourG.ENBID)
+	messages[p.generationTopic(id, fiveG)] = newGenerationState(gateway.Signal.FiveG, gateway.Signal.FiveG.GNBID)
+
+	var tokens []paho.Token
+	for topic, message := range messages {
+		payload, err := json.Marshal(message)
+		if err != nil {
+			p.logger.Printf("Failed to encode %s: %v", topic, err)
+			continue
+		}
+		tokens = append(tokens, p.client.Publish(topic, qos, true, payload))
+	}
+
+	deadline := time.Now().Add(publishTimeout)
+	for _, token := range tokens {
+		if !token.WaitTimeout(time.Until(deadline)) {
+			p.logger.Println("Timed out publishing the poll to MQTT")
+			return
+		}
+		if token.Error() != nil {
+			p.logger.Printf("Failed to publish the poll to MQTT: %v", token.Error())
+			return
+		}
+	}
+
+	// The sensors are only announced once the broker has them, a failed announcement is retried on the next poll
+	if announce {
+		p.mu.Lock()
+		p.announced[id] = true
+		p.mu.Unlock()
+	}
+}
+
+// stateTopic holds the registration and uptime of the gateway
+func (p *Publisher) stateTopic(id string) string {
+	return p.config.TopicPrefix + "/" + id + "/state"
+}
+
+// generationTopic holds the signal of one generation of the gateway
+func (p *Publisher) generationTopic(id, generation string) string {
+	return p.config.TopicPrefix + "/" + id + "/" + generation
+}
+
+// TopicID is the topic level of a gateway, its label or its serial if it has none. Characters other than letters, digits,
+// dashes and underscores are replaced, as MQTT gives a meaning to some and Home Assistant refuses the others in
+// discovery topics.
+func TopicID(label, serial string) string {
+	id := label
+	if id == "" {
+		id = serial
+	}
+	if id == "" {
+		return "gateway"
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, id)
+}
+
+// GatewayState is the payload of the state topic of a gateway
+type GatewayState struct {
+	Registration    string `json:"registration"`
+	Roaming         bool   `json:"roaming"`
+	Apn             string `json:"apn"`
+	IPv6            bool   `json:"ipv6"`
+	Uptime          int    `json:"uptime"`
+	Serial          string `json:"serial"`
+	SoftwareVersion string `json:"software_version"`
+}
+
+// newGatewayState takes the state of a gateway from its response
+func newGatewayState(gateway api.GatewayResponse) GatewayState {
+	return GatewayState{
+		Registration:    gateway.Signal.Generic.Registration,
+		Roaming:         gateway.Signal.Generic.Roaming,
+		Apn:             gateway.Signal.Generic.Apn,
+		IPv6:            gateway.Signal.Generic.HasIPv6,
+		Uptime:          gateway.Time.UpTime,
+		Serial:          gateway.Device.Serial,
+		SoftwareVersion: gateway.Device.SoftwareVersion,
+	}
+}
+
+// GenerationState is the payload of the topic of one generation of a gateway. Everything but the bands is null while
+// the gateway reports no band for the generation, so the sensors show as unknown rather than stale.
+type GenerationState struct {
+	Band   *string  `json:"band"`
+	Bands  []string `json:"bands"`
+	CellID *int     `json:"cell_id"`
+	NodeID *int     `json:"node_id"`
+	Rsrp   *int     `json:"rsrp"`
+	Rsrq   *int     `json:"rsrq"`
+	Rssi   *int     `json:"rssi"`
+	Sinr   *int     `json:"sinr"`
+	Bars   *float64 `json:"bars"`
+}
+
+// newGenerationState takes the state of one generation from its signal, with the eNB or gNB ID as the node
+func newGenerationState(stats api.SignalStats, node int) GenerationState {
+	if len(stats.Bands) == 0 {
+		return GenerationState{Bands: []string{}}
+	}
+	return GenerationState{
+		Band:   &stats.Bands[0],
+		Bands:  stats.Bands,
+		CellID: &stats.Cid,
+		NodeID: &node,
+		Rsrp:   &stats.Rsrp,
+		Rsrq:   &stats.Rsrq,
+		Rssi:   &stats.Rssi,
+		Sinr:   &stats.Sinr,
+		Bars:   &stats.Bars,
+	}
+}
@@ -0,0 +1,310 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"local/tmo/api"
+	"log"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+)
+
+// broker is an embedded broker standing in for the live service, recording the last message of every topic
+type broker struct {
+	*mochi.Server
+	address string
+
+	mu       sync.Mutex
+	messages map[string]string
+	// statuses are the payloads published to the availability topic in order
+	statuses []string
+}
+
+func newBroker(t *testing.T) *broker {
+	b := &broker{
+		Server:   mochi.New(&mochi.Options{InlineClient: true, Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}),
+		messages: make(map[string]string),
+	}
+	err := b.AddHook(new(auth.AllowHook), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	listener := listeners.NewTCP(listeners.Config{ID: "tcp", Address: "127.0.0.1:0"})
+	err = b.AddListener(listener)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	b.address = listener.Address()
+
+	err = b.Subscribe("#", 1, func(cl *mochi.Client, sub packets.Subscription, pk packets.Packet) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.messages[pk.TopicName] = string(pk.Payload)
+		if pk.TopicName == "tmo/status" {
+			b.statuses = append(b.statuses, string(pk.Payload))
+		}
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	err = b.Serve()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	t.Cleanup(func() { b.Close() })
+	return b
+}
+
+// message returns the last payload of the topic
+func (b *broker) message(topic string) (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	message, ok := b.messages[topic]
+	return message, ok
+}
+
+// forget clears the recorded messages
+func (b *broker) forget() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	clear(b.messages)
+}
+
+// lastStatus returns the last payload of the availability topic
+func (b *broker) lastStatus() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.statuses) == 0 {
+		return ""
+	}
+	return b.statuses[len(b.statuses)-1]
+}
+
+// waitFor polls the condition until it holds or a few seconds have passed
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	for range 500 {
+		if condition() {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %s", what)
+}
+
+// newTestPublisher connects a publisher to the broker
+func newTestPublisher(t *testing.T, b *broker) *Publisher {
+	p := NewPublisher(Config{
+		Broker: "tcp://" + b.address, ClientID: "tmo-test", TopicPrefix: DefaultTopicPrefix, DiscoveryPrefix: DefaultDiscoveryPrefix,
+	}, log.New(io.Discard, "", 0))
+	p.Start()
+	t.Cleanup(p.Close)
+	waitFor(t, "the poller to be online", func() bool { return b.lastStatus() == Online })
+	return p
+}
+
+// gateway returns a response with 5G only
+func gateway() api.GatewayResponse {
+	return api.GatewayResponse{
+		Device: api.Device{Serial: "ABC123", Manufacturer: "Arcadyan", Model: "KVD21", SoftwareVersion: "1.00.18"},
+		Time:   api.Time{UpTime: 3600},
+		Signal: api.Signal{
+			Generic: api.Generic{Registration: "registered", Apn: "fbb.home", HasIPv6: true},
+			FiveG:   api.SignalStats{Bands: []string{"n41"}, Bars: 4, Cid: 34, GNBID: 567, Rsrp: -90, Rsrq: -11, Rssi: -80, Sinr: 15},
+		},
+	}
+}
+
+func TestPublisher(t *testing.T) {
+	b := newBroker(t)
+	p := newTestPublisher(t, b)
+
+	p.ObservePoll("home/office", gateway(), time.Second, nil)
+
+	state, _ := b.message("tmo/home_office/state")
+	var gatewayState GatewayState
+	if err := json.Unmarshal([]byte(state), &gatewayState); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if gatewayState != (GatewayState{Registration: "registered", Apn: "fbb.home", IPv6: true, Uptime: 3600, Serial: "ABC123", SoftwareVersion: "1.00.18"}) {
+		t.Errorf("Unexpected state: %+v", gatewayState)
+	}
+
+	fiveG, _ := b.message("tmo/home_office/5g")
+	if fiveG != `{"band":"n41","bands":["n41"],"cell_id":34,"node_id":567,"rsrp":-90,"rsrq":-11,"rssi":-80,"sinr":15,"bars":4}` {
+		t.Errorf("Unexpected 5G state: %s", fiveG)
+	}
+	fourG, _ := b.message("tmo/home_office/4g")
+	if fourG != `{"band":null,"bands":[],"cell_id":null,"node_id":null,"rsrp":null,"rsrq":null,"rssi":null,"sinr":null,"bars":null}` {
+		t.Errorf("Expected an unknown 4G state without a band, got %s", fourG)
+	}
+
+	for _, object := range []string{"registration", "uptime", "4g_rsrp", "4g_sinr", "4g_band", "4g_cell_id", "5g_rsrp", "5g_sinr", "5g_band", "5g_cell_id"} {
+		if _, ok := b.message("homeassistant/sensor/tmo_home_office/" + object + "/config"); !ok {
+			t.Errorf("Expected the %s sensor to be announced", object)
+		}
+	}
+	announced, _ := b.message("homeassistant/sensor/tmo_home_office/5g_rsrp/config")
+	var config DiscoveryConfig
+	if err := json.Unmarshal([]byte(announced), &config); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if config.UniqueID != "tmo_ABC123_5g_rsrp" || config.StateTopic != "tmo/home_office/5g" || config.ValueTemplate != "{{ value_json.rsrp }}" ||
+		config.Unit != "dBm" || config.DeviceClass != "signal_strength" || config.AvailabilityTopic != "tmo/status" {
+		t.Errorf("Unexpected discovery config: %+v", config)
+	}
+	if config.Device.Identifiers[0] != "tmo_ABC123" || config.Device.Model != "KVD21" {
+		t.Errorf("Unexpected discovery device: %+v", config.Device)
+	}
+
+	t.Run("Retained", func(t *testing.T) {
+		var retained []string
+		err := b.Subscribe("tmo/home_office/#", 2, func(cl *mochi.Client, sub packets.Subscription, pk packets.Packet) {
+			retained = append(retained, pk.TopicName)
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if len(retained) != 3 {
+			t.Errorf("Expected the state and both generations to be retained, got %v", retained)
+		}
+	})
+
+	t.Run("Announced Once", func(t *testing.T) {
+		b.forget()
+		p.ObservePoll("home/office", gateway(), time.Second, errors.New("connection refused"))
+		p.ObservePoll("home/office", gateway(), time.Second, nil)
+		if _, ok := b.message("tmo/home_office/state"); !ok {
+			t.Error("Expected the state to be published again")
+		}
+		if _, ok := b.message("homeassistant/sensor/tmo_home_office/uptime/config"); ok {
+			t.Error("Expected the sensors not to be announced again")
+		}
+
+		// Home Assistant coming back online has the sensors announced again
+		b.Publish("homeassistant/status", []byte(Online), false, 1)
+		waitFor(t, "the sensors to be forgotten", func() bool {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			return !p.announced["home_office"]
+		})
+		p.ObservePoll("home/office", gateway(), time.Second, nil)
+		if _, ok := b.message("homeassistant/sensor/tmo_home_office/uptime/config"); !ok {
+			t.Error("Expected the sensors to be announced again")
+		}
+	})
+}
+
+// failedToken is the token of a publish the broker refused
+type failedToken struct {
+	err error
+}
+
+func (t failedToken) Wait() bool                     { return true }
+func (t failedToken) WaitTimeout(time.Duration) bool { return true }
+func (t failedToken) Error() error                   { return t.err }
+
+func (t failedToken) Done() <-chan struct{} {
+	done := make(chan struct{})
+	close(done)
+	return done
+}
+
+// refusingClient fails every publish to a discovery topic
+type refusingClient struct {
+	paho.Client
+}
+
+func (c refusingClient) Publish(topic string, qos byte, retained bool, payload any) paho.Token {
+	if strings.HasPrefix(topic, DefaultDiscoveryPrefix+"/") {
+		return failedToken{errors.New("not authorized")}
+	}
+	return c.Client.Publish(topic, qos, retained, payload)
+}
+
+func TestPublisherFailedAnnouncement(t *testing.T) {
+	b := newBroker(t)
+	p := newTestPublisher(t, b)
+
+	client := p.client
+	p.client = refusingClient{client}
+	p.ObservePoll("home/office", gateway(), time.Second, nil)
+	if p.announced["home_office"] {
+		t.Fatal("Expected a failed announcement not to count")
+	}
+
+	// The next poll announces the sensors
+	p.client = client
+	p.ObservePoll("home/office", gateway(), time.Second, nil)
+	if _, ok := b.message("homeassistant/sensor/tmo_home_office/uptime/config"); !ok {
+		t.Error("Expected the sensors to be announced on the next poll")
+	}
+	if !p.announced["home_office"] {
+		t.Error("Expected the sensors to be announced")
+	}
+}
+
+func TestAvailability(t *testing.T) {
+	b := newBroker(t)
+	p := newTestPublisher(t, b)
+
+	// The broker publishes the will when the connection drops without a disconnect
+	client, ok := b.Clients.Get("tmo-test")
+	if !ok {
+		t.Fatal("Expected the publisher to be connected")
+	}
+	client.Stop(errors.New("connection lost"))
+	waitFor(t, "the will", func() bool { return b.lastStatus() == Offline })
+	waitFor(t, "the reconnect", func() bool { return b.lastStatus() == Online })
+
+	p.ObservePoll("", gateway(), time.Second, nil)
+	if _, ok := b.message("tmo/ABC123/state"); !ok {
+		t.Error("Expected the state to be published under the serial")
+	}
+
+	p.Close()
+	if b.lastStatus() != Offline {
+		t.Errorf("Expected the poller to be offline once closed, got %s", b.lastStatus())
+	}
+}
+
+func TestTopicID(t *testing.T) {
+	for _, test := range []struct{ label, serial, expected string }{
+		{"home", "ABC123", "home"},
+		{"", "ABC123", "ABC123"},
+		{"", "", "gateway"},
+		{"lab #2/+x.y", "", "lab__2__x_y"},
+	} {
+		if id := TopicID(test.label, test.serial); id != test.expected {
+			t.Errorf("Expected %q for %q, got %q", test.expected, test.label, id)
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := Config{Broker: "tcp://localhost:1883", ClientID: DefaultClientID, TopicPrefix: DefaultTopicPrefix}
+	if err := valid.Validate(); err != nil || !valid.Enabled() {
+		t.Errorf("Expected a valid enabled config, got %v", err)
+	}
+	if (Config{}).Enabled() {
+		t.Error("Expected an empty config to be disabled")
+	}
+
+	invalid := Config{Broker: "localhost:1883", TopicPrefix: "tmo/#", DiscoveryPrefix: "+"}
+	err := invalid.Validate()
+	for _, message := range []string{"broker", "client id", "topic prefix", "discovery prefix"} {
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("Expected %q to be reported, got %v", message, err)
+		}
+	}
+}
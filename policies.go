@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"local/tmo/db"
+	"local/tmo/policy"
+	"strings"
+	"time"
+)
+
+// policyHistory is how far back the policy firings are restored from, the window of the limits per day
+const policyHistory = 24 * time.Hour
+
+// restorePolicies loads the recent firings of the policies of the gateway from its policy events
+func (p *GatewayPoller) restorePolicies(ctx context.Context) error {
+	now := time.Now()
+	recent, err := p.store.ListEventsBetween(ctx, db.ListEventsBetweenParams{Start: now.Add(-policyHistory), End: now})
+	if err != nil {
+		return err
+	}
+	p.policies.Restore(p.config.Label, recent)
+	return nil
+}
+
+// observePolicies evaluates the policies of the gateway over the outcome of a poll and takes the actions of the rules
+// that fire. A failed action is logged rather than failing the poll.
+func (p *GatewayPoller) observePolicies(ctx context.Context, sample policy.Sample) {
+	if p.policies == nil {
+		return
+	}
+	for _, firing := range p.policies.Observe(sample) {
+		p.act(ctx, firing)
+	}
+}
+
+// act records a rule that fired as a policy event and takes its actions, or only logs them in a dry run
+func (p *GatewayPoller) act(ctx context.Context, firing policy.Firing) {
+	rule := firing.Rule
+
+	err := p.saveEvents(ctx, []db.CreateEventParams{firing.Event(p.config.Label)})
+	if err != nil {
+		p.logger.Printf("Failed to save policy event: %v", err)
+	}
+
+	if firing.DryRun {
+		p.logger.Printf("Policy %s would %s: %s", rule.Name, strings.Join(rule.Actions, ", "), firing.Reason())
+		return
+	}
+
+	if rule.Has(policy.ActionLog) {
+		p.logger.Printf("Policy %s fired: %s", rule.Name, firing.Reason())
+	}
+
+	// The webhook is posted before rebooting, which may take down the only way out to it
+	if rule.Has(policy.ActionWebhook) {
+		err := policy.PostWebhook(ctx, rule.WebhookURL, firing.Webhook(p.config.Label))
+		if err != nil {
+			p.logger.Printf("Policy %s failed to post to its webhook: %v", rule.Name, err)
+		}
+	}
+
+	if rule.Has(policy.ActionReboot) {
+		p.logger.Printf("Policy %s is rebooting the gateway: %s", rule.Name, firing.Reason())
+		err := p.apiClient.Reboot(ctx)
+		if err != nil {
+			p.logger.Printf("Policy %s failed to reboot the gateway: %v", rule.Name, err)
+		}
+	}
+}
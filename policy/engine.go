@@ -0,0 +1,144 @@
+package policy
+
+import (
+	"fmt"
+	"local/tmo/api"
+	"local/tmo/db"
+	"local/tmo/events"
+	"strings"
+	"time"
+)
+
+// day is the window the firings of a rule are counted over for its limit per day
+const day = 24 * time.Hour
+
+// Sample is the outcome of one poll of the gateway, as seen by the poller at the time it was taken
+type Sample struct {
+	At time.Time
+	// Reachable is false when the gateway could not be reached, its response is then empty
+	Reachable bool
+	Gateway   api.GatewayResponse
+}
+
+// Firing is a rule whose condition has held long enough, the engine has already counted it against the cooldown
+// and limit of the rule
+type Firing struct {
+	Rule Rule
+	// Since is when the condition was first seen holding and At the sample it fired on
+	Since time.Time
+	At    time.Time
+	// DryRun is set when the actions are only to be logged
+	DryRun bool
+}
+
+// Reason describes what made the rule fire
+func (f Firing) Reason() string {
+	return fmt.Sprintf("%s for %s", f.Rule.describe(), f.At.Sub(f.Since).Round(time.Second))
+}
+
+// Event returns the policy event recording the firing
+func (f Firing) Event(label string) db.CreateEventParams {
+	detail := fmt.Sprintf("%s: %s", f.Reason(), strings.Join(f.Rule.Actions, ", "))
+	if f.DryRun {
+		detail += " (dry run)"
+	}
+	return db.CreateEventParams{
+		Label:     label,
+		Type:      events.Policy,
+		CreatedAt: f.At,
+		NewValue:  f.Rule.Name,
+		Detail:    detail,
+	}
+}
+
+// Engine evaluates the rules of one gateway over its recent samples
+type Engine struct {
+	rules  []Rule
+	dryRun bool
+	// history holds the samples the longest rule needs, oldest first
+	history []Sample
+	// fired holds when each rule fired in the last day by rule name, oldest first
+	fired map[string][]time.Time
+}
+
+// NewEngine creates an engine for the rules, dryRun makes every rule a dry run
+func NewEngine(rules []Rule, dryRun bool) *Engine {
+	return &Engine{rules: rules, dryRun: dryRun, fired: make(map[string][]time.Time)}
+}
+
+// Restore loads the firings of the rules from the events of the last day, so a restart neither resets their cooldowns
+// nor their limits per day
+func (e *Engine) Restore(label string, recent []db.Event) {
+	for _, event := range recent {
+		if event.Type == events.Policy && event.Label == label {
+			e.fired[event.NewValue] = append(e.fired[event.NewValue], event.CreatedAt)
+		}
+	}
+}
+
+// Observe adds a sample to the history and returns the rules that fire on it. A rule fires once its condition has
+// held for every sample from at least its For duration ago, unless it fired within its cooldown or has reached its
+// limit for the last day.
+func (e *Engine) Observe(sample Sample) []Firing {
+	e.history = append(e.history, sample)
+	e.prune(sample.At)
+
+	var firings []Firing
+	for _, rule := range e.rules {
+		since, ok := e.since(rule)
+		if !ok || sample.At.Sub(since) < rule.For {
+			continue
+		}
+
+		fired := e.fired[rule.Name]
+		if len(fired) > 0 && sample.At.Sub(fired[len(fired)-1]) < rule.cooldown() {
+			continue
+		}
+		if rule.MaxPerDay > 0 && len(fired) >= rule.MaxPerDay {
+			continue
+		}
+
+		e.fired[rule.Name] = append(fired, sample.At)
+		firings = append(firings, Firing{Rule: rule, Since: since, At: sample.At, DryRun: e.dryRun || rule.DryRun})
+	}
+	return firings
+}
+
+// since returns when the condition of the rule started holding without a break up to the latest sample
+func (e *Engine) since(rule Rule) (time.Time, bool) {
+	var since time.Time
+	for i := len(e.history) - 1; i >= 0; i-- {
+		if !rule.matches(e.history[i]) {
+			break
+		}
+		since = e.history[i].At
+	}
+	return since, !since.IsZero()
+}
+
+// prune drops the firings older than a day and the samples older than the longest rule needs. The newest sample
+// before that is kept, so a condition that held from before it is not cut short.
+func (e *Engine) prune(now time.Time) {
+	var longest time.Duration
+	for _, rule := range e.rules {
+		longest = max(longest, rule.For)
+	}
+
+	keep := 0
+	for keep < len(e.history)-1 && !e.history[keep+1].At.After(now.Add(-longest)) {
+		keep++
+	}
+	e.history = e.history[keep:]
+
+	for name, fired := range e.fired {
+		recent := 0
+		for recent < len(fired) && now.Sub(fired[recent]) >= day {
+			recent++
+		}
+		if recent == len(fired) {
+			delete(e.fired, name)
+		} else {
+			e.fired[name] = fired[recent:]
+		}
+	}
+}
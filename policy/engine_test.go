@@ -0,0 +1,260 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"local/tmo/api"
+	"local/tmo/db"
+	"local/tmo/events"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+
+// healthy is a sample of a registered gateway with good 4G and 5G signal
+func healthy(minutes int) Sample {
+	return Sample{
+		At:        start.Add(time.Duration(minutes) * time.Minute),
+		Reachable: true,
+		Gateway: api.GatewayResponse{Signal: api.Signal{
+			FourG:   api.SignalStats{Bands: []string{"b2"}, Rsrp: -95, Sinr: 8},
+			FiveG:   api.SignalStats{Bands: []string{"n41"}, Rsrp: -90, Sinr: 12},
+			Generic: api.Generic{Registration: events.Registered},
+		}},
+	}
+}
+
+// noFiveG is a sample of a gateway that fell back to 4G only
+func noFiveG(minutes int) Sample {
+	sample := healthy(minutes)
+	sample.Gateway.Signal.FiveG = api.SignalStats{}
+	return sample
+}
+
+// lowSINR is a sample of a gateway with its 5G SINR at the given value
+func lowSINR(minutes, sinr int) Sample {
+	sample := healthy(minutes)
+	sample.Gateway.Signal.FiveG.Sinr = sinr
+	return sample
+}
+
+// unreachable is a sample of a gateway that could not be reached
+func unreachable(minutes int) Sample {
+	return Sample{At: start.Add(time.Duration(minutes) * time.Minute)}
+}
+
+// observe feeds the history to a new engine for the rules and returns the minutes of the samples each rule fired on
+func observe(rules []Rule, history []Sample) map[string][]int {
+	engine := NewEngine(rules, false)
+	fired := make(map[string][]int)
+	for _, sample := range history {
+		for _, firing := range engine.Observe(sample) {
+			fired[firing.Rule.Name] = append(fired[firing.Rule.Name], int(firing.At.Sub(start)/time.Minute))
+		}
+	}
+	return fired
+}
+
+func TestEngine(t *testing.T) {
+	noFiveGRule := Rule{Name: "no-5g", Condition: NoFiveG, For: 15 * time.Minute, Actions: []string{ActionReboot}}
+
+	t.Run("Holds For Duration", func(t *testing.T) {
+		fired := observe([]Rule{noFiveGRule}, []Sample{
+			healthy(0), noFiveG(5), noFiveG(10), noFiveG(15), noFiveG(20), noFiveG(25),
+		})
+		if len(fired["no-5g"]) != 1 || fired["no-5g"][0] != 20 {
+			t.Errorf("Expected the rule to fire once at 20 minutes, got %v", fired)
+		}
+	})
+
+	t.Run("Broken Streak", func(t *testing.T) {
+		fired := observe([]Rule{noFiveGRule}, []Sample{
+			noFiveG(0), noFiveG(5), noFiveG(10), healthy(15), noFiveG(20), noFiveG(25), noFiveG(30),
+		})
+		if len(fired) != 0 {
+			t.Errorf("Expected the rule not to fire, got %v", fired)
+		}
+	})
+
+	t.Run("Unreachable Breaks Signal Conditions", func(t *testing.T) {
+		fired := observe([]Rule{noFiveGRule}, []Sample{
+			noFiveG(0), noFiveG(5), unreachable(10), noFiveG(15), noFiveG(20),
+		})
+		if len(fired) != 0 {
+			t.Errorf("Expected the rule not to fire, got %v", fired)
+		}
+	})
+
+	t.Run("Irregular Samples", func(t *testing.T) {
+		// The samples before the window of the rule are pruned, but the condition has held since the first
+		fired := observe([]Rule{noFiveGRule}, []Sample{
+			noFiveG(0), noFiveG(7), noFiveG(14), noFiveG(21),
+		})
+		if len(fired["no-5g"]) != 1 || fired["no-5g"][0] != 21 {
+			t.Errorf("Expected the rule to fire at 21 minutes, got %v", fired)
+		}
+	})
+
+	t.Run("Cooldown", func(t *testing.T) {
+		rule := Rule{Name: "no-5g", Condition: NoFiveG, Cooldown: 30 * time.Minute, Actions: []string{ActionLog}}
+		var history []Sample
+		for minutes := 0; minutes <= 60; minutes += 5 {
+			history = append(history, noFiveG(minutes))
+		}
+		fired := observe([]Rule{rule}, history)
+		if got := fired["no-5g"]; len(got) != 3 || got[0] != 0 || got[1] != 30 || got[2] != 60 {
+			t.Errorf("Expected the rule to fire every 30 minutes, got %v", got)
+		}
+	})
+
+	t.Run("Default Cooldown", func(t *testing.T) {
+		rule := Rule{Name: "no-5g", Condition: NoFiveG, Actions: []string{ActionLog}}
+		fired := observe([]Rule{rule}, []Sample{noFiveG(0), noFiveG(30), noFiveG(59), noFiveG(60)})
+		if got := fired["no-5g"]; len(got) != 2 || got[1] != 60 {
+			t.Errorf("Expected the rule to fire again after an hour, got %v", got)
+		}
+	})
+
+	t.Run("Max Per Day", func(t *testing.T) {
+		rule := Rule{Name: "no-5g", Condition: NoFiveG, Cooldown: time.Hour, MaxPerDay: 3, Actions: []string{ActionReboot}}
+		var history []Sample
+		for hours := 0; hours <= 30; hours++ {
+			history = append(history, noFiveG(hours*60))
+		}
+		fired := observe([]Rule{rule}, history)
+		// The limit is over the last 24 hours, so the rule fires again once the first firing is a day old
+		if got := fired["no-5g"]; len(got) != 6 || got[2] != 120 || got[3] != 24*60 {
+			t.Errorf("Expected 3 firings a day, got %v", got)
+		}
+	})
+
+	t.Run("SINR Below", func(t *testing.T) {
+		rules := []Rule{
+			{Name: "sinr", Condition: SINRBelow, Threshold: 0, For: 30 * time.Minute, Actions: []string{ActionLog}},
+			{Name: "4g-sinr", Condition: SINRBelow, Generation: "4G", Threshold: 0, Actions: []string{ActionLog}},
+		}
+		fired := observe(rules, []Sample{
+			lowSINR(0, -2), lowSINR(10, -5), lowSINR(20, 0), lowSINR(25, -1), lowSINR(40, -3), lowSINR(55, -4),
+		})
+		if got := fired["sinr"]; len(got) != 1 || got[0] != 55 {
+			t.Errorf("Expected the 5G rule to fire at 55 minutes, got %v", got)
+		}
+		if len(fired["4g-sinr"]) != 0 {
+			t.Errorf("Expected the 4G rule not to fire, got %v", fired["4g-sinr"])
+		}
+	})
+
+	t.Run("Registration Lost", func(t *testing.T) {
+		rule := Rule{Name: "registration", Condition: NotRegistered, For: 10 * time.Minute, Actions: []string{ActionLog}}
+		searching := func(minutes int) Sample {
+			sample := noFiveG(minutes)
+			sample.Gateway.Signal.Generic.Registration = "searching"
+			return sample
+		}
+		unknown := healthy(0)
+		unknown.Gateway.Signal.Generic.Registration = ""
+		fired := observe([]Rule{rule}, []Sample{unknown, searching(5), searching(10), searching(15)})
+		if got := fired["registration"]; len(got) != 1 || got[0] != 15 {
+			t.Errorf("Expected the rule to fire at 15 minutes, got %v", got)
+		}
+	})
+
+	t.Run("Unreachable", func(t *testing.T) {
+		rule := Rule{Name: "down", Condition: Unreachable, For: 5 * time.Minute, Actions: []string{ActionWebhook}}
+		fired := observe([]Rule{rule}, []Sample{healthy(0), unreachable(1), unreachable(3), unreachable(6)})
+		if got := fired["down"]; len(got) != 1 || got[0] != 6 {
+			t.Errorf("Expected the rule to fire at 6 minutes, got %v", got)
+		}
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		rule := Rule{Name: "no-5g", Condition: NoFiveG, MaxPerDay: 2, Actions: []string{ActionReboot}}
+		engine := NewEngine([]Rule{rule}, false)
+		engine.Restore("home", []db.Event{
+			{Label: "home", Type: events.Policy, NewValue: "no-5g", CreatedAt: start.Add(-3 * time.Hour)},
+			{Label: "lab", Type: events.Policy, NewValue: "no-5g", CreatedAt: start.Add(-2 * time.Hour)},
+			{Label: "home", Type: events.Registration, NewValue: "no-5g", CreatedAt: start.Add(-time.Hour)},
+			{Label: "home", Type: events.Policy, NewValue: "no-5g", CreatedAt: start.Add(-30 * time.Minute)},
+		})
+
+		if firings := engine.Observe(noFiveG(0)); len(firings) != 0 {
+			t.Errorf("Expected the cooldown to be restored, got %+v", firings)
+		}
+		if firings := engine.Observe(noFiveG(120)); len(firings) != 0 {
+			t.Errorf("Expected the limit per day to be restored, got %+v", firings)
+		}
+		// The first restored firing is a day old by then
+		if firings := engine.Observe(noFiveG(21*60 + 1)); len(firings) != 1 {
+			t.Errorf("Expected the rule to fire, got %+v", firings)
+		}
+	})
+}
+
+func TestFiring(t *testing.T) {
+	rule := Rule{Name: "no-5g", Condition: NoFiveG, For: 15 * time.Minute, Actions: []string{ActionReboot, ActionWebhook}}
+	engine := NewEngine([]Rule{rule}, true)
+	var firings []Firing
+	for _, sample := range []Sample{noFiveG(0), noFiveG(10), noFiveG(20)} {
+		firings = append(firings, engine.Observe(sample)...)
+	}
+	if len(firings) != 1 || !firings[0].DryRun {
+		t.Fatalf("Expected a dry run firing, got %+v", firings)
+	}
+
+	event := firings[0].Event("home")
+	if event.Type != events.Policy || event.NewValue != "no-5g" || event.Detail != "5G missing for 20m0s: reboot, webhook (dry run)" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+
+	var received Webhook
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Content-Type") != "application/json" || json.NewDecoder(r.Body).Decode(&received) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	err := PostWebhook(context.Background(), srv.URL, firings[0].Webhook("home"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if received.Gateway != "home" || received.Rule != "no-5g" || !received.Since.Equal(start) || !received.DryRun {
+		t.Errorf("Unexpected webhook: %+v", received)
+	}
+
+	err = PostWebhook(context.Background(), srv.URL+"/missing", Webhook{})
+	if err == nil {
+		t.Error("Expected an error for a failed webhook")
+	}
+}
+
+func TestRuleValidate(t *testing.T) {
+	valid := Rule{Name: "sinr", Condition: SINRBelow, Threshold: 0, Actions: []string{ActionWebhook}, WebhookURL: "https://example.com/hook"}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	invalid := Rule{Condition: SINRBelow, Generation: "3G", For: -time.Minute, Actions: []string{ActionWebhook, "shutdown"}, WebhookURL: "example.com"}
+	err := invalid.Validate()
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	for _, problem := range []string{"name", "generation", "for", "shutdown", "webhook url"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Expected the error to report the %s, got %q", problem, err)
+		}
+	}
+
+	err = Rule{Name: "empty", Condition: "flapping"}.Validate()
+	if err == nil || !strings.Contains(err.Error(), "condition") || !strings.Contains(err.Error(), "action") {
+		t.Errorf("Expected condition and action errors, got %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"local/tmo/api"
+	"local/tmo/events"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// Conditions a rule can watch for
+const (
+	// NoFiveG holds while the gateway reports no 5G band
+	NoFiveG = "no_5g"
+	// SINRBelow and RSRPBelow hold while the signal of the rule generation is below its threshold
+	SINRBelow = "sinr_below"
+	RSRPBelow = "rsrp_below"
+	// NotRegistered holds while the gateway reports a registration state other than registered
+	NotRegistered = "not_registered"
+	// Unreachable holds while the gateway cannot be reached at all
+	Unreachable = "unreachable"
+)
+
+// Actions a rule can take when it fires
+const (
+	// ActionReboot reboots the gateway
+	ActionReboot = "reboot"
+	// ActionWebhook posts the firing to the webhook URL of the rule
+	ActionWebhook = "webhook"
+	// ActionLog writes the firing to the poller log
+	ActionLog = "log"
+)
+
+// DefaultCooldown is how long a rule waits after firing before it may fire again, when it sets no cooldown
+const DefaultCooldown = time.Hour
+
+// Rule takes its actions once its condition has held for a while, at most once per cooldown and a number of times a day
+type Rule struct {
+	// Name identifies the rule in logs and on its policy events
+	Name      string `yaml:"name"`
+	Condition string `yaml:"condition"`
+	// Generation is the signal the SINR and RSRP conditions watch, 4G or 5G, 5G if unset
+	Generation string `yaml:"generation"`
+	// Threshold is the SINR or RSRP the signal must stay below
+	Threshold int `yaml:"threshold"`
+	// For is how long the condition must hold before the rule fires, it fires on the first sample if 0
+	For     time.Duration `yaml:"for"`
+	Actions []string      `yaml:"actions"`
+	// WebhookURL is where the webhook action posts to
+	WebhookURL string `yaml:"webhook_url"`
+	// Cooldown is how long the rule waits after firing before it may fire again, DefaultCooldown if 0
+	Cooldown time.Duration `yaml:"cooldown"`
+	// MaxPerDay limits how many times the rule may fire in 24 hours, 0 does not limit it
+	MaxPerDay int `yaml:"max_per_day"`
+	// DryRun records and logs the firings of the rule without rebooting or posting to the webhook
+	DryRun bool `yaml:"dry_run"`
+}
+
+// Validate checks the rule can be evaluated and its actions taken, reporting every problem together
+func (r Rule) Validate() error {
+	var errs []error
+
+	if r.Name == "" {
+		errs = append(errs, errors.New("name is required"))
+	}
+	switch r.Condition {
+	case NoFiveG, NotRegistered, Unreachable:
+	case SINRBelow, RSRPBelow:
+		if r.Generation != "" && r.Generation != "4G" && r.Generation != "5G" {
+			errs = append(errs, fmt.Errorf("generation must be 4G or 5G, got %q", r.Generation))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("condition must be %s, %s, %s, %s or %s, got %q", NoFiveG, SINRBelow, RSRPBelow, NotRegistered, Unreachable, r.Condition))
+	}
+	if r.For < 0 {
+		errs = append(errs, errors.New("for must not be negative"))
+	}
+	if r.Cooldown < 0 {
+		errs = append(errs, errors.New("cooldown must not be negative"))
+	}
+	if r.MaxPerDay < 0 {
+		errs = append(errs, errors.New("max per day must not be negative"))
+	}
+
+	if len(r.Actions) == 0 {
+		errs = append(errs, errors.New("at least one action is required"))
+	}
+	for _, action := range r.Actions {
+		if action != ActionReboot && action != ActionWebhook && action != ActionLog {
+			errs = append(errs, fmt.Errorf("action must be %s, %s or %s, got %q", ActionReboot, ActionWebhook, ActionLog, action))
+		}
+	}
+	if r.Has(ActionWebhook) {
+		u, err := url.Parse(r.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("webhook url must be an http or https URL, got %q", r.WebhookURL))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Has reports whether the rule takes the action
+func (r Rule) Has(action string) bool {
+	return slices.Contains(r.Actions, action)
+}
+
+// cooldown is how long the rule waits after firing before it may fire again
+func (r Rule) cooldown() time.Duration {
+	if r.Cooldown == 0 {
+		return DefaultCooldown
+	}
+	return r.Cooldown
+}
+
+// matches reports whether the condition of the rule holds for a sample. A signal condition does not hold when the
+// gateway is unreachable or reports no band for the generation, as there is no signal to compare.
+func (r Rule) matches(sample Sample) bool {
+	if r.Condition == Unreachable {
+		return !sample.Reachable
+	}
+	if !sample.Reachable {
+		return false
+	}
+
+	switch r.Condition {
+	case NoFiveG:
+		return len(sample.Gateway.Signal.FiveG.Bands) == 0
+	case NotRegistered:
+		registration := sample.Gateway.Signal.Generic.Registration
+		return registration != "" && registration != events.Registered
+	case SINRBelow, RSRPBelow:
+		stats := r.stats(sample.Gateway.Signal)
+		if len(stats.Bands) == 0 {
+			return false
+		}
+		if r.Condition == SINRBelow {
+			return stats.Sinr < r.Threshold
+		}
+		return stats.Rsrp < r.Threshold
+	default:
+		return false
+	}
+}
+
+// stats returns the signal of the generation the rule watches
+func (r Rule) stats(signal api.Signal) api.SignalStats {
+	if r.Generation == "4G" {
+		return signal.FourG
+	}
+	return signal.FiveG
+}
+
+// describe names the condition of the rule for logs and events
+func (r Rule) describe() string {
+	generation := r.Generation
+	if generation == "" {
+		generation = "5G"
+	}
+
+	switch r.Condition {
+	case NoFiveG:
+		return "5G missing"
+	case SINRBelow:
+		return fmt.Sprintf("%s SINR below %d", generation, r.Threshold)
+	case RSRPBelow:
+		return fmt.Sprintf("%s RSRP below %d", generation, r.Threshold)
+	case NotRegistered:
+		return "not registered"
+	case Unreachable:
+		return "unreachable"
+	default:
+		return r.Condition
+	}
+}
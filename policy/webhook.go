@@ -0,0 +1,68 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// webhookTimeout limits how long posting a firing to a webhook may take
+const webhookTimeout = 10 * time.Second
+
+// Webhook is the JSON body posted to the webhook of a rule when it fires
+type Webhook struct {
+	Gateway   string    `json:"gateway"`
+	Rule      string    `json:"rule"`
+	Condition string    `json:"condition"`
+	Reason    string    `json:"reason"`
+	Since     time.Time `json:"since"`
+	At        time.Time `json:"at"`
+	Actions   []string  `json:"actions"`
+	DryRun    bool      `json:"dry_run"`
+}
+
+// Webhook returns the body posted to the webhook of the rule for the firing
+func (f Firing) Webhook(label string) Webhook {
+	return Webhook{
+		Gateway:   label,
+		Rule:      f.Rule.Name,
+		Condition: f.Rule.Condition,
+		Reason:    f.Reason(),
+		Since:     f.Since,
+		At:        f.At,
+		Actions:   f.Rule.Actions,
+		DryRun:    f.DryRun,
+	}
+}
+
+// PostWebhook posts the body to the URL as JSON, failing unless the response has a 2xx status
+func PostWebhook(ctx context.Context, url string, body Webhook) error {
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("error encoding webhook: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("error creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error posting webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -1,8 +1,17 @@
 -- name: CreateSnapshot :one
 INSERT INTO
-    snapshot (deviceid, created_at, uptime)
+    snapshot (
+        deviceid,
+        created_at,
+        uptime,
+        label,
+        apn,
+        has_ipv6,
+        registration,
+        roaming
+    )
 VALUES
-    (?, ?, ?) RETURNING *;
+    (?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
 
 -- name: CreateSignal :one
 INSERT INTO
@@ -48,7 +57,628 @@ INSERT INTO
         serial,
         software_version,
         type,
-        update_state
+        update_state,
+        label
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: UpdateDeviceLabel :exec
+UPDATE device
+SET
+    label = ?
+WHERE
+    id = ?;
+
+-- name: CreateSignalBand :one
+INSERT INTO
+    signal_band (signalid, band, is_primary)
+VALUES
+    (?, ?, ?) RETURNING *;
+
+-- name: CreateSignalChannel :one
+INSERT INTO
+    signal_channel (
+        signalid,
+        pci,
+        arfcn,
+        bandwidth,
+        cqi,
+        tac,
+        plmn,
+        ecgi
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: ListSignalBands :many
+SELECT
+    *
+FROM
+    signal_band
+WHERE
+    signalid = ?
+ORDER BY
+    id;
+
+
+-- name: ListSignalsBetween :many
+SELECT
+    signal.*,
+    snapshot.created_at,
+    snapshot.label
+FROM
+    signal
+    JOIN snapshot ON snapshot.id = signal.snapshotid
+WHERE
+    snapshot.created_at >= sqlc.arg(start)
+    AND snapshot.created_at < sqlc.arg(end)
+ORDER BY
+    snapshot.created_at;
+
+-- name: GetFirstSnapshotTime :one
+SELECT
+    created_at
+FROM
+    snapshot
+ORDER BY
+    created_at
+LIMIT
+    1;
+
+-- name: CreateHourlyRollup :exec
+INSERT INTO
+    signal_rollup_hour (
+        period_start,
+        label,
+        generation,
+        band,
+        cid,
+        enbid,
+        gnbid,
+        sample_count,
+        rsrp_min,
+        rsrp_max,
+        rsrp_avg,
+        rsrp_p5,
+        rsrp_p95,
+        rsrq_min,
+        rsrq_max,
+        rsrq_avg,
+        rsrq_p5,
+        rsrq_p95,
+        rssi_min,
+        rssi_max,
+        rssi_avg,
+        rssi_p5,
+        rssi_p95,
+        sinr_min,
+        sinr_max,
+        sinr_avg,
+        sinr_p5,
+        sinr_p95
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: DeleteHourlyRollupsBetween :exec
+DELETE FROM signal_rollup_hour
+WHERE
+    period_start >= sqlc.arg(start)
+    AND period_start < sqlc.arg(end);
+
+-- name: GetLatestHourlyRollupTime :one
+SELECT
+    period_start
+FROM
+    signal_rollup_hour
+ORDER BY
+    period_start DESC
+LIMIT
+    1;
+
+-- name: CreateDailyRollup :exec
+INSERT INTO
+    signal_rollup_day (
+        period_start,
+        label,
+        generation,
+        band,
+        cid,
+        enbid,
+        gnbid,
+        sample_count,
+        rsrp_min,
+        rsrp_max,
+        rsrp_avg,
+        rsrp_p5,
+        rsrp_p95,
+        rsrq_min,
+        rsrq_max,
+        rsrq_avg,
+        rsrq_p5,
+        rsrq_p95,
+        rssi_min,
+        rssi_max,
+        rssi_avg,
+        rssi_p5,
+        rssi_p95,
+        sinr_min,
+        sinr_max,
+        sinr_avg,
+        sinr_p5,
+        sinr_p95
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: DeleteDailyRollupsBetween :exec
+DELETE FROM signal_rollup_day
+WHERE
+    period_start >= sqlc.arg(start)
+    AND period_start < sqlc.arg(end);
+
+-- name: GetLatestDailyRollupTime :one
+SELECT
+    period_start
+FROM
+    signal_rollup_day
+ORDER BY
+    period_start DESC
+LIMIT
+    1;
+
+-- name: GetRollupWatermark :one
+SELECT
+    snapshotid
+FROM
+    rollup_watermark
+WHERE
+    id = 1;
+
+-- name: SetRollupWatermark :exec
+INSERT INTO
+    rollup_watermark (id, snapshotid)
+VALUES
+    (1, ?) ON CONFLICT (id) DO
+UPDATE
+SET
+    snapshotid = excluded.snapshotid;
+
+-- name: GetLatestSnapshotID :one
+SELECT
+    id
+FROM
+    snapshot
+ORDER BY
+    id DESC
+LIMIT
+    1;
+
+-- name: ListSnapshotTimesAfter :many
+SELECT
+    created_at
+FROM
+    snapshot
+WHERE
+    id > sqlc.arg(after)
+    AND id <= sqlc.arg(last)
+ORDER BY
+    created_at;
+
+-- name: DeleteSignalChannelsBefore :execrows
+DELETE FROM signal_channel
+WHERE
+    signalid IN (
+        SELECT
+            signal.id
+        FROM
+            signal
+            JOIN snapshot ON snapshot.id = signal.snapshotid
+        WHERE
+            snapshot.created_at < ?
+    );
+
+-- name: DeleteSignalBandsBefore :execrows
+DELETE FROM signal_band
+WHERE
+    signalid IN (
+        SELECT
+            signal.id
+        FROM
+            signal
+            JOIN snapshot ON snapshot.id = signal.snapshotid
+        WHERE
+            snapshot.created_at < ?
+    );
+
+-- name: DeleteSignalsBefore :execrows
+DELETE FROM signal
+WHERE
+    snapshotid IN (
+        SELECT
+            id
+        FROM
+            snapshot
+        WHERE
+            created_at < ?
+    );
+
+-- name: DeleteSnapshotsBefore :execrows
+DELETE FROM snapshot
+WHERE
+    created_at < ?;
+
+-- name: CountSnapshotsBetween :many
+SELECT
+    snapshot.label,
+    COUNT(*) AS total,
+    COUNT(five_g.id) AS with_5g
+FROM
+    snapshot
+    LEFT JOIN signal five_g ON five_g.snapshotid = snapshot.id
+    AND five_g.generation = '5G'
+WHERE
+    snapshot.created_at >= sqlc.arg(start)
+    AND snapshot.created_at < sqlc.arg(end)
+GROUP BY
+    snapshot.label
+ORDER BY
+    snapshot.label;
+
+-- name: CountRegistrationsBetween :many
+SELECT
+    label,
+    registration,
+    roaming,
+    apn,
+    has_ipv6,
+    COUNT(*) AS total
+FROM
+    snapshot
+WHERE
+    created_at >= sqlc.arg(start)
+    AND created_at < sqlc.arg(end)
+GROUP BY
+    label,
+    registration,
+    roaming,
+    apn,
+    has_ipv6
+ORDER BY
+    label,
+    registration,
+    roaming,
+    apn,
+    has_ipv6;
+
+-- name: CreateEvent :one
+INSERT INTO
+    event (label, type, created_at, old_value, new_value, detail)
+VALUES
+    (?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: ListLastEvents :many
+SELECT
+    *
+FROM
+    event
+WHERE
+    id IN (
+        SELECT
+            MAX(id)
+        FROM
+            event
+        WHERE
+            event.label = ?
+        GROUP BY
+            event.type
+    )
+ORDER BY
+    id;
+
+-- name: ListEventsBetween :many
+SELECT
+    *
+FROM
+    event
+WHERE
+    created_at >= sqlc.arg(start)
+    AND created_at < sqlc.arg(end)
+ORDER BY
+    created_at,
+    id;
+
+-- name: GetLatestSnapshot :one
+SELECT
+    snapshot.created_at,
+    snapshot.uptime,
+    device.serial,
+    device.software_version
+FROM
+    snapshot
+    JOIN device ON device.id = snapshot.deviceid
+WHERE
+    snapshot.label = ?
+ORDER BY
+    snapshot.created_at DESC,
+    snapshot.id DESC
+LIMIT
+    1;
+
+-- name: GetCell :one
+SELECT
+    *
+FROM
+    cell
+WHERE
+    label = ?
+    AND generation = ?
+    AND enbid = ?
+    AND gnbid = ?
+    AND cid = ?;
+
+-- name: CreateCell :one
+INSERT INTO
+    cell (
+        label,
+        generation,
+        enbid,
+        gnbid,
+        cid,
+        bands,
+        first_seen,
+        last_seen,
+        sample_count,
+        rsrp_avg,
+        rsrq_avg,
+        rssi_avg,
+        sinr_avg
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: UpdateCell :one
+UPDATE cell
+SET
+    bands = ?,
+    last_seen = ?,
+    sample_count = ?,
+    rsrp_avg = ?,
+    rsrq_avg = ?,
+    rssi_avg = ?,
+    sinr_avg = ?
+WHERE
+    id = ? RETURNING *;
+
+-- name: GetServingCell :one
+SELECT
+    *
+FROM
+    cell
+WHERE
+    label = ?
+    AND generation = ?
+ORDER BY
+    last_seen DESC,
+    id DESC
+LIMIT
+    1;
+
+-- name: ListCells :many
+SELECT
+    *
+FROM
+    cell
+ORDER BY
+    label,
+    generation,
+    sample_count DESC,
+    id;
+
+-- name: GetClientDevice :one
+SELECT
+    *
+FROM
+    client_device
+WHERE
+    label = ?
+    AND mac = ?;
+
+-- name: CreateClientDevice :one
+INSERT INTO
+    client_device (label, mac, name, first_seen, last_seen)
+VALUES
+    (?, ?, ?, ?, ?) RETURNING *;
+
+-- name: UpdateClientDevice :one
+UPDATE client_device
+SET
+    name = ?,
+    last_seen = ?
+WHERE
+    id = ? RETURNING *;
+
+-- name: CreateClientObservation :one
+INSERT INTO
+    client_observation (
+        clientdeviceid,
+        label,
+        created_at,
+        connection,
+        connected,
+        ipv4,
+        ipv6,
+        signal
     )
 VALUES
-    (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+    (?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: ListClientDevices :many
+SELECT
+    *
+FROM
+    client_device
+ORDER BY
+    label,
+    last_seen DESC,
+    id;
+
+-- name: CountClientsBetween :many
+SELECT
+    label,
+    created_at,
+    COUNT(
+        CASE
+            WHEN connected THEN 1
+        END
+    ) AS connected,
+    COUNT(
+        CASE
+            WHEN connected
+            AND connection != 'ethernet' THEN 1
+        END
+    ) AS wifi
+FROM
+    client_observation
+WHERE
+    created_at >= sqlc.arg(start)
+    AND created_at < sqlc.arg(end)
+GROUP BY
+    label,
+    created_at
+ORDER BY
+    created_at,
+    label;
+
+-- name: ListChannelsBetween :many
+SELECT
+    snapshot.label,
+    signal.generation,
+    signal.band,
+    signal_channel.arfcn,
+    signal_channel.pci,
+    signal_channel.bandwidth,
+    COUNT(*) AS samples,
+    CAST(AVG(signal.rsrp) AS REAL) AS rsrp_avg,
+    CAST(AVG(signal.sinr) AS REAL) AS sinr_avg
+FROM
+    signal_channel
+    JOIN signal ON signal.id = signal_channel.signalid
+    JOIN snapshot ON snapshot.id = signal.snapshotid
+WHERE
+    snapshot.created_at >= sqlc.arg(start)
+    AND snapshot.created_at < sqlc.arg(end)
+GROUP BY
+    snapshot.label,
+    signal.generation,
+    signal.band,
+    signal_channel.arfcn,
+    signal_channel.pci,
+    signal_channel.bandwidth
+ORDER BY
+    signal.generation,
+    signal.band,
+    signal_channel.arfcn,
+    signal_channel.pci,
+    snapshot.label;
+
+-- name: ListDevicesPage :many
+SELECT
+    *
+FROM
+    device
+WHERE
+    id > sqlc.arg(after)
+ORDER BY
+    id
+LIMIT
+    sqlc.arg(page_size);
+
+-- name: ListSnapshotsPage :many
+SELECT
+    *
+FROM
+    snapshot
+WHERE
+    created_at >= sqlc.arg(start)
+    AND created_at < sqlc.arg(end)
+    AND (
+        label = sqlc.narg(label)
+        OR sqlc.narg(label) IS NULL
+    )
+    AND id > sqlc.arg(after)
+ORDER BY
+    id
+LIMIT
+    sqlc.arg(page_size);
+
+-- name: ListSignalsPage :many
+SELECT
+    signal.*,
+    snapshot.created_at,
+    snapshot.label
+FROM
+    signal
+    JOIN snapshot ON snapshot.id = signal.snapshotid
+WHERE
+    snapshot.created_at >= sqlc.arg(start)
+    AND snapshot.created_at < sqlc.arg(end)
+    AND (
+        snapshot.label = sqlc.narg(label)
+        OR sqlc.narg(label) IS NULL
+    )
+    AND (
+        signal.generation = sqlc.narg(generation)
+        OR sqlc.narg(generation) IS NULL
+    )
+    AND (
+        signal.band = sqlc.narg(band)
+        OR sqlc.narg(band) IS NULL
+    )
+    AND (
+        signal.cid = sqlc.narg(cid)
+        OR sqlc.narg(cid) IS NULL
+    )
+    AND signal.id > sqlc.arg(after)
+ORDER BY
+    signal.id
+LIMIT
+    sqlc.arg(page_size);
+
+-- name: SummarizeSignalsBetween :many
+SELECT
+    snapshot.label,
+    signal.generation,
+    signal.band,
+    COUNT(*) AS samples,
+    CAST(MIN(signal.rsrp) AS INTEGER) AS rsrp_min,
+    CAST(AVG(signal.rsrp) AS REAL) AS rsrp_avg,
+    CAST(MAX(signal.rsrp) AS INTEGER) AS rsrp_max,
+    CAST(MIN(signal.rsrq) AS INTEGER) AS rsrq_min,
+    CAST(AVG(signal.rsrq) AS REAL) AS rsrq_avg,
+    CAST(MAX(signal.rsrq) AS INTEGER) AS rsrq_max,
+    CAST(MIN(signal.rssi) AS INTEGER) AS rssi_min,
+    CAST(AVG(signal.rssi) AS REAL) AS rssi_avg,
+    CAST(MAX(signal.rssi) AS INTEGER) AS rssi_max,
+    CAST(MIN(signal.sinr) AS INTEGER) AS sinr_min,
+    CAST(AVG(signal.sinr) AS REAL) AS sinr_avg,
+    CAST(MAX(signal.sinr) AS INTEGER) AS sinr_max
+FROM
+    signal
+    JOIN snapshot ON snapshot.id = signal.snapshotid
+WHERE
+    snapshot.created_at >= sqlc.arg(start)
+    AND snapshot.created_at < sqlc.arg(end)
+    AND (
+        snapshot.label = sqlc.narg(label)
+        OR sqlc.narg(label) IS NULL
+    )
+GROUP BY
+    snapshot.label,
+    signal.generation,
+    signal.band
+ORDER BY
+    snapshot.label,
+    signal.generation,
+    signal.band;
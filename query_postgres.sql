@@ -0,0 +1,490 @@
+-- name: CreateSnapshot :one
+INSERT INTO
+    snapshot (
+        deviceid,
+        created_at,
+        uptime,
+        label,
+        apn,
+        has_ipv6,
+        registration,
+        roaming
+    )
+VALUES
+    ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *;
+
+-- name: CreateSignal :one
+INSERT INTO
+    signal (
+        snapshotid,
+        generation,
+        antenna_used,
+        band,
+        bars,
+        cid,
+        enbid,
+        gnbid,
+        rsrp,
+        rsrq,
+        rssi,
+        sinr
+    )
+VALUES
+    ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING *;
+
+-- name: GetDevice :one
+SELECT
+    *
+FROM
+    device
+WHERE
+    serial = $1
+    AND software_version = $2;
+
+-- name: CreateDevice :one
+INSERT INTO
+    device (
+        friendly_name,
+        hardware_version,
+        isenabled,
+        ismesh_supported,
+        macid,
+        manufacturer,
+        manufacturer_oui,
+        model,
+        name,
+        role,
+        serial,
+        software_version,
+        type,
+        update_state,
+        label
+    )
+VALUES
+    ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) RETURNING *;
+
+-- name: UpdateDeviceLabel :exec
+UPDATE device
+SET
+    label = $1
+WHERE
+    id = $2;
+
+-- name: CreateSignalBand :one
+INSERT INTO
+    signal_band (signalid, band, is_primary)
+VALUES
+    ($1, $2, $3) RETURNING *;
+
+-- name: CreateSignalChannel :one
+INSERT INTO
+    signal_channel (
+        signalid,
+        pci,
+        arfcn,
+        bandwidth,
+        cqi,
+        tac,
+        plmn,
+        ecgi
+    )
+VALUES
+    ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *;
+
+-- name: ListSignalBands :many
+SELECT
+    *
+FROM
+    signal_band
+WHERE
+    signalid = $1
+ORDER BY
+    id;
+
+-- name: ListSignalsBetween :many
+SELECT
+    signal.*,
+    snapshot.created_at,
+    snapshot.label
+FROM
+    signal
+    JOIN snapshot ON snapshot.id = signal.snapshotid
+WHERE
+    snapshot.created_at >= sqlc.arg(start)
+    AND snapshot.created_at < sqlc.arg('end')
+ORDER BY
+    snapshot.created_at;
+
+-- name: CountSnapshotsBetween :many
+SELECT
+    snapshot.label,
+    COUNT(*) AS total,
+    COUNT(five_g.id) AS with_5g
+FROM
+    snapshot
+    LEFT JOIN signal five_g ON five_g.snapshotid = snapshot.id
+    AND five_g.generation = '5G'
+WHERE
+    snapshot.created_at >= sqlc.arg(start)
+    AND snapshot.created_at < sqlc.arg('end')
+GROUP BY
+    snapshot.label
+ORDER BY
+    snapshot.label;
+
+-- name: CountRegistrationsBetween :many
+SELECT
+    label,
+    registration,
+    roaming,
+    apn,
+    has_ipv6,
+    COUNT(*) AS total
+FROM
+    snapshot
+WHERE
+    created_at >= sqlc.arg(start)
+    AND created_at < sqlc.arg('end')
+GROUP BY
+    label,
+    registration,
+    roaming,
+    apn,
+    has_ipv6
+ORDER BY
+    label,
+    registration,
+    roaming,
+    apn,
+    has_ipv6;
+
+-- name: CreateEvent :one
+INSERT INTO
+    event (label, type, created_at, old_value, new_value, detail)
+VALUES
+    ($1, $2, $3, $4, $5, $6) RETURNING *;
+
+-- name: ListLastEvents :many
+SELECT
+    *
+FROM
+    event
+WHERE
+    id IN (
+        SELECT
+            MAX(id)
+        FROM
+            event
+        WHERE
+            event.label = $1
+        GROUP BY
+            event.type
+    )
+ORDER BY
+    id;
+
+-- name: ListEventsBetween :many
+SELECT
+    *
+FROM
+    event
+WHERE
+    created_at >= sqlc.arg(start)
+    AND created_at < sqlc.arg('end')
+ORDER BY
+    created_at,
+    id;
+
+-- name: GetLatestSnapshot :one
+SELECT
+    snapshot.created_at,
+    snapshot.uptime,
+    device.serial,
+    device.software_version
+FROM
+    snapshot
+    JOIN device ON device.id = snapshot.deviceid
+WHERE
+    snapshot.label = $1
+ORDER BY
+    snapshot.created_at DESC,
+    snapshot.id DESC
+LIMIT
+    1;
+
+-- name: GetCell :one
+SELECT
+    *
+FROM
+    cell
+WHERE
+    label = $1
+    AND generation = $2
+    AND enbid = $3
+    AND gnbid = $4
+    AND cid = $5;
+
+-- name: CreateCell :one
+INSERT INTO
+    cell (
+        label,
+        generation,
+        enbid,
+        gnbid,
+        cid,
+        bands,
+        first_seen,
+        last_seen,
+        sample_count,
+        rsrp_avg,
+        rsrq_avg,
+        rssi_avg,
+        sinr_avg
+    )
+VALUES
+    ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING *;
+
+-- name: UpdateCell :one
+UPDATE cell
+SET
+    bands = $1,
+    last_seen = $2,
+    sample_count = $3,
+    rsrp_avg = $4,
+    rsrq_avg = $5,
+    rssi_avg = $6,
+    sinr_avg = $7
+WHERE
+    id = $8 RETURNING *;
+
+-- name: GetServingCell :one
+SELECT
+    *
+FROM
+    cell
+WHERE
+    label = $1
+    AND generation = $2
+ORDER BY
+    last_seen DESC,
+    id DESC
+LIMIT
+    1;
+
+-- name: ListCells :many
+SELECT
+    *
+FROM
+    cell
+ORDER BY
+    label,
+    generation,
+    sample_count DESC,
+    id;
+
+-- name: GetClientDevice :one
+SELECT
+    *
+FROM
+    client_device
+WHERE
+    label = $1
+    AND mac = $2;
+
+-- name: CreateClientDevice :one
+INSERT INTO
+    client_device (label, mac, name, first_seen, last_seen)
+VALUES
+    ($1, $2, $3, $4, $5) RETURNING *;
+
+-- name: UpdateClientDevice :one
+UPDATE client_device
+SET
+    name = $1,
+    last_seen = $2
+WHERE
+    id = $3 RETURNING *;
+
+-- name: CreateClientObservation :one
+INSERT INTO
+    client_observation (
+        clientdeviceid,
+        label,
+        created_at,
+        connection,
+        connected,
+        ipv4,
+        ipv6,
+        signal
+    )
+VALUES
+    ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *;
+
+-- name: ListClientDevices :many
+SELECT
+    *
+FROM
+    client_device
+ORDER BY
+    label,
+    last_seen DESC,
+    id;
+
+-- name: CountClientsBetween :many
+SELECT
+    label,
+    created_at,
+    COUNT(
+        CASE
+            WHEN connected THEN 1
+        END
+    ) AS connected,
+    COUNT(
+        CASE
+            WHEN connected
+            AND connection != 'ethernet' THEN 1
+        END
+    ) AS wifi
+FROM
+    client_observation
+WHERE
+    created_at >= sqlc.arg(start)
+    AND created_at < sqlc.arg('end')
+GROUP BY
+    label,
+    created_at
+ORDER BY
+    created_at,
+    label;
+
+-- name: ListChannelsBetween :many
+SELECT
+    snapshot.label,
+    signal.generation,
+    signal.band,
+    signal_channel.arfcn,
+    signal_channel.pci,
+    signal_channel.bandwidth,
+    COUNT(*) AS samples,
+    AVG(signal.rsrp)::DOUBLE PRECISION AS rsrp_avg,
+    AVG(signal.sinr)::DOUBLE PRECISION AS sinr_avg
+FROM
+    signal_channel
+    JOIN signal ON signal.id = signal_channel.signalid
+    JOIN snapshot ON snapshot.id = signal.snapshotid
+WHERE
+    snapshot.created_at >= sqlc.arg(start)
+    AND snapshot.created_at < sqlc.arg('end')
+GROUP BY
+    snapshot.label,
+    signal.generation,
+    signal.band,
+    signal_channel.arfcn,
+    signal_channel.pci,
+    signal_channel.bandwidth
+ORDER BY
+    signal.generation,
+    signal.band,
+    signal_channel.arfcn,
+    signal_channel.pci,
+    snapshot.label;
+
+-- name: ListDevicesPage :many
+SELECT
+    *
+FROM
+    device
+WHERE
+    id > sqlc.arg(after)
+ORDER BY
+    id
+LIMIT
+    sqlc.arg(page_size)::BIGINT;
+
+-- name: ListSnapshotsPage :many
+SELECT
+    *
+FROM
+    snapshot
+WHERE
+    created_at >= sqlc.arg(start)
+    AND created_at < sqlc.arg('end')
+    AND (
+        label = sqlc.narg(label)::TEXT
+        OR sqlc.narg(label)::TEXT IS NULL
+    )
+    AND id > sqlc.arg(after)
+ORDER BY
+    id
+LIMIT
+    sqlc.arg(page_size)::BIGINT;
+
+-- name: ListSignalsPage :many
+SELECT
+    signal.*,
+    snapshot.created_at,
+    snapshot.label
+FROM
+    signal
+    JOIN snapshot ON snapshot.id = signal.snapshotid
+WHERE
+    snapshot.created_at >= sqlc.arg(start)
+    AND snapshot.created_at < sqlc.arg('end')
+    AND (
+        snapshot.label = sqlc.narg(label)::TEXT
+        OR sqlc.narg(label)::TEXT IS NULL
+    )
+    AND (
+        signal.generation = sqlc.narg(generation)::TEXT
+        OR sqlc.narg(generation)::TEXT IS NULL
+    )
+    AND (
+        signal.band = sqlc.narg(band)::TEXT
+        OR sqlc.narg(band)::TEXT IS NULL
+    )
+    AND (
+        signal.cid = sqlc.narg(cid)::BIGINT
+        OR sqlc.narg(cid)::BIGINT IS NULL
+    )
+    AND signal.id > sqlc.arg(after)
+ORDER BY
+    signal.id
+LIMIT
+    sqlc.arg(page_size)::BIGINT;
+
+-- name: SummarizeSignalsBetween :many
+SELECT
+    snapshot.label,
+    signal.generation,
+    signal.band,
+    COUNT(*) AS samples,
+    MIN(signal.rsrp)::BIGINT AS rsrp_min,
+    AVG(signal.rsrp)::DOUBLE PRECISION AS rsrp_avg,
+    MAX(signal.rsrp)::BIGINT AS rsrp_max,
+    MIN(signal.rsrq)::BIGINT AS rsrq_min,
+    AVG(signal.rsrq)::DOUBLE PRECISION AS rsrq_avg,
+    MAX(signal.rsrq)::BIGINT AS rsrq_max,
+    MIN(signal.rssi)::BIGINT AS rssi_min,
+    AVG(signal.rssi)::DOUBLE PRECISION AS rssi_avg,
+    MAX(signal.rssi)::BIGINT AS rssi_max,
+    MIN(signal.sinr)::BIGINT AS sinr_min,
+    AVG(signal.sinr)::DOUBLE PRECISION AS sinr_avg,
+    MAX(signal.sinr)::BIGINT AS sinr_max
+FROM
+    signal
+    JOIN snapshot ON snapshot.id = signal.snapshotid
+WHERE
+    snapshot.created_at >= sqlc.arg(start)
+    AND snapshot.created_at < sqlc.arg('end')
+    AND (
+        snapshot.label = sqlc.narg(label)::TEXT
+        OR sqlc.narg(label)::TEXT IS NULL
+    )
+GROUP BY
+    snapshot.label,
+    signal.generation,
+    signal.band
+ORDER BY
+    snapshot.label,
+    signal.generation,
+    signal.band;
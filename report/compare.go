@@ -0,0 +1,82 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"local/tmo/db"
+	"text/tabwriter"
+	"time"
+)
+
+// Comparison is the signal of a gateway in the windows before and after an event, such as a reboot
+type Comparison struct {
+	Event  db.Event
+	Before *Report
+	After  *Report
+}
+
+// Compare builds reports of the gateway of an event for the window before it and the window after it
+func Compare(ctx context.Context, source Source, event db.Event, window time.Duration) (Comparison, error) {
+	before, err := Build(ctx, source, event.Label, event.CreatedAt.Add(-window), event.CreatedAt)
+	if err != nil {
+		return Comparison{}, err
+	}
+
+	after, err := Build(ctx, source, event.Label, event.CreatedAt, event.CreatedAt.Add(window))
+	if err != nil {
+		return Comparison{}, err
+	}
+
+	return Comparison{Event: event, Before: before, After: after}, nil
+}
+
+// generation returns the summary of a generation, or false if it has no samples
+func (r *Report) generation(generation string) (MetricSummary, bool) {
+	for _, s := range r.Generations {
+		if s.Generation == generation {
+			return s, true
+		}
+	}
+	return MetricSummary{}, false
+}
+
+// WriteComparisons prints the average signal of each generation before and after each event as a plain text table
+func WriteComparisons(w io.Writer, comparisons []Comparison) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "TIME\tGATEWAY\tEVENT\tFROM\tTO\tGEN\tSAMPLES\tRSRP\tRSRQ\tSINR")
+	for _, c := range comparisons {
+		label := c.Event.Label
+		if label == "" {
+			label = "-"
+		}
+		for _, generation := range Generations {
+			before, okBefore := c.Before.generation(generation)
+			after, okAfter := c.After.generation(generation)
+			if !okBefore && !okAfter {
+				continue
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%d -> %d\t%s\t%s\t%s\n",
+				c.Event.CreatedAt.Format(timeFormat), label, c.Event.Type, c.Event.OldValue, c.Event.NewValue, generation,
+				before.Rsrp.Count, after.Rsrp.Count,
+				change(before.Rsrp.Avg, after.Rsrp.Avg, okBefore, okAfter),
+				change(before.Rsrq.Avg, after.Rsrq.Avg, okBefore, okAfter),
+				change(before.Sinr.Avg, after.Sinr.Avg, okBefore, okAfter))
+		}
+	}
+
+	return tw.Flush()
+}
+
+// change formats the averages before and after an event and the difference between them
+func change(before, after float64, okBefore, okAfter bool) string {
+	switch {
+	case !okBefore:
+		return fmt.Sprintf("- -> %.1f", after)
+	case !okAfter:
+		return fmt.Sprintf("%.1f -> -", before)
+	default:
+		return fmt.Sprintf("%.1f -> %.1f (%+.1f)", before, after, after-before)
+	}
+}
@@ -0,0 +1,68 @@
+package report
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"local/tmo/storage"
+	"strings"
+	"time"
+)
+
+// Flags are the database, gateway and time range flags of the commands reading the collected history
+type Flags struct {
+	Backend string
+	DSN     string
+	// Label limits the command to one gateway, every gateway if empty
+	Label string
+	from  string
+	to    string
+	days  int
+}
+
+// RegisterFlags defines the -backend, -dsn, -label, -from and -to flags on the flag set. The subject describes
+// what the range covers in the usage, and the range starts the given number of days before -to unless -from is set.
+func RegisterFlags(flags *flag.FlagSet, subject string, days int) *Flags {
+	f := &Flags{days: days}
+	flags.StringVar(&f.Backend, "backend", storage.BackendSQLite, "usage: -backend=sqlite|postgres")
+	flags.StringVar(&f.DSN, "dsn", "", "usage: -dsn=<database dsn>")
+	flags.StringVar(&f.Label, "label", "", "usage: -label=<gateway label, default all gateways>")
+	flags.StringVar(&f.from, "from", "", fmt.Sprintf("start of %s, YYYY-MM-DD or RFC3339 (default %d days before -to)", subject, days))
+	flags.StringVar(&f.to, "to", "", fmt.Sprintf("end of %s, YYYY-MM-DD or RFC3339 (default now)", subject))
+	return f
+}
+
+// Range checks the DSN is set and returns the start and end of the time range, ending now if -to is not set
+func (f *Flags) Range() (time.Time, time.Time, error) {
+	if strings.TrimSpace(f.DSN) == "" {
+		return time.Time{}, time.Time{}, errors.New("DSN is required")
+	}
+
+	end := time.Now()
+	if f.to != "" {
+		t, err := ParseTime(f.to)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid -to: %w", err)
+		}
+		end = t
+	}
+
+	start := end.AddDate(0, 0, -f.days)
+	if f.from != "" {
+		t, err := ParseTime(f.from)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid -from: %w", err)
+		}
+		start = t
+	}
+
+	return start, end, nil
+}
+
+// ParseTime accepts a local date or an RFC3339 timestamp
+func ParseTime(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
@@ -0,0 +1,369 @@
+package report
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"local/tmo/db"
+	"local/tmo/stats"
+	"slices"
+	"time"
+)
+
+// Generations are the signal generations in the order they are reported
+var Generations = []string{"4G", "5G"}
+
+// MetricSummary summarizes the signal metrics of one generation, or one band of a generation
+type MetricSummary struct {
+	Generation string
+	Band       string
+	Rsrp       stats.Summary
+	Rsrq       stats.Summary
+	Rssi       stats.Summary
+	Sinr       stats.Summary
+}
+
+// CellTime is the time spent connected to one cell
+type CellTime struct {
+	Generation string
+	// NodeID is the eNB ID for 4G cells and the gNB ID for 5G cells
+	NodeID   int64
+	Cid      int64
+	Bands    []string
+	Samples  int
+	Duration time.Duration
+}
+
+// ChannelSummary is the average signal on one radio channel, telling apart the channels and PCIs of a band
+type ChannelSummary struct {
+	Generation string
+	Band       string
+	Arfcn      int64
+	Pci        int64
+	Bandwidth  string
+	Samples    int64
+	Rsrp       float64
+	Sinr       float64
+}
+
+// HourSummary is the average signal quality of one generation during one hour of the day
+type HourSummary struct {
+	Samples int
+	Rsrp    float64
+	Sinr    float64
+}
+
+// ClientHour is the average number of clients connected to the gateway during one hour of the day
+type ClientHour struct {
+	Samples   int
+	Connected float64
+	Wifi      float64
+}
+
+// RegistrationTime is the number of snapshots taken in one registration state, roaming state, APN and IPv6 setting
+type RegistrationTime struct {
+	Registration string
+	Roaming      bool
+	Apn          string
+	HasIPv6      bool
+	Snapshots    int64
+}
+
+// Source is the signal history a report is built from, such as a storage.Store or db.Queries
+type Source interface {
+	CountSnapshotsBetween(ctx context.Context, arg db.CountSnapshotsBetweenParams) ([]db.CountSnapshotsBetweenRow, error)
+	CountRegistrationsBetween(ctx context.Context, arg db.CountRegistrationsBetweenParams) ([]db.CountRegistrationsBetweenRow, error)
+	CountClientsBetween(ctx context.Context, arg db.CountClientsBetweenParams) ([]db.CountClientsBetweenRow, error)
+	ListChannelsBetween(ctx context.Context, arg db.ListChannelsBetweenParams) ([]db.ListChannelsBetweenRow, error)
+	ListSignalsBetween(ctx context.Context, arg db.ListSignalsBetweenParams) ([]db.ListSignalsBetweenRow, error)
+}
+
+// Report summarizes the signal history between From and To
+type Report struct {
+	// Label is the gateway the report covers, all gateways if empty
+	Label string
+	From  time.Time
+	To    time.Time
+
+	Snapshots      int64
+	FiveGSnapshots int64
+	// Registrations counts the snapshots in each registration state, most frequent first
+	Registrations []RegistrationTime
+
+	Generations []MetricSummary
+	Bands       []MetricSummary
+	Cells       []CellTime
+	// Channels only covers the signals stored with cell telemetry
+	Channels []ChannelSummary
+
+	// Heatmap holds the hour of day summaries of each generation, indexed by hour in the report location
+	Heatmap map[string]*[24]HourSummary
+	// Clients holds the hour of day client counts, to compare congestion with the Wi-Fi load
+	Clients [24]ClientHour
+}
+
+// Build reads the signal history of the gateway with the given label between from and to,
+// or of every gateway if label is empty. Hours of the day are bucketed in the location of from.
+func Build(ctx context.Context, source Source, label string, from, to time.Time) (*Report, error) {
+	counts, err := source.CountSnapshotsBetween(ctx, db.CountSnapshotsBetweenParams{Start: from, End: to})
+	if err != nil {
+		return nil, fmt.Errorf("error counting snapshots: %w", err)
+	}
+
+	registrations, err := source.CountRegistrationsBetween(ctx, db.CountRegistrationsBetweenParams{Start: from, End: to})
+	if err != nil {
+		return nil, fmt.Errorf("error counting registrations: %w", err)
+	}
+
+	signals, err := source.ListSignalsBetween(ctx, db.ListSignalsBetweenParams{Start: from, End: to})
+	if err != nil {
+		return nil, fmt.Errorf("error listing signals: %w", err)
+	}
+
+	clients, err := source.CountClientsBetween(ctx, db.CountClientsBetweenParams{Start: from, End: to})
+	if err != nil {
+		return nil, fmt.Errorf("error counting clients: %w", err)
+	}
+
+	channels, err := source.ListChannelsBetween(ctx, db.ListChannelsBetweenParams{Start: from, End: to})
+	if err != nil {
+		return nil, fmt.Errorf("error listing channels: %w", err)
+	}
+
+	r := &Report{
+		Label:   label,
+		From:    from,
+		To:      to,
+		Heatmap: make(map[string]*[24]HourSummary),
+	}
+
+	for _, count := range counts {
+		if label == "" || count.Label == label {
+			r.Snapshots += count.Total
+			r.FiveGSnapshots += count.With5g
+		}
+	}
+
+	r.Registrations = registrationTimes(registrations, label)
+
+	if label != "" {
+		signals = slices.DeleteFunc(signals, func(s db.ListSignalsBetweenRow) bool { return s.Label != label })
+	}
+
+	r.Generations = summarize(signals, func(s db.ListSignalsBetweenRow) string { return "" })
+	r.Bands = summarize(signals, func(s db.ListSignalsBetweenRow) string { return s.Band })
+	r.Cells = cellTimes(signals)
+	r.Channels = channelSummaries(channels, label)
+	r.buildHeatmap(signals, from.Location())
+	r.buildClientHours(clients, label, from.Location())
+
+	return r, nil
+}
+
+// FourGOnlySnapshots is the number of snapshots without a 5G signal
+func (r *Report) FourGOnlySnapshots() int64 {
+	return r.Snapshots - r.FiveGSnapshots
+}
+
+// registrationTimes sums the snapshot counts of the gateway with the given label, or of every gateway if label is empty
+func registrationTimes(counts []db.CountRegistrationsBetweenRow, label string) []RegistrationTime {
+	var times []RegistrationTime
+	for _, count := range counts {
+		if label != "" && count.Label != label {
+			continue
+		}
+		i := slices.IndexFunc(times, func(t RegistrationTime) bool {
+			return t.Registration == count.Registration && t.Roaming == count.Roaming && t.Apn == count.Apn && t.HasIPv6 == count.HasIpv6
+		})
+		if i < 0 {
+			times = append(times, RegistrationTime{Registration: count.Registration, Roaming: count.Roaming, Apn: count.Apn, HasIPv6: count.HasIpv6})
+			i = len(times) - 1
+		}
+		times[i].Snapshots += count.Total
+	}
+
+	slices.SortStableFunc(times, func(a, b RegistrationTime) int {
+		return cmp.Compare(b.Snapshots, a.Snapshots)
+	})
+	return times
+}
+
+// channelSummaries merges the channel summaries of the gateway with the given label, or of every gateway if label is empty
+func channelSummaries(rows []db.ListChannelsBetweenRow, label string) []ChannelSummary {
+	var summaries []ChannelSummary
+	for _, row := range rows {
+		if label != "" && row.Label != label {
+			continue
+		}
+		i := slices.IndexFunc(summaries, func(s ChannelSummary) bool {
+			return s.Generation == row.Generation && s.Band == row.Band && s.Arfcn == row.Arfcn && s.Pci == row.Pci && s.Bandwidth == row.Bandwidth
+		})
+		if i < 0 {
+			summaries = append(summaries, ChannelSummary{Generation: row.Generation, Band: row.Band, Arfcn: row.Arfcn, Pci: row.Pci, Bandwidth: row.Bandwidth})
+			i = len(summaries) - 1
+		}
+		s := &summaries[i]
+		n, m := float64(s.Samples), float64(row.Samples)
+		s.Rsrp = (s.Rsrp*n + row.RsrpAvg*m) / (n + m)
+		s.Sinr = (s.Sinr*n + row.SinrAvg*m) / (n + m)
+		s.Samples += row.Samples
+	}
+	return summaries
+}
+
+// summarize groups signals by generation and the band returned by bandOf
+func summarize(signals []db.ListSignalsBetweenRow, bandOf func(db.ListSignalsBetweenRow) string) []MetricSummary {
+	type key struct {
+		generation string
+		band       string
+	}
+	type values struct {
+		rsrp, rsrq, rssi, sinr []int64
+	}
+
+	groups := make(map[key]*values)
+	for _, signal := range signals {
+		k := key{signal.Generation, bandOf(signal)}
+		group, ok := groups[k]
+		if !ok {
+			group = &values{}
+			groups[k] = group
+		}
+		group.rsrp = append(group.rsrp, signal.Rsrp)
+		group.rsrq = append(group.rsrq, signal.Rsrq)
+		group.rssi = append(group.rssi, signal.Rssi)
+		group.sinr = append(group.sinr, signal.Sinr)
+	}
+
+	summaries := make([]MetricSummary, 0, len(groups))
+	for k, group := range groups {
+		summaries = append(summaries, MetricSummary{
+			Generation: k.generation,
+			Band:       k.band,
+			Rsrp:       stats.Summarize(group.rsrp),
+			Rsrq:       stats.Summarize(group.rsrq),
+			Rssi:       stats.Summarize(group.rssi),
+			Sinr:       stats.Summarize(group.sinr),
+		})
+	}
+
+	slices.SortFunc(summaries, func(a, b MetricSummary) int {
+		return cmp.Or(cmp.Compare(a.Generation, b.Generation), cmp.Compare(a.Band, b.Band))
+	})
+
+	return summaries
+}
+
+// cellTimes attributes the time between consecutive samples of a gateway and generation to the cell of the first sample.
+// Gaps longer than twice the typical polling interval are treated as outages and only count one interval.
+func cellTimes(signals []db.ListSignalsBetweenRow) []CellTime {
+	type key struct {
+		generation string
+		nodeID     int64
+		cid        int64
+	}
+	type sequence struct {
+		label      string
+		generation string
+	}
+
+	var order []sequence
+	sequences := make(map[sequence][]db.ListSignalsBetweenRow)
+	for _, signal := range signals {
+		k := sequence{signal.Label, signal.Generation}
+		if _, ok := sequences[k]; !ok {
+			order = append(order, k)
+		}
+		sequences[k] = append(sequences[k], signal)
+	}
+
+	cells := make(map[key]*CellTime)
+	for _, seq := range order {
+		generation, samples := seq.generation, sequences[seq]
+
+		interval := typicalInterval(samples)
+		for i, signal := range samples {
+			duration := interval
+			if i+1 < len(samples) {
+				if gap := samples[i+1].CreatedAt.Sub(signal.CreatedAt); gap <= 2*interval {
+					duration = gap
+				}
+			}
+
+			nodeID := signal.Enbid
+			if generation == "5G" {
+				nodeID = signal.Gnbid
+			}
+
+			k := key{generation, nodeID, signal.Cid}
+			c, ok := cells[k]
+			if !ok {
+				c = &CellTime{Generation: generation, NodeID: nodeID, Cid: signal.Cid}
+				cells[k] = c
+			}
+			if !slices.Contains(c.Bands, signal.Band) {
+				c.Bands = append(c.Bands, signal.Band)
+			}
+			c.Samples++
+			c.Duration += duration
+		}
+	}
+
+	result := make([]CellTime, 0, len(cells))
+	for _, c := range cells {
+		result = append(result, *c)
+	}
+
+	slices.SortFunc(result, func(a, b CellTime) int {
+		return cmp.Or(cmp.Compare(a.Generation, b.Generation), cmp.Compare(b.Duration, a.Duration),
+			cmp.Compare(a.NodeID, b.NodeID), cmp.Compare(a.Cid, b.Cid))
+	})
+
+	return result
+}
+
+// typicalInterval is the median time between consecutive samples, signals must be ordered by time
+func typicalInterval(samples []db.ListSignalsBetweenRow) time.Duration {
+	if len(samples) < 2 {
+		return 0
+	}
+
+	gaps := make([]int64, 0, len(samples)-1)
+	for i := 1; i < len(samples); i++ {
+		gaps = append(gaps, int64(samples[i].CreatedAt.Sub(samples[i-1].CreatedAt)))
+	}
+
+	return time.Duration(stats.Summarize(gaps).P50)
+}
+
+// buildHeatmap averages RSRP and SINR per generation and hour of the day
+func (r *Report) buildHeatmap(signals []db.ListSignalsBetweenRow, loc *time.Location) {
+	for _, signal := range signals {
+		hours, ok := r.Heatmap[signal.Generation]
+		if !ok {
+			hours = &[24]HourSummary{}
+			r.Heatmap[signal.Generation] = hours
+		}
+
+		hour := &hours[signal.CreatedAt.In(loc).Hour()]
+		n := float64(hour.Samples)
+		hour.Rsrp = (hour.Rsrp*n + float64(signal.Rsrp)) / (n + 1)
+		hour.Sinr = (hour.Sinr*n + float64(signal.Sinr)) / (n + 1)
+		hour.Samples++
+	}
+}
+
+// buildClientHours averages the connected and Wi-Fi client counts of each clients poll per hour of the day
+func (r *Report) buildClientHours(counts []db.CountClientsBetweenRow, label string, loc *time.Location) {
+	for _, count := range counts {
+		if label != "" && count.Label != label {
+			continue
+		}
+
+		hour := &r.Clients[count.CreatedAt.In(loc).Hour()]
+		n := float64(hour.Samples)
+		hour.Connected = (hour.Connected*n + float64(count.Connected)) / (n + 1)
+		hour.Wifi = (hour.Wifi*n + float64(count.Wifi)) / (n + 1)
+		hour.Samples++
+	}
+}
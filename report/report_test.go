@@ -0,0 +1,299 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"flag"
+	"local/tmo/db"
+	"local/tmo/migrations"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupDatabase(t *testing.T) *db.Queries {
+	sqlDB, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator, err := migrations.New(sqlDB)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	return db.New(sqlDB)
+}
+
+func TestBuild(t *testing.T) {
+	ctx := context.Background()
+	queries := setupDatabase(t)
+
+	device, err := queries.CreateDevice(ctx, db.CreateDeviceParams{Serial: "ABC123", SoftwareVersion: "1.0.0"})
+	if err != nil {
+		t.Fatalf("Failed to create device: %v", err)
+	}
+
+	start := time.Date(2025, 4, 1, 10, 0, 0, 0, time.UTC)
+	for i := range 6 {
+		// Roaming while 5G is missing from the last snapshot
+		snapshot, err := queries.CreateSnapshot(ctx, db.CreateSnapshotParams{
+			Deviceid:     device.ID,
+			CreatedAt:    start.Add(time.Duration(i) * 10 * time.Minute),
+			Label:        "home",
+			Apn:          "FBB.HOME",
+			Registration: "registered",
+			Roaming:      i == 5,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create snapshot: %v", err)
+		}
+
+		_, err = queries.CreateSignal(ctx, db.CreateSignalParams{
+			Snapshotid: snapshot.ID, Generation: "4G", Band: "b66", Cid: 1, Enbid: 100, Rsrp: -100, Sinr: 5,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create signal: %v", err)
+		}
+
+		// 5G is missing from the last snapshot, and hands off to a second cell after the first three
+		if i == 5 {
+			continue
+		}
+		cid, band := int64(2), "n41"
+		if i >= 3 {
+			cid, band = 3, "n71"
+		}
+		fiveG, err := queries.CreateSignal(ctx, db.CreateSignalParams{
+			Snapshotid: snapshot.ID, Generation: "5G", Band: band, Cid: cid, Gnbid: 200, Rsrp: int64(-90 - i), Sinr: 20,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create signal: %v", err)
+		}
+
+		// Cell telemetry was only fetched from the second snapshot on
+		if i == 0 {
+			continue
+		}
+		_, err = queries.CreateSignalChannel(ctx, db.CreateSignalChannelParams{Signalid: fiveG.ID, Pci: cid * 100, Arfcn: 500000 + cid, Bandwidth: "100M"})
+		if err != nil {
+			t.Fatalf("Failed to create signal channel: %v", err)
+		}
+	}
+
+	// A second gateway on the same cells is excluded by the label
+	other, err := queries.CreateSnapshot(ctx, db.CreateSnapshotParams{Deviceid: device.ID, CreatedAt: start.Add(5 * time.Minute), Label: "lab"})
+	if err != nil {
+		t.Fatalf("Failed to create snapshot: %v", err)
+	}
+	_, err = queries.CreateSignal(ctx, db.CreateSignalParams{
+		Snapshotid: other.ID, Generation: "5G", Band: "n41", Cid: 2, Gnbid: 200, Rsrp: -120, Sinr: -5,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create signal: %v", err)
+	}
+
+	// Two polls of the clients of the home gateway, a phone leaving the Wi-Fi between them
+	clientDevice, err := queries.CreateClientDevice(ctx, db.CreateClientDeviceParams{Label: "home", Mac: "aa:bb:cc:00:00:01", FirstSeen: start, LastSeen: start})
+	if err != nil {
+		t.Fatalf("Failed to create client device: %v", err)
+	}
+	for _, observation := range []struct {
+		minute     int
+		connection string
+		connected  bool
+	}{{0, "5.0ghz", true}, {0, "2.4ghz", true}, {0, "ethernet", true}, {30, "5.0ghz", true}, {30, "2.4ghz", false}} {
+		_, err = queries.CreateClientObservation(ctx, db.CreateClientObservationParams{
+			Clientdeviceid: clientDevice.ID, Label: "home", CreatedAt: start.Add(time.Duration(observation.minute) * time.Minute),
+			Connection: observation.connection, Connected: observation.connected,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create client observation: %v", err)
+		}
+	}
+
+	all, err := Build(ctx, queries, "", start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if all.Snapshots != 7 || all.FiveGSnapshots != 6 {
+		t.Errorf("Expected 7 snapshots with 6 5G across all gateways, got %d and %d", all.Snapshots, all.FiveGSnapshots)
+	}
+
+	r, err := Build(ctx, queries, "home", start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if r.Snapshots != 6 || r.FiveGSnapshots != 5 || r.FourGOnlySnapshots() != 1 {
+		t.Errorf("Unexpected snapshot counts: total=%d 5G=%d 4G only=%d", r.Snapshots, r.FiveGSnapshots, r.FourGOnlySnapshots())
+	}
+
+	if len(r.Registrations) != 2 || r.Registrations[0].Snapshots != 5 || r.Registrations[0].Roaming || !r.Registrations[1].Roaming {
+		t.Errorf("Unexpected registrations: %+v", r.Registrations)
+	}
+
+	if len(r.Generations) != 2 || r.Generations[1].Rsrp.Count != 5 || r.Generations[1].Rsrp.Max != -90 {
+		t.Errorf("Unexpected generation summaries: %+v", r.Generations)
+	}
+
+	if len(r.Bands) != 3 {
+		t.Errorf("Expected 3 band summaries, got %d", len(r.Bands))
+	}
+
+	cells := make(map[int64]CellTime)
+	for _, c := range r.Cells {
+		cells[c.Cid] = c
+	}
+	if cells[1].Duration != time.Hour {
+		t.Errorf("Expected 1 hour on the 4G cell, got %s", cells[1].Duration)
+	}
+	if cells[2].Duration != 30*time.Minute || cells[2].Samples != 3 {
+		t.Errorf("Expected 30 minutes and 3 samples on cell 2, got %s and %d", cells[2].Duration, cells[2].Samples)
+	}
+	if cells[3].Duration != 20*time.Minute {
+		t.Errorf("Expected 20 minutes on cell 3, got %s", cells[3].Duration)
+	}
+
+	if hour := r.Heatmap["5G"][10]; hour.Samples != 5 || hour.Rsrp != -92 {
+		t.Errorf("Unexpected 5G heatmap hour: %+v", hour)
+	}
+
+	if len(r.Channels) != 2 || r.Channels[0].Pci != 200 || r.Channels[0].Samples != 2 || r.Channels[0].Rsrp != -91.5 || r.Channels[1].Arfcn != 500003 {
+		t.Errorf("Unexpected channel summaries: %+v", r.Channels)
+	}
+
+	if hour := r.Clients[10]; hour.Samples != 2 || hour.Wifi != 1.5 || hour.Connected != 2 {
+		t.Errorf("Unexpected clients hour: %+v", hour)
+	}
+
+	var out bytes.Buffer
+	if err := r.Write(&out); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, expected := range []string{"gateway home", "4G only: 1", "n71", "Average by hour of day", "WIFI CLIENTS", "Signal by channel", "FBB.HOME"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected report to contain %q", expected)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	ctx := context.Background()
+	queries := setupDatabase(t)
+
+	device, err := queries.CreateDevice(ctx, db.CreateDeviceParams{Serial: "ABC123", SoftwareVersion: "1.0.0"})
+	if err != nil {
+		t.Fatalf("Failed to create device: %v", err)
+	}
+
+	reboot := time.Date(2025, 4, 1, 10, 0, 0, 0, time.UTC)
+	for i := -3; i < 3; i++ {
+		snapshot, err := queries.CreateSnapshot(ctx, db.CreateSnapshotParams{
+			Deviceid:  device.ID,
+			CreatedAt: reboot.Add(time.Duration(i)*time.Hour + time.Minute),
+			Label:     "home",
+		})
+		if err != nil {
+			t.Fatalf("Failed to create snapshot: %v", err)
+		}
+
+		// The signal improves by 10dB after the reboot
+		rsrp := int64(-100)
+		if i >= 0 {
+			rsrp = -90
+		}
+		_, err = queries.CreateSignal(ctx, db.CreateSignalParams{
+			Snapshotid: snapshot.ID, Generation: "5G", Band: "n41", Cid: 2, Gnbid: 200, Rsrp: rsrp, Sinr: 10,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create signal: %v", err)
+		}
+	}
+
+	c, err := Compare(ctx, queries, db.Event{Label: "home", Type: "reboot", CreatedAt: reboot, OldValue: "72h0m0s"}, 2*time.Hour)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if c.Before.Snapshots != 2 || c.After.Snapshots != 2 {
+		t.Errorf("Expected 2 snapshots in each 2 hour window, got %d and %d", c.Before.Snapshots, c.After.Snapshots)
+	}
+
+	var out bytes.Buffer
+	if err := WriteComparisons(&out, []Comparison{c}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, expected := range []string{"reboot", "2 -> 2", "-100.0 -> -90.0 (+10.0)", "10.0 -> 10.0 (+0.0)"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected comparison to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "4G") {
+		t.Errorf("Expected no 4G row without 4G samples")
+	}
+}
+
+func TestFlags(t *testing.T) {
+	parse := func(args ...string) (*Flags, error) {
+		flags := flag.NewFlagSet("report", flag.ContinueOnError)
+		f := RegisterFlags(flags, "the report", 7)
+		return f, flags.Parse(args)
+	}
+
+	t.Run("Default Range", func(t *testing.T) {
+		f, err := parse("-dsn=tmo.db", "-to=2025-04-08")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		start, end, err := f.Range()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if !end.Equal(time.Date(2025, 4, 8, 0, 0, 0, 0, time.Local)) || !start.Equal(end.AddDate(0, 0, -7)) {
+			t.Errorf("Unexpected range: %s to %s", start, end)
+		}
+	})
+
+	t.Run("RFC3339 From", func(t *testing.T) {
+		f, err := parse("-dsn=tmo.db", "-from=2025-04-01T10:00:00Z")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		start, _, err := f.Range()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if !start.Equal(time.Date(2025, 4, 1, 10, 0, 0, 0, time.UTC)) {
+			t.Errorf("Unexpected start: %s", start)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tests := []struct {
+			args     []string
+			expected string
+		}{
+			{nil, "DSN is required"},
+			{[]string{"-dsn=tmo.db", "-to=yesterday"}, "invalid -to"},
+			{[]string{"-dsn=tmo.db", "-from=04/01"}, "invalid -from"},
+		}
+		for _, tt := range tests {
+			f, err := parse(tt.args...)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			_, _, err = f.Range()
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected %q for %v, got %v", tt.expected, tt.args, err)
+			}
+		}
+	})
+}
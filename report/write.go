@@ -0,0 +1,122 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"local/tmo/stats"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const timeFormat = "2006-01-02 15:04"
+
+// Write prints the report as plain text tables
+func (r *Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	gateway := "all gateways"
+	if r.Label != "" {
+		gateway = "gateway " + r.Label
+	}
+	fmt.Fprintf(tw, "Report for %s from %s to %s\n", gateway, r.From.Format(timeFormat), r.To.Format(timeFormat))
+	fmt.Fprintf(tw, "Snapshots: %d (with 5G: %d, 4G only: %d)\n", r.Snapshots, r.FiveGSnapshots, r.FourGOnlySnapshots())
+
+	fmt.Fprintln(tw, "\nRegistration")
+	fmt.Fprintln(tw, "STATE\tROAMING\tAPN\tIPV6\tSNAPSHOTS\tSHARE")
+	for _, reg := range r.Registrations {
+		state, apn := reg.Registration, reg.Apn
+		if state == "" {
+			state = "-"
+		}
+		if apn == "" {
+			apn = "-"
+		}
+		share := 0.0
+		if r.Snapshots > 0 {
+			share = 100 * float64(reg.Snapshots) / float64(r.Snapshots)
+		}
+		fmt.Fprintf(tw, "%s\t%t\t%s\t%t\t%d\t%.1f%%\n", state, reg.Roaming, apn, reg.HasIPv6, reg.Snapshots, share)
+	}
+
+	fmt.Fprintln(tw, "\nSignal by generation")
+	writeSummaries(tw, r.Generations)
+
+	fmt.Fprintln(tw, "\nSignal by band")
+	writeSummaries(tw, r.Bands)
+
+	fmt.Fprintln(tw, "\nSignal by channel")
+	fmt.Fprintln(tw, "GEN\tBAND\tARFCN\tPCI\tWIDTH\tSAMPLES\tRSRP\tSINR")
+	for _, c := range r.Channels {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%d\t%.1f\t%.1f\n", c.Generation, c.Band, c.Arfcn, c.Pci, c.Bandwidth, c.Samples, c.Rsrp, c.Sinr)
+	}
+
+	fmt.Fprintln(tw, "\nTime on each cell")
+	fmt.Fprintln(tw, "GEN\tNODE\tCID\tBANDS\tSAMPLES\tTIME\tSHARE")
+	total := make(map[string]time.Duration)
+	for _, c := range r.Cells {
+		total[c.Generation] += c.Duration
+	}
+	for _, c := range r.Cells {
+		share := 0.0
+		if total[c.Generation] > 0 {
+			share = 100 * float64(c.Duration) / float64(total[c.Generation])
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%d\t%s\t%.1f%%\n",
+			c.Generation, c.NodeID, c.Cid, strings.Join(c.Bands, ","), c.Samples, c.Duration.Round(time.Minute), share)
+	}
+
+	fmt.Fprintln(tw, "\nAverage by hour of day")
+	header := []string{"HOUR"}
+	for _, generation := range Generations {
+		header = append(header, generation+" RSRP", generation+" SINR")
+	}
+	header = append(header, "WIFI CLIENTS")
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for hour := range 24 {
+		row := []string{fmt.Sprintf("%02d", hour)}
+		for _, generation := range Generations {
+			hours, ok := r.Heatmap[generation]
+			if !ok || hours[hour].Samples == 0 {
+				row = append(row, "-", "-")
+				continue
+			}
+			row = append(row, fmt.Sprintf("%.1f", hours[hour].Rsrp), fmt.Sprintf("%.1f %s", hours[hour].Sinr, bar(hours[hour].Sinr)))
+		}
+		if r.Clients[hour].Samples == 0 {
+			row = append(row, "-")
+		} else {
+			row = append(row, fmt.Sprintf("%.1f", r.Clients[hour].Wifi))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// writeSummaries prints one row per metric of each summary
+func writeSummaries(w io.Writer, summaries []MetricSummary) {
+	fmt.Fprintln(w, "GEN\tBAND\tMETRIC\tSAMPLES\tMIN\tP5\tAVG\tP50\tP95\tMAX")
+	for _, s := range summaries {
+		metrics := []struct {
+			name    string
+			summary stats.Summary
+		}{
+			{"RSRP", s.Rsrp},
+			{"RSRQ", s.Rsrq},
+			{"RSSI", s.Rssi},
+			{"SINR", s.Sinr},
+		}
+		for _, m := range metrics {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%.1f\t%d\t%d\t%d\n",
+				s.Generation, s.Band, m.name, m.summary.Count,
+				m.summary.Min, m.summary.P5, m.summary.Avg, m.summary.P50, m.summary.P95, m.summary.Max)
+		}
+	}
+}
+
+// bar draws SINR as a bar of up to 15 characters, one per 2dB above -10dB
+func bar(sinr float64) string {
+	n := int((sinr + 10) / 2)
+	return strings.Repeat("#", min(max(n, 0), 15))
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"local/tmo/api"
+	"local/tmo/backoff"
+	"local/tmo/storage"
+	"time"
+)
+
+// errStorage marks poll errors caused by the database rather than the gateway
+var errStorage = errors.New("storage error")
+
+// retryPolicy holds the backoff applied after each class of poll error
+type retryPolicy struct {
+	// Network covers unreachable gateways and errors of no known class
+	Network backoff.Policy
+	// Auth backs off slowly, the gateway locks out clients that keep failing to log in
+	Auth    backoff.Policy
+	Status  backoff.Policy
+	Decode  backoff.Policy
+	Storage backoff.Policy
+}
+
+// defaultRetryPolicy retries gateway errors within seconds at first, backing off to minutes while they persist
+func defaultRetryPolicy() retryPolicy {
+	return retryPolicy{
+		Network: backoff.Policy{Initial: 5 * time.Second, Max: 5 * time.Minute, Multiplier: 2, Jitter: 0.2},
+		Auth:    backoff.Policy{Initial: time.Minute, Max: 30 * time.Minute, Multiplier: 2, Jitter: 0.2},
+		Status:  backoff.Policy{Initial: 10 * time.Second, Max: 5 * time.Minute, Multiplier: 2, Jitter: 0.2},
+		Decode:  backoff.Policy{Initial: 30 * time.Second, Max: 10 * time.Minute, Multiplier: 2, Jitter: 0.2},
+		Storage: backoff.Policy{Initial: time.Second, Max: time.Minute, Multiplier: 2, Jitter: 0.2},
+	}
+}
+
+// delay returns how long to wait after the nth consecutive failed poll, or false if the error is unrecoverable
+func (r retryPolicy) delay(err error, n int) (time.Duration, bool) {
+	switch {
+	case errors.Is(err, errStorage):
+		if !storage.IsTransient(err) {
+			return 0, false
+		}
+		return r.Storage.Delay(n), true
+	case errors.Is(err, api.ErrAuth):
+		return r.Auth.Delay(n), true
+	case errors.Is(err, api.ErrStatus):
+		return r.Status.Delay(n), true
+	case errors.Is(err, api.ErrDecode):
+		return r.Decode.Delay(n), true
+	default:
+		return r.Network.Delay(n), true
+	}
+}
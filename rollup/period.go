@@ -0,0 +1,101 @@
+package rollup
+
+import (
+	"context"
+	"local/tmo/db"
+	"time"
+)
+
+// period describes one rollup resolution and the table it is stored in
+type period struct {
+	truncate func(time.Time) time.Time
+	next     func(time.Time) time.Time
+	latest   func(context.Context, *db.Queries) (time.Time, error)
+	replace  func(ctx context.Context, queries *db.Queries, start, end time.Time, summaries []summary) error
+}
+
+var hourly = period{
+	truncate: func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	},
+	next: func(t time.Time) time.Time {
+		return t.Add(time.Hour)
+	},
+	latest: func(ctx context.Context, queries *db.Queries) (time.Time, error) {
+		return queries.GetLatestHourlyRollupTime(ctx)
+	},
+	replace: func(ctx context.Context, queries *db.Queries, start, end time.Time, summaries []summary) error {
+		err := queries.DeleteHourlyRollupsBetween(ctx, db.DeleteHourlyRollupsBetweenParams{Start: start, End: end})
+		if err != nil {
+			return err
+		}
+
+		for _, s := range summaries {
+			err := queries.CreateHourlyRollup(ctx, db.CreateHourlyRollupParams(s.params()))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+var daily = period{
+	truncate: func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	},
+	next: func(t time.Time) time.Time {
+		return t.AddDate(0, 0, 1)
+	},
+	latest: func(ctx context.Context, queries *db.Queries) (time.Time, error) {
+		return queries.GetLatestDailyRollupTime(ctx)
+	},
+	replace: func(ctx context.Context, queries *db.Queries, start, end time.Time, summaries []summary) error {
+		err := queries.DeleteDailyRollupsBetween(ctx, db.DeleteDailyRollupsBetweenParams{Start: start, End: end})
+		if err != nil {
+			return err
+		}
+
+		for _, s := range summaries {
+			err := queries.CreateDailyRollup(ctx, s.params())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// params converts a summary to the insert parameters shared by both rollup tables
+func (s summary) params() db.CreateDailyRollupParams {
+	return db.CreateDailyRollupParams{
+		PeriodStart: s.PeriodStart,
+		Label:       s.Label,
+		Generation:  s.Generation,
+		Band:        s.Band,
+		Cid:         s.Cid,
+		Enbid:       s.Enbid,
+		Gnbid:       s.Gnbid,
+		SampleCount: s.SampleCount,
+		RsrpMin:     s.Rsrp.Min,
+		RsrpMax:     s.Rsrp.Max,
+		RsrpAvg:     s.Rsrp.Avg,
+		RsrpP5:      s.Rsrp.P5,
+		RsrpP95:     s.Rsrp.P95,
+		RsrqMin:     s.Rsrq.Min,
+		RsrqMax:     s.Rsrq.Max,
+		RsrqAvg:     s.Rsrq.Avg,
+		RsrqP5:      s.Rsrq.P5,
+		RsrqP95:     s.Rsrq.P95,
+		RssiMin:     s.Rssi.Min,
+		RssiMax:     s.Rssi.Max,
+		RssiAvg:     s.Rssi.Avg,
+		RssiP5:      s.Rssi.P5,
+		RssiP95:     s.Rssi.P95,
+		SinrMin:     s.Sinr.Min,
+		SinrMax:     s.Sinr.Max,
+		SinrAvg:     s.Sinr.Avg,
+		SinrP5:      s.Sinr.P5,
+		SinrP95:     s.Sinr.P95,
+	}
+}
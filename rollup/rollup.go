@@ -0,0 +1,286 @@
+package rollup
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"local/tmo/db"
+	"local/tmo/stats"
+	"log"
+	"os"
+	"time"
+)
+
+// Config holds rollup and retention configuration
+type Config struct {
+	// RetentionDays is how many days of raw snapshot and signal rows are kept, 0 keeps them forever
+	RetentionDays int
+	Logger        *log.Logger
+}
+
+// Result summarizes a single run of the job
+type Result struct {
+	Hours            int
+	Days             int
+	DeletedSnapshots int64
+	DeletedSignals   int64
+}
+
+// Job maintains the hourly and daily rollup tables and deletes raw rows past the retention period
+type Job struct {
+	config  Config
+	db      *sql.DB
+	queries *db.Queries
+}
+
+// New creates a new rollup Job
+func New(sqlDB *sql.DB, config Config) *Job {
+	if config.Logger == nil {
+		config.Logger = log.New(os.Stdout, "", log.LstdFlags)
+	}
+
+	return &Job{
+		config:  config,
+		db:      sqlDB,
+		queries: db.New(sqlDB),
+	}
+}
+
+// Run rolls up every complete hour and day that has not been rolled up yet, rolls up again the periods that rows
+// stored since the last run arrived late for, then deletes raw rows older than the retention period
+func (j *Job) Run(ctx context.Context, now time.Time) (Result, error) {
+	var result Result
+
+	late, last, err := j.lateSnapshots(ctx)
+	if err != nil {
+		return result, fmt.Errorf("error listing new snapshots: %w", err)
+	}
+
+	result.Hours, err = j.rollup(ctx, hourly, now, late)
+	if err != nil {
+		return result, fmt.Errorf("error rolling up hours: %w", err)
+	}
+
+	result.Days, err = j.rollup(ctx, daily, now, late)
+	if err != nil {
+		return result, fmt.Errorf("error rolling up days: %w", err)
+	}
+
+	// Only move the watermark once every period the new rows touched has been rolled up
+	err = j.queries.SetRollupWatermark(ctx, last)
+	if err != nil {
+		return result, fmt.Errorf("error saving rollup watermark: %w", err)
+	}
+
+	if j.config.RetentionDays > 0 {
+		// Rollups only cover complete days, so never delete rows from the current day
+		cutoff := daily.truncate(now).AddDate(0, 0, -j.config.RetentionDays)
+		result.DeletedSnapshots, result.DeletedSignals, err = j.deleteBefore(ctx, cutoff)
+		if err != nil {
+			return result, fmt.Errorf("error deleting raw rows: %w", err)
+		}
+	}
+
+	j.config.Logger.Printf("Rolled up %d hours and %d days, deleted %d snapshots and %d signals",
+		result.Hours, result.Days, result.DeletedSnapshots, result.DeletedSignals)
+
+	return result, nil
+}
+
+// lateSnapshots returns the times of the snapshots stored since the last run, along with the ID of the latest one.
+// Replays and gateways that were offline store rows for periods that may already be rolled up.
+func (j *Job) lateSnapshots(ctx context.Context) ([]time.Time, int64, error) {
+	last, err := j.queries.GetLatestSnapshotID(ctx)
+	if err == sql.ErrNoRows {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Without a watermark every snapshot is new, which also rolls up again what earlier versions missed
+	watermark, err := j.queries.GetRollupWatermark(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, 0, err
+	}
+	if watermark >= last {
+		return nil, last, nil
+	}
+
+	times, err := j.queries.ListSnapshotTimesAfter(ctx, db.ListSnapshotTimesAfterParams{After: watermark, Last: last})
+	return times, last, err
+}
+
+// rollup aggregates the periods already rolled up that late snapshots fall in, then every complete period after the
+// latest rollup, returning the number of periods written
+func (j *Job) rollup(ctx context.Context, p period, now time.Time, late []time.Time) (int, error) {
+	loc := now.Location()
+
+	latest, err := p.latest(ctx, j.queries)
+	var start time.Time
+	switch {
+	case err == nil:
+		start = p.next(p.truncate(latest.In(loc)))
+	case err == sql.ErrNoRows:
+		first, err := j.queries.GetFirstSnapshotTime(ctx)
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		start = p.truncate(first.In(loc))
+	default:
+		return 0, err
+	}
+
+	count := 0
+	var previous time.Time
+	for _, at := range late {
+		// Late times are sorted, so a period is only rolled up once
+		periodStart := p.truncate(at.In(loc))
+		if !periodStart.Before(start) || periodStart.Equal(previous) {
+			continue
+		}
+		err := j.rollupPeriod(ctx, p, periodStart, p.next(periodStart))
+		if err != nil {
+			return count, err
+		}
+		previous = periodStart
+		count++
+	}
+
+	end := p.truncate(now)
+	for periodStart := start; periodStart.Before(end); periodStart = p.next(periodStart) {
+		err := j.rollupPeriod(ctx, p, periodStart, p.next(periodStart))
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// rollupPeriod replaces the rollup rows of a single period
+func (j *Job) rollupPeriod(ctx context.Context, p period, start, end time.Time) error {
+	tx, err := j.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	queries := j.queries.WithTx(tx)
+
+	signals, err := queries.ListSignalsBetween(ctx, db.ListSignalsBetweenParams{Start: start, End: end})
+	if err != nil {
+		return err
+	}
+
+	err = p.replace(ctx, queries, start, end, aggregate(start, signals))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// deleteBefore deletes snapshots, signals, signal bands and signal channels recorded before the cutoff
+func (j *Job) deleteBefore(ctx context.Context, cutoff time.Time) (int64, int64, error) {
+	tx, err := j.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	queries := j.queries.WithTx(tx)
+
+	_, err = queries.DeleteSignalChannelsBefore(ctx, cutoff)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	_, err = queries.DeleteSignalBandsBefore(ctx, cutoff)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	signals, err := queries.DeleteSignalsBefore(ctx, cutoff)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	snapshots, err := queries.DeleteSnapshotsBefore(ctx, cutoff)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return snapshots, signals, tx.Commit()
+}
+
+// cell identifies the signal rows of one gateway aggregated into one rollup row
+type cell struct {
+	Label      string
+	Generation string
+	Band       string
+	Cid        int64
+	Enbid      int64
+	Gnbid      int64
+}
+
+// summary holds the aggregates of one cell over one period
+type summary struct {
+	cell
+	PeriodStart time.Time
+	SampleCount int64
+	Rsrp        stats.Summary
+	Rsrq        stats.Summary
+	Rssi        stats.Summary
+	Sinr        stats.Summary
+}
+
+// aggregate groups signals by cell and summarizes each metric
+func aggregate(periodStart time.Time, signals []db.ListSignalsBetweenRow) []summary {
+	type values struct {
+		rsrp, rsrq, rssi, sinr []int64
+	}
+
+	var order []cell
+	groups := make(map[cell]*values)
+	for _, signal := range signals {
+		key := cell{
+			Label:      signal.Label,
+			Generation: signal.Generation,
+			Band:       signal.Band,
+			Cid:        signal.Cid,
+			Enbid:      signal.Enbid,
+			Gnbid:      signal.Gnbid,
+		}
+		group, ok := groups[key]
+		if !ok {
+			group = &values{}
+			groups[key] = group
+			order = append(order, key)
+		}
+		group.rsrp = append(group.rsrp, signal.Rsrp)
+		group.rsrq = append(group.rsrq, signal.Rsrq)
+		group.rssi = append(group.rssi, signal.Rssi)
+		group.sinr = append(group.sinr, signal.Sinr)
+	}
+
+	summaries := make([]summary, 0, len(order))
+	for _, key := range order {
+		group := groups[key]
+		summaries = append(summaries, summary{
+			cell:        key,
+			PeriodStart: periodStart,
+			SampleCount: int64(len(group.rsrp)),
+			Rsrp:        stats.Summarize(group.rsrp),
+			Rsrq:        stats.Summarize(group.rsrq),
+			Rssi:        stats.Summarize(group.rssi),
+			Sinr:        stats.Summarize(group.sinr),
+		})
+	}
+
+	return summaries
+}
@@ -0,0 +1,226 @@
+package rollup
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"local/tmo/db"
+	"local/tmo/migrations"
+	"log"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupDatabase(t *testing.T) (*sql.DB, *db.Queries) {
+	sqlDB, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator, err := migrations.New(sqlDB)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	return sqlDB, db.New(sqlDB)
+}
+
+// insertSignal stores a snapshot with a single 5G signal and its channel
+func insertSignal(t *testing.T, queries *db.Queries, deviceID int64, at time.Time, rsrp int64) {
+	ctx := context.Background()
+	snapshot, err := queries.CreateSnapshot(ctx, db.CreateSnapshotParams{
+		Deviceid:  deviceID,
+		CreatedAt: at,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create snapshot: %v", err)
+	}
+
+	signal, err := queries.CreateSignal(ctx, db.CreateSignalParams{
+		Snapshotid: snapshot.ID,
+		Generation: "5G",
+		Band:       "n41",
+		Cid:        12,
+		Gnbid:      4567,
+		Rsrp:       rsrp,
+		Rsrq:       -10,
+		Rssi:       -90,
+		Sinr:       10,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create signal: %v", err)
+	}
+
+	_, err = queries.CreateSignalBand(ctx, db.CreateSignalBandParams{
+		Signalid:  signal.ID,
+		Band:      "n41",
+		IsPrimary: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create signal band: %v", err)
+	}
+
+	_, err = queries.CreateSignalChannel(ctx, db.CreateSignalChannelParams{Signalid: signal.ID, Pci: 403, Arfcn: 520110})
+	if err != nil {
+		t.Fatalf("Failed to create signal channel: %v", err)
+	}
+}
+
+func count(t *testing.T, sqlDB *sql.DB, table string) int {
+	var n int
+	err := sqlDB.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n)
+	if err != nil {
+		t.Fatalf("Failed to count %s: %v", table, err)
+	}
+	return n
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	sqlDB, queries := setupDatabase(t)
+
+	device, err := queries.CreateDevice(ctx, db.CreateDeviceParams{Serial: "ABC123", SoftwareVersion: "1.0.0"})
+	if err != nil {
+		t.Fatalf("Failed to create device: %v", err)
+	}
+
+	// Ten samples at minute intervals in each of the first two hours of three consecutive days
+	dayOne := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	for day := range 3 {
+		for hour := range 2 {
+			for minute := range 10 {
+				at := dayOne.AddDate(0, 0, day).Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+				insertSignal(t, queries, device.ID, at, int64(-100-minute))
+			}
+		}
+	}
+
+	job := New(sqlDB, Config{RetentionDays: 1, Logger: log.New(io.Discard, "", 0)})
+
+	// Part way through the third day, so only the first two days are complete
+	now := dayOne.AddDate(0, 0, 2).Add(90 * time.Minute)
+	result, err := job.Run(ctx, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if result.Hours != 49 {
+		t.Errorf("Expected 49 hours rolled up, got %d", result.Hours)
+	}
+	if result.Days != 2 {
+		t.Errorf("Expected 2 days rolled up, got %d", result.Days)
+	}
+	if count(t, sqlDB, "signal_rollup_hour") != 5 {
+		t.Errorf("Expected 5 hourly rollups, got %d", count(t, sqlDB, "signal_rollup_hour"))
+	}
+	if count(t, sqlDB, "signal_rollup_day") != 2 {
+		t.Errorf("Expected 2 daily rollups, got %d", count(t, sqlDB, "signal_rollup_day"))
+	}
+
+	var sampleCount, rsrpMin, rsrpMax, rsrpP5, rsrpP95 int64
+	var rsrpAvg float64
+	err = sqlDB.QueryRow(`SELECT sample_count, rsrp_min, rsrp_max, rsrp_avg, rsrp_p5, rsrp_p95
+		FROM signal_rollup_day ORDER BY period_start LIMIT 1`).Scan(&sampleCount, &rsrpMin, &rsrpMax, &rsrpAvg, &rsrpP5, &rsrpP95)
+	if err != nil {
+		t.Fatalf("Failed to read daily rollup: %v", err)
+	}
+	if sampleCount != 20 || rsrpMin != -109 || rsrpMax != -100 || rsrpAvg != -104.5 || rsrpP5 != -109 || rsrpP95 != -100 {
+		t.Errorf("Unexpected daily rollup: count=%d min=%d max=%d avg=%f p5=%d p95=%d",
+			sampleCount, rsrpMin, rsrpMax, rsrpAvg, rsrpP5, rsrpP95)
+	}
+
+	// Retention keeps one day before the start of the current day
+	if result.DeletedSnapshots != 20 || result.DeletedSignals != 20 {
+		t.Errorf("Expected 20 snapshots and signals deleted, got %d and %d", result.DeletedSnapshots, result.DeletedSignals)
+	}
+	if count(t, sqlDB, "signal_band") != 40 {
+		t.Errorf("Expected 40 signal bands left, got %d", count(t, sqlDB, "signal_band"))
+	}
+	if count(t, sqlDB, "signal_channel") != 40 {
+		t.Errorf("Expected 40 signal channels left, got %d", count(t, sqlDB, "signal_channel"))
+	}
+
+	t.Run("Second Run Only Rolls Up New Periods", func(t *testing.T) {
+		result, err := job.Run(ctx, now.Add(time.Hour))
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if result.Hours != 1 || result.Days != 0 {
+			t.Errorf("Expected 1 hour and 0 days rolled up, got %d and %d", result.Hours, result.Days)
+		}
+		if count(t, sqlDB, "signal_rollup_hour") != 6 {
+			t.Errorf("Expected 6 hourly rollups, got %d", count(t, sqlDB, "signal_rollup_hour"))
+		}
+	})
+}
+
+func TestRunLateData(t *testing.T) {
+	ctx := context.Background()
+	sqlDB, queries := setupDatabase(t)
+
+	device, err := queries.CreateDevice(ctx, db.CreateDeviceParams{Serial: "ABC123", SoftwareVersion: "1.0.0"})
+	if err != nil {
+		t.Fatalf("Failed to create device: %v", err)
+	}
+
+	dayOne := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	for minute := range 10 {
+		insertSignal(t, queries, device.ID, dayOne.Add(time.Duration(minute)*time.Minute), -100)
+	}
+	insertSignal(t, queries, device.ID, dayOne.Add(25*time.Hour), -100)
+
+	job := New(sqlDB, Config{Logger: log.New(io.Discard, "", 0)})
+	now := dayOne.Add(26 * time.Hour)
+	_, err = job.Run(ctx, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// A replay stores rows in an hour and a day that are already rolled up, and in an hour without rows before
+	insertSignal(t, queries, device.ID, dayOne.Add(30*time.Minute), -80)
+	insertSignal(t, queries, device.ID, dayOne.Add(5*time.Hour), -80)
+
+	result, err := job.Run(ctx, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if result.Hours != 2 || result.Days != 1 {
+		t.Errorf("Expected 2 hours and 1 day rolled up again, got %d and %d", result.Hours, result.Days)
+	}
+
+	var hourSamples, daySamples, rsrpMax int64
+	err = sqlDB.QueryRow("SELECT sample_count, rsrp_max FROM signal_rollup_hour ORDER BY period_start LIMIT 1").Scan(&hourSamples, &rsrpMax)
+	if err != nil {
+		t.Fatalf("Failed to read hourly rollup: %v", err)
+	}
+	if hourSamples != 11 || rsrpMax != -80 {
+		t.Errorf("Expected the late sample in the first hour, got count=%d max=%d", hourSamples, rsrpMax)
+	}
+	err = sqlDB.QueryRow("SELECT sample_count FROM signal_rollup_day ORDER BY period_start LIMIT 1").Scan(&daySamples)
+	if err != nil {
+		t.Fatalf("Failed to read daily rollup: %v", err)
+	}
+	if daySamples != 12 {
+		t.Errorf("Expected both late samples in the first day, got %d", daySamples)
+	}
+	if count(t, sqlDB, "signal_rollup_hour") != 3 {
+		t.Errorf("Expected 3 hourly rollups, got %d", count(t, sqlDB, "signal_rollup_hour"))
+	}
+
+	t.Run("Late Rows Are Rolled Up Once", func(t *testing.T) {
+		result, err := job.Run(ctx, now)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if result.Hours != 0 || result.Days != 0 {
+			t.Errorf("Expected nothing rolled up, got %d hours and %d days", result.Hours, result.Days)
+		}
+	})
+}
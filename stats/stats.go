@@ -0,0 +1,48 @@
+package stats
+
+import (
+	"math"
+	"slices"
+)
+
+// Summary holds the aggregates of a set of signal metric samples
+type Summary struct {
+	Count int
+	Min   int64
+	Max   int64
+	Avg   float64
+	P5    int64
+	P50   int64
+	P95   int64
+}
+
+// Summarize computes the min, max, average and nearest-rank percentiles of the values.
+// The values are sorted in place.
+func Summarize(values []int64) Summary {
+	if len(values) == 0 {
+		return Summary{}
+	}
+
+	slices.Sort(values)
+
+	var sum int64
+	for _, v := range values {
+		sum += v
+	}
+
+	return Summary{
+		Count: len(values),
+		Min:   values[0],
+		Max:   values[len(values)-1],
+		Avg:   float64(sum) / float64(len(values)),
+		P5:    Percentile(values, 5),
+		P50:   Percentile(values, 50),
+		P95:   Percentile(values, 95),
+	}
+}
+
+// Percentile returns the nearest-rank percentile p of sorted values
+func Percentile(sorted []int64, p float64) int64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank-1, 0)]
+}
@@ -0,0 +1,21 @@
+package stats
+
+import "testing"
+
+func TestSummarize(t *testing.T) {
+	values := []int64{20, 19, 18, 17, 16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1}
+	summary := Summarize(values)
+
+	expected := Summary{Count: 20, Min: 1, Max: 20, Avg: 10.5, P5: 1, P50: 10, P95: 19}
+	if summary != expected {
+		t.Errorf("Expected %+v, got %+v", expected, summary)
+	}
+
+	if single := Summarize([]int64{7}); single.P5 != 7 || single.P95 != 7 {
+		t.Errorf("Expected percentiles of a single value to be the value, got %+v", single)
+	}
+
+	if empty := Summarize(nil); empty != (Summary{}) {
+		t.Errorf("Expected empty summary, got %+v", empty)
+	}
+}
@@ -0,0 +1,340 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"local/tmo/db"
+	"local/tmo/db/postgres"
+	"local/tmo/migrations"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// Postgres stores snapshots in a PostgreSQL database, so the gateways of many households can share one database
+type Postgres struct {
+	db      *sql.DB
+	queries *postgres.Queries
+}
+
+// NewPostgres creates a PostgreSQL store for an open database
+func NewPostgres(sqlDB *sql.DB) *Postgres {
+	return &Postgres{
+		db:      sqlDB,
+		queries: postgres.New(sqlDB),
+	}
+}
+
+// OpenPostgres opens and tests a PostgreSQL database connection
+func OpenPostgres(ctx context.Context, dsn string) (*Postgres, error) {
+	sqlDb, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
+
+	if err = sqlDb.PingContext(ctx); err != nil {
+		sqlDb.Close()
+		return nil, fmt.Errorf("error connecting to database: %w", err)
+	}
+
+	return NewPostgres(sqlDb), nil
+}
+
+// Begin starts a transaction
+func (p *Postgres) Begin(ctx context.Context) (Tx, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return postgresTx{Tx: tx, queries: p.queries.WithTx(tx)}, nil
+}
+
+// ListSignalsBetween lists the signals of the snapshots taken in [start, end) ordered by time
+func (p *Postgres) ListSignalsBetween(ctx context.Context, arg db.ListSignalsBetweenParams) ([]db.ListSignalsBetweenRow, error) {
+	rows, err := p.queries.ListSignalsBetween(ctx, postgres.ListSignalsBetweenParams(arg))
+	if err != nil {
+		return nil, err
+	}
+
+	signals := make([]db.ListSignalsBetweenRow, len(rows))
+	for i, row := range rows {
+		signals[i] = db.ListSignalsBetweenRow(row)
+	}
+	return signals, nil
+}
+
+// CountSnapshotsBetween counts the snapshots of each gateway taken in [start, end) and how many of them had a 5G signal
+func (p *Postgres) CountSnapshotsBetween(ctx context.Context, arg db.CountSnapshotsBetweenParams) ([]db.CountSnapshotsBetweenRow, error) {
+	rows, err := p.queries.CountSnapshotsBetween(ctx, postgres.CountSnapshotsBetweenParams(arg))
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]db.CountSnapshotsBetweenRow, len(rows))
+	for i, row := range rows {
+		counts[i] = db.CountSnapshotsBetweenRow(row)
+	}
+	return counts, nil
+}
+
+// ListSignalBands lists the bands of a signal, primary carrier first
+func (p *Postgres) ListSignalBands(ctx context.Context, signalid int64) ([]db.SignalBand, error) {
+	rows, err := p.queries.ListSignalBands(ctx, signalid)
+	if err != nil {
+		return nil, err
+	}
+
+	bands := make([]db.SignalBand, len(rows))
+	for i, row := range rows {
+		bands[i] = db.SignalBand(row)
+	}
+	return bands, nil
+}
+
+// CountRegistrationsBetween counts the snapshots of each gateway taken in [start, end) by registration state, roaming, APN and IPv6
+func (p *Postgres) CountRegistrationsBetween(ctx context.Context, arg db.CountRegistrationsBetweenParams) ([]db.CountRegistrationsBetweenRow, error) {
+	rows, err := p.queries.CountRegistrationsBetween(ctx, postgres.CountRegistrationsBetweenParams(arg))
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]db.CountRegistrationsBetweenRow, len(rows))
+	for i, row := range rows {
+		counts[i] = db.CountRegistrationsBetweenRow(row)
+	}
+	return counts, nil
+}
+
+// GetLatestSnapshot returns the time, uptime and device of the latest snapshot of a gateway, or sql.ErrNoRows
+func (p *Postgres) GetLatestSnapshot(ctx context.Context, label string) (db.GetLatestSnapshotRow, error) {
+	row, err := p.queries.GetLatestSnapshot(ctx, label)
+	return db.GetLatestSnapshotRow(row), err
+}
+
+// ListLastEvents lists the most recent event of each type recorded for a gateway
+func (p *Postgres) ListLastEvents(ctx context.Context, label string) ([]db.Event, error) {
+	rows, err := p.queries.ListLastEvents(ctx, label)
+	return events(rows), err
+}
+
+// ListEventsBetween lists the events recorded in [start, end) ordered by time
+func (p *Postgres) ListEventsBetween(ctx context.Context, arg db.ListEventsBetweenParams) ([]db.Event, error) {
+	rows, err := p.queries.ListEventsBetween(ctx, postgres.ListEventsBetweenParams(arg))
+	return events(rows), err
+}
+
+// ListCells lists every cell ordered by gateway, generation and most samples first
+func (p *Postgres) ListCells(ctx context.Context) ([]db.Cell, error) {
+	rows, err := p.queries.ListCells(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cells := make([]db.Cell, len(rows))
+	for i, row := range rows {
+		cells[i] = db.Cell(row)
+	}
+	return cells, nil
+}
+
+// ListClientDevices lists every client device ordered by gateway and most recently seen first
+func (p *Postgres) ListClientDevices(ctx context.Context) ([]db.ClientDevice, error) {
+	rows, err := p.queries.ListClientDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make([]db.ClientDevice, len(rows))
+	for i, row := range rows {
+		devices[i] = db.ClientDevice(row)
+	}
+	return devices, nil
+}
+
+// CountClientsBetween counts the connected and Wi-Fi clients of each gateway at each client poll in [start, end)
+func (p *Postgres) CountClientsBetween(ctx context.Context, arg db.CountClientsBetweenParams) ([]db.CountClientsBetweenRow, error) {
+	rows, err := p.queries.CountClientsBetween(ctx, postgres.CountClientsBetweenParams(arg))
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]db.CountClientsBetweenRow, len(rows))
+	for i, row := range rows {
+		counts[i] = db.CountClientsBetweenRow(row)
+	}
+	return counts, nil
+}
+
+// ListChannelsBetween summarizes the signals of each gateway on each radio channel in [start, end)
+func (p *Postgres) ListChannelsBetween(ctx context.Context, arg db.ListChannelsBetweenParams) ([]db.ListChannelsBetweenRow, error) {
+	rows, err := p.queries.ListChannelsBetween(ctx, postgres.ListChannelsBetweenParams(arg))
+	if err != nil {
+		return nil, err
+	}
+
+	channels := make([]db.ListChannelsBetweenRow, len(rows))
+	for i, row := range rows {
+		channels[i] = db.ListChannelsBetweenRow(row)
+	}
+	return channels, nil
+}
+
+// ListDevicesPage lists up to page_size devices with an id above after, ordered by id
+func (p *Postgres) ListDevicesPage(ctx context.Context, arg db.ListDevicesPageParams) ([]db.Device, error) {
+	rows, err := p.queries.ListDevicesPage(ctx, postgres.ListDevicesPageParams(arg))
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make([]db.Device, len(rows))
+	for i, row := range rows {
+		devices[i] = db.Device(row)
+	}
+	return devices, nil
+}
+
+// ListSnapshotsPage lists up to page_size snapshots taken in [start, end) with an id above after, ordered by id
+func (p *Postgres) ListSnapshotsPage(ctx context.Context, arg db.ListSnapshotsPageParams) ([]db.Snapshot, error) {
+	rows, err := p.queries.ListSnapshotsPage(ctx, postgres.ListSnapshotsPageParams(arg))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]db.Snapshot, len(rows))
+	for i, row := range rows {
+		snapshots[i] = db.Snapshot(row)
+	}
+	return snapshots, nil
+}
+
+// ListSignalsPage lists up to page_size signals stored in [start, end) with an id above after, ordered by id
+func (p *Postgres) ListSignalsPage(ctx context.Context, arg db.ListSignalsPageParams) ([]db.ListSignalsPageRow, error) {
+	rows, err := p.queries.ListSignalsPage(ctx, postgres.ListSignalsPageParams(arg))
+	if err != nil {
+		return nil, err
+	}
+
+	signals := make([]db.ListSignalsPageRow, len(rows))
+	for i, row := range rows {
+		signals[i] = db.ListSignalsPageRow(row)
+	}
+	return signals, nil
+}
+
+// SummarizeSignalsBetween summarizes the signals of each gateway on each band in [start, end)
+func (p *Postgres) SummarizeSignalsBetween(ctx context.Context, arg db.SummarizeSignalsBetweenParams) ([]db.SummarizeSignalsBetweenRow, error) {
+	rows, err := p.queries.SummarizeSignalsBetween(ctx, postgres.SummarizeSignalsBetweenParams(arg))
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]db.SummarizeSignalsBetweenRow, len(rows))
+	for i, row := range rows {
+		summaries[i] = db.SummarizeSignalsBetweenRow(row)
+	}
+	return summaries, nil
+}
+
+// events converts PostgreSQL event rows
+func events(rows []postgres.Event) []db.Event {
+	events := make([]db.Event, len(rows))
+	for i, row := range rows {
+		events[i] = db.Event(row)
+	}
+	return events
+}
+
+// Migrator returns a Migrator for the PostgreSQL schema
+func (p *Postgres) Migrator() (*migrations.Migrator, error) {
+	return migrations.NewPostgres(p.db)
+}
+
+// Close closes the database
+func (p *Postgres) Close() error {
+	return p.db.Close()
+}
+
+type postgresTx struct {
+	*sql.Tx
+	queries *postgres.Queries
+}
+
+func (t postgresTx) GetDevice(ctx context.Context, arg db.GetDeviceParams) (db.Device, error) {
+	device, err := t.queries.GetDevice(ctx, postgres.GetDeviceParams(arg))
+	return db.Device(device), err
+}
+
+func (t postgresTx) CreateDevice(ctx context.Context, arg db.CreateDeviceParams) (db.Device, error) {
+	device, err := t.queries.CreateDevice(ctx, postgres.CreateDeviceParams(arg))
+	return db.Device(device), err
+}
+
+func (t postgresTx) UpdateDeviceLabel(ctx context.Context, arg db.UpdateDeviceLabelParams) error {
+	return t.queries.UpdateDeviceLabel(ctx, postgres.UpdateDeviceLabelParams(arg))
+}
+
+func (t postgresTx) CreateSnapshot(ctx context.Context, arg db.CreateSnapshotParams) (db.Snapshot, error) {
+	snapshot, err := t.queries.CreateSnapshot(ctx, postgres.CreateSnapshotParams(arg))
+	return db.Snapshot(snapshot), err
+}
+
+func (t postgresTx) CreateSignal(ctx context.Context, arg db.CreateSignalParams) (db.Signal, error) {
+	signal, err := t.queries.CreateSignal(ctx, postgres.CreateSignalParams(arg))
+	return db.Signal(signal), err
+}
+
+func (t postgresTx) CreateSignalBand(ctx context.Context, arg db.CreateSignalBandParams) (db.SignalBand, error) {
+	band, err := t.queries.CreateSignalBand(ctx, postgres.CreateSignalBandParams(arg))
+	return db.SignalBand(band), err
+}
+
+func (t postgresTx) CreateSignalChannel(ctx context.Context, arg db.CreateSignalChannelParams) (db.SignalChannel, error) {
+	channel, err := t.queries.CreateSignalChannel(ctx, postgres.CreateSignalChannelParams(arg))
+	return db.SignalChannel(channel), err
+}
+
+func (t postgresTx) CreateEvent(ctx context.Context, arg db.CreateEventParams) (db.Event, error) {
+	event, err := t.queries.CreateEvent(ctx, postgres.CreateEventParams(arg))
+	return db.Event(event), err
+}
+
+func (t postgresTx) GetCell(ctx context.Context, arg db.GetCellParams) (db.Cell, error) {
+	cell, err := t.queries.GetCell(ctx, postgres.GetCellParams(arg))
+	return db.Cell(cell), err
+}
+
+func (t postgresTx) GetServingCell(ctx context.Context, arg db.GetServingCellParams) (db.Cell, error) {
+	cell, err := t.queries.GetServingCell(ctx, postgres.GetServingCellParams(arg))
+	return db.Cell(cell), err
+}
+
+func (t postgresTx) CreateCell(ctx context.Context, arg db.CreateCellParams) (db.Cell, error) {
+	cell, err := t.queries.CreateCell(ctx, postgres.CreateCellParams(arg))
+	return db.Cell(cell), err
+}
+
+func (t postgresTx) UpdateCell(ctx context.Context, arg db.UpdateCellParams) (db.Cell, error) {
+	cell, err := t.queries.UpdateCell(ctx, postgres.UpdateCellParams(arg))
+	return db.Cell(cell), err
+}
+
+func (t postgresTx) GetClientDevice(ctx context.Context, arg db.GetClientDeviceParams) (db.ClientDevice, error) {
+	device, err := t.queries.GetClientDevice(ctx, postgres.GetClientDeviceParams(arg))
+	return db.ClientDevice(device), err
+}
+
+func (t postgresTx) CreateClientDevice(ctx context.Context, arg db.CreateClientDeviceParams) (db.ClientDevice, error) {
+	device, err := t.queries.CreateClientDevice(ctx, postgres.CreateClientDeviceParams(arg))
+	return db.ClientDevice(device), err
+}
+
+func (t postgresTx) UpdateClientDevice(ctx context.Context, arg db.UpdateClientDeviceParams) (db.ClientDevice, error) {
+	device, err := t.queries.UpdateClientDevice(ctx, postgres.UpdateClientDeviceParams(arg))
+	return db.ClientDevice(device), err
+}
+
+func (t postgresTx) CreateClientObservation(ctx context.Context, arg db.CreateClientObservationParams) (db.ClientObservation, error) {
+	observation, err := t.queries.CreateClientObservation(ctx, postgres.CreateClientObservationParams(arg))
+	return db.ClientObservation(observation), err
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"local/tmo/db"
+	"local/tmo/migrations"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLite stores snapshots in a SQLite database
+type SQLite struct {
+	*db.Queries
+	DB *sql.DB
+}
+
+// NewSQLite creates a SQLite store for an open database
+func NewSQLite(sqlDB *sql.DB) *SQLite {
+	return &SQLite{
+		Queries: db.New(sqlDB),
+		DB:      sqlDB,
+	}
+}
+
+// OpenSQLite opens and tests a SQLite database connection
+func OpenSQLite(ctx context.Context, dsn string) (*SQLite, error) {
+	sqlDb, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
+
+	sqlDb.SetMaxOpenConns(1)
+	sqlDb.SetMaxIdleConns(1)
+
+	if err = sqlDb.PingContext(ctx); err != nil {
+		sqlDb.Close()
+		return nil, fmt.Errorf("error connecting to database: %w", err)
+	}
+
+	return NewSQLite(sqlDb), nil
+}
+
+// Begin starts a transaction
+func (s *SQLite) Begin(ctx context.Context) (Tx, error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return sqliteTx{Tx: tx, Queries: s.Queries.WithTx(tx)}, nil
+}
+
+// Migrator returns a Migrator for the SQLite schema
+func (s *SQLite) Migrator() (*migrations.Migrator, error) {
+	return migrations.New(s.DB)
+}
+
+// Close closes the database
+func (s *SQLite) Close() error {
+	return s.DB.Close()
+}
+
+type sqliteTx struct {
+	*sql.Tx
+	*db.Queries
+}
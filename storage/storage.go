@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"local/tmo/db"
+	"local/tmo/migrations"
+)
+
+// Backends accepted by Open
+const (
+	BackendSQLite   = "sqlite"
+	BackendPostgres = "postgres"
+)
+
+// Store saves gateway snapshots and queries the stored signal history
+type Store interface {
+	// Begin starts a transaction to save a snapshot in
+	Begin(ctx context.Context) (Tx, error)
+	ListSignalsBetween(ctx context.Context, arg db.ListSignalsBetweenParams) ([]db.ListSignalsBetweenRow, error)
+	CountSnapshotsBetween(ctx context.Context, arg db.CountSnapshotsBetweenParams) ([]db.CountSnapshotsBetweenRow, error)
+	CountRegistrationsBetween(ctx context.Context, arg db.CountRegistrationsBetweenParams) ([]db.CountRegistrationsBetweenRow, error)
+	ListSignalBands(ctx context.Context, signalid int64) ([]db.SignalBand, error)
+	// GetLatestSnapshot returns the time, uptime and device of the latest snapshot of a gateway, or sql.ErrNoRows
+	GetLatestSnapshot(ctx context.Context, label string) (db.GetLatestSnapshotRow, error)
+	// ListLastEvents lists the most recent event of each type recorded for a gateway
+	ListLastEvents(ctx context.Context, label string) ([]db.Event, error)
+	ListEventsBetween(ctx context.Context, arg db.ListEventsBetweenParams) ([]db.Event, error)
+	ListCells(ctx context.Context) ([]db.Cell, error)
+	ListClientDevices(ctx context.Context) ([]db.ClientDevice, error)
+	// CountClientsBetween counts the connected and Wi-Fi clients of each gateway at each client poll in [start, end)
+	CountClientsBetween(ctx context.Context, arg db.CountClientsBetweenParams) ([]db.CountClientsBetweenRow, error)
+	// ListChannelsBetween summarizes the signals of each gateway on each radio channel in [start, end)
+	ListChannelsBetween(ctx context.Context, arg db.ListChannelsBetweenParams) ([]db.ListChannelsBetweenRow, error)
+	// ListDevicesPage lists up to page_size devices with an id above after, ordered by id
+	ListDevicesPage(ctx context.Context, arg db.ListDevicesPageParams) ([]db.Device, error)
+	// ListSnapshotsPage lists up to page_size snapshots taken in [start, end) with an id above after, ordered by id
+	ListSnapshotsPage(ctx context.Context, arg db.ListSnapshotsPageParams) ([]db.Snapshot, error)
+	// ListSignalsPage lists up to page_size signals stored in [start, end) with an id above after, ordered by id
+	ListSignalsPage(ctx context.Context, arg db.ListSignalsPageParams) ([]db.ListSignalsPageRow, error)
+	// SummarizeSignalsBetween summarizes the signals of each gateway on each band in [start, end)
+	SummarizeSignalsBetween(ctx context.Context, arg db.SummarizeSignalsBetweenParams) ([]db.SummarizeSignalsBetweenRow, error)
+	// Migrator returns a Migrator for the schema of the backend
+	Migrator() (*migrations.Migrator, error)
+	Close() error
+}
+
+// Tx saves a snapshot atomically, GetDevice, GetCell and GetClientDevice return sql.ErrNoRows for unknown rows
+type Tx interface {
+	GetDevice(ctx context.Context, arg db.GetDeviceParams) (db.Device, error)
+	CreateDevice(ctx context.Context, arg db.CreateDeviceParams) (db.Device, error)
+	UpdateDeviceLabel(ctx context.Context, arg db.UpdateDeviceLabelParams) error
+	CreateSnapshot(ctx context.Context, arg db.CreateSnapshotParams) (db.Snapshot, error)
+	CreateSignal(ctx context.Context, arg db.CreateSignalParams) (db.Signal, error)
+	CreateSignalBand(ctx context.Context, arg db.CreateSignalBandParams) (db.SignalBand, error)
+	CreateSignalChannel(ctx context.Context, arg db.CreateSignalChannelParams) (db.SignalChannel, error)
+	CreateEvent(ctx context.Context, arg db.CreateEventParams) (db.Event, error)
+	GetCell(ctx context.Context, arg db.GetCellParams) (db.Cell, error)
+	// GetServingCell returns the cell of a gateway and generation seen most recently, or sql.ErrNoRows
+	GetServingCell(ctx context.Context, arg db.GetServingCellParams) (db.Cell, error)
+	CreateCell(ctx context.Context, arg db.CreateCellParams) (db.Cell, error)
+	UpdateCell(ctx context.Context, arg db.UpdateCellParams) (db.Cell, error)
+	GetClientDevice(ctx context.Context, arg db.GetClientDeviceParams) (db.ClientDevice, error)
+	CreateClientDevice(ctx context.Context, arg db.CreateClientDeviceParams) (db.ClientDevice, error)
+	UpdateClientDevice(ctx context.Context, arg db.UpdateClientDeviceParams) (db.ClientDevice, error)
+	CreateClientObservation(ctx context.Context, arg db.CreateClientObservationParams) (db.ClientObservation, error)
+	Commit() error
+	Rollback() error
+}
+
+// Open connects to the database of the given backend
+func Open(ctx context.Context, backend, dsn string) (Store, error) {
+	switch backend {
+	case BackendSQLite, "":
+		return OpenSQLite(ctx, dsn)
+	case BackendPostgres:
+		return OpenPostgres(ctx, dsn)
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", backend)
+	}
+}
@@ -0,0 +1,198 @@
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"local/tmo/api"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Event types sent to subscribers
+const (
+	// TypePoll is sent with every successful poll
+	TypePoll = "poll"
+	// TypeFailure is sent with every failed poll
+	TypeFailure = "failure"
+)
+
+// DefaultBuffer is how many events a subscriber may fall behind by before it is dropped
+const DefaultBuffer = 16
+
+// heartbeat is how often an idle stream gets a comment, so proxies keep it open and closed clients are noticed
+const heartbeat = 15 * time.Second
+
+// retryMillis is how long browsers wait before reconnecting to a stream that ended, such as after being dropped
+const retryMillis = 5000
+
+// Poll is the data of a poll event
+type Poll struct {
+	Label    string     `json:"label"`
+	Time     time.Time  `json:"time"`
+	Duration float64    `json:"duration_seconds"`
+	Device   api.Device `json:"device"`
+	Signal   api.Signal `json:"signal"`
+	Uptime   int        `json:"uptime"`
+}
+
+// Failure is the data of a failure event
+type Failure struct {
+	Label    string    `json:"label"`
+	Time     time.Time `json:"time"`
+	Duration float64   `json:"duration_seconds"`
+	Error    string    `json:"error"`
+}
+
+// Event is an event sent to the subscribers of the gateway with the label, Data is JSON
+type Event struct {
+	ID    uint64
+	Type  string
+	Label string
+	Data  []byte
+}
+
+// subscriber receives the events of the gateway with the label, or of every gateway if the label is empty
+type subscriber struct {
+	events chan Event
+	label  string
+}
+
+// Hub fans the outcome of every poll out to its subscribers. A subscriber that does not keep up is dropped rather than
+// holding up the pollers or the other subscribers.
+type Hub struct {
+	buffer int
+	logger *log.Logger
+
+	mu          sync.Mutex
+	lastID      uint64
+	subscribers map[*subscriber]struct{}
+}
+
+// NewHub creates a hub letting each subscriber fall behind by buffer events
+func NewHub(buffer int, logger *log.Logger) *Hub {
+	return &Hub{
+		buffer:      buffer,
+		logger:      logger,
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+// Subscribe returns the events of the gateway with the label, or of every gateway if the label is empty. The channel
+// is closed when the subscriber is dropped for falling behind, or once unsubscribe is called.
+func (h *Hub) Subscribe(label string) (<-chan Event, func()) {
+	sub := &subscriber{events: make(chan Event, h.buffer), label: label}
+
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub.events, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(sub)
+	}
+}
+
+// Subscribers returns how many subscribers there are
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers)
+}
+
+// remove closes the channel of a subscriber that has not been removed yet, the lock must be held
+func (h *Hub) remove(sub *subscriber) {
+	if _, ok := h.subscribers[sub]; ok {
+		delete(h.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// Publish sends an event with the data encoded as JSON to the subscribers of the gateway with the label, without
+// waiting for any of them
+func (h *Hub) Publish(label, eventType string, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("error encoding %s event: %w", eventType, err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	event := Event{ID: h.lastID, Type: eventType, Label: label, Data: encoded}
+	for sub := range h.subscribers {
+		if sub.label != "" && sub.label != label {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			h.logger.Printf("Dropping a stream subscriber %d events behind", len(sub.events))
+			h.remove(sub)
+		}
+	}
+	return nil
+}
+
+// ObservePoll publishes a poll event if the poll succeeded, otherwise a failure event
+func (h *Hub) ObservePoll(label string, gateway api.GatewayResponse, duration time.Duration, err error) {
+	now := time.Now()
+	if err != nil {
+		err = h.Publish(label, TypeFailure, Failure{Label: label, Time: now, Duration: duration.Seconds(), Error: err.Error()})
+	} else {
+		err = h.Publish(label, TypePoll, Poll{
+			Label:    label,
+			Time:     now,
+			Duration: duration.Seconds(),
+			Device:   gateway.Device,
+			Signal:   gateway.Signal,
+			Uptime:   gateway.Time.UpTime,
+		})
+	}
+	if err != nil {
+		h.logger.Printf("Failed to publish poll: %v", err)
+	}
+}
+
+// ServeHTTP streams the events of the gateway in the label query parameter, or of every gateway, as server-sent
+// events until the client goes away or falls behind
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	controller := http.NewResponseController(w)
+	events, unsubscribe := h.Subscribe(r.URL.Query().Get("label"))
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Keep reverse proxies such as nginx from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", retryMillis)
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		err := controller.Flush()
+		if err != nil {
+			return
+		}
+
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+		case <-ticker.C:
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		case <-r.Context().Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package stream
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"local/tmo/api"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// receive returns the next event, failing if none arrives in time
+func receive(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("Expected an event, the subscriber was dropped")
+		}
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for an event")
+	}
+	return Event{}
+}
+
+func TestHub(t *testing.T) {
+	hub := NewHub(4, log.New(io.Discard, "", 0))
+
+	all, unsubscribeAll := hub.Subscribe("")
+	home, unsubscribeHome := hub.Subscribe("home")
+	if hub.Subscribers() != 2 {
+		t.Fatalf("Expected 2 subscribers, got %d", hub.Subscribers())
+	}
+
+	hub.ObservePoll("lab", api.GatewayResponse{}, time.Second, errors.New("connection refused"))
+	hub.ObservePoll("home", api.GatewayResponse{
+		Device: api.Device{Serial: "ABC123"},
+		Signal: api.Signal{FiveG: api.SignalStats{Bands: []string{"n41"}, Rsrp: -90}},
+		Time:   api.Time{UpTime: 3600},
+	}, 250*time.Millisecond, nil)
+
+	failure := receive(t, all)
+	if failure.Type != TypeFailure || failure.Label != "lab" || !strings.Contains(string(failure.Data), `"error":"connection refused"`) {
+		t.Errorf("Unexpected failure event: %+v", failure)
+	}
+	poll := receive(t, all)
+	if poll.Type != TypePoll || poll.ID != failure.ID+1 {
+		t.Errorf("Unexpected poll event: %+v", poll)
+	}
+
+	// Only the poll of home reaches its subscriber
+	event := receive(t, home)
+	var data Poll
+	err := json.Unmarshal(event.Data, &data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if event.ID != poll.ID || data.Label != "home" || data.Device.Serial != "ABC123" || data.Signal.FiveG.Rsrp != -90 || data.Uptime != 3600 || data.Duration != 0.25 {
+		t.Errorf("Unexpected home poll: %+v", data)
+	}
+
+	unsubscribeHome()
+	unsubscribeHome()
+	if _, ok := <-home; ok || hub.Subscribers() != 1 {
+		t.Errorf("Expected the home subscriber to be closed and removed, %d left", hub.Subscribers())
+	}
+	unsubscribeAll()
+}
+
+func TestSlowSubscriber(t *testing.T) {
+	hub := NewHub(2, log.New(io.Discard, "", 0))
+	slow, unsubscribeSlow := hub.Subscribe("")
+	defer unsubscribeSlow()
+	fast, unsubscribeFast := hub.Subscribe("")
+	defer unsubscribeFast()
+
+	for range 3 {
+		hub.ObservePoll("home", api.GatewayResponse{}, time.Second, nil)
+		receive(t, fast)
+	}
+
+	// The events buffered before falling behind are still delivered
+	for range 2 {
+		receive(t, slow)
+	}
+	if _, ok := <-slow; ok {
+		t.Error("Expected the slow subscriber to be dropped")
+	}
+	if hub.Subscribers() != 1 {
+		t.Errorf("Expected the fast subscriber to be left, got %d subscribers", hub.Subscribers())
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	hub := NewHub(DefaultBuffer, log.New(io.Discard, "", 0))
+	server := httptest.NewServer(hub)
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "?label=home")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer response.Body.Close()
+	if response.Header.Get("Content-Type") != "text/event-stream" || response.Header.Get("Cache-Control") != "no-cache" {
+		t.Errorf("Unexpected headers: %v", response.Header)
+	}
+
+	reader := bufio.NewReader(response.Body)
+	// readEvent reads the lines up to the next blank line
+	readEvent := func() []string {
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if line == "\n" {
+				return lines
+			}
+			lines = append(lines, strings.TrimSuffix(line, "\n"))
+		}
+	}
+
+	if lines := readEvent(); len(lines) != 1 || lines[0] != "retry: 5000" {
+		t.Errorf("Expected the retry delay first, got %q", lines)
+	}
+
+	hub.ObservePoll("lab", api.GatewayResponse{}, time.Second, nil)
+	hub.ObservePoll("home", api.GatewayResponse{}, time.Second, errors.New("gateway returned status 503"))
+
+	lines := readEvent()
+	if len(lines) != 3 || lines[0] != "id: 2" || lines[1] != "event: failure" || !strings.HasPrefix(lines[2], `data: {"label":"home"`) {
+		t.Errorf("Expected the failure of home, got %q", lines)
+	}
+
+	response.Body.Close()
+	// The subscriber is removed once the handler notices the client went away
+	for range 100 {
+		if hub.Subscribers() == 0 {
+			return
+		}
+		hub.ObservePoll("home", api.GatewayResponse{}, time.Second, nil)
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("Expected the subscriber to be removed, got %d subscribers", hub.Subscribers())
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"local/tmo/api"
+	"local/tmo/dashboard"
+	"local/tmo/influx"
+	"local/tmo/metrics"
+	"local/tmo/mqtt"
+	"local/tmo/rollup"
+	"local/tmo/storage"
+	"local/tmo/stream"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Supervisor polls every configured gateway in its own goroutine, sharing one store and metrics exporter
+type Supervisor struct {
+	config  Config
+	store   storage.Store
+	metrics *metrics.Exporter
+	// dashboard is told about every poll and serves the dashboard, nil if it is disabled
+	dashboard *dashboard.Server
+	// stream pushes every poll to the subscribers of the dashboard stream, nil if the dashboard is disabled
+	stream *stream.Hub
+	// influx exports every poll, nil if it is disabled
+	influx *influx.Exporter
+	// mqtt publishes every poll to a broker, nil if it is disabled
+	mqtt *mqtt.Publisher
+	// recorders archive the raw responses of each gateway, they are closed once Run returns
+	recorders []*api.Recorder
+	pollers   []*GatewayPoller
+}
+
+// NewSupervisor creates a new Supervisor
+func NewSupervisor(config Config) *Supervisor {
+	if config.Logger == nil {
+		config.Logger = log.New(os.Stdout, "", log.LstdFlags)
+	}
+
+	return &Supervisor{
+		config: config,
+	}
+}
+
+// Initialize sets up the database connection and a poller for each gateway
+func (s *Supervisor) Initialize(ctx context.Context) error {
+	// Set up database
+	var err error
+	s.store, err = storage.Open(ctx, s.config.DBBackend, s.config.DBDSN)
+	if err != nil {
+		return fmt.Errorf("database initialization failed: %w", err)
+	}
+
+	// Refuse to write to a database that has not been migrated
+	migrator, err := s.store.Migrator()
+	if err != nil {
+		return fmt.Errorf("error loading migrations: %w", err)
+	}
+	err = migrator.Check(ctx)
+	if err != nil {
+		return fmt.Errorf("database schema check failed, run the migrate command: %w", err)
+	}
+
+	// Set up metrics
+	if s.config.MetricsAddr != "" {
+		s.metrics = metrics.NewExporter()
+	}
+
+	// Set up the line protocol export and the MQTT publisher. Replays only load the database, the export and the
+	// publisher are never run for them.
+	replaying := s.config.ReplayPath != ""
+	if s.config.Influx.Enabled() && !replaying {
+		s.influx, err = influx.NewExporter(s.config.Influx, s.config.Logger)
+		if err != nil {
+			return fmt.Errorf("influx initialization failed: %w", err)
+		}
+	}
+	if s.config.MQTT.Enabled() && !replaying {
+		s.mqtt = mqtt.NewPublisher(s.config.MQTT, s.config.Logger)
+	}
+	if replaying && (s.config.Influx.Enabled() || s.config.MQTT.Enabled()) {
+		s.config.Logger.Println("Replaying without the InfluxDB export and MQTT publishing")
+	}
+
+	// Set up the dashboard
+	if s.config.DashboardAddr != "" {
+		var labels []string
+		for _, gateway := range s.config.Gateways {
+			labels = append(labels, gateway.Label)
+		}
+		s.dashboard = dashboard.NewServer(s.store, labels, s.config.Logger)
+		s.stream = stream.NewHub(stream.DefaultBuffer, s.config.Logger)
+		s.dashboard.Handle("GET /api/stream", s.stream)
+	}
+
+	// Set up a poller for each gateway
+	if replaying {
+		// Recordings are stored under the label of the first gateway, if any
+		var gateway GatewayConfig
+		if len(s.config.Gateways) > 0 {
+			gateway = s.config.Gateways[0]
+		}
+
+		client, err := api.NewReplayClient(s.config.ReplayPath)
+		if err != nil {
+			return fmt.Errorf("replay initialization failed: %w", err)
+		}
+		s.pollers = []*GatewayPoller{s.newPoller(gateway, client)}
+		return nil
+	}
+
+	for _, gateway := range s.config.Gateways {
+		client, err := s.newClient(gateway)
+		if err != nil {
+			return fmt.Errorf("gateway %s: %w", gateway.Label, err)
+		}
+		s.pollers = append(s.pollers, s.newPoller(gateway, client))
+	}
+
+	return nil
+}
+
+// newPoller creates the poller of a gateway, telling the dashboard, its stream, the export and the MQTT publisher about
+// its polls if they are enabled
+func (s *Supervisor) newPoller(gateway GatewayConfig, client api.IClient) *GatewayPoller {
+	poller := NewGatewayPoller(gateway, client, s.store, s.metrics, s.logger(gateway))
+	if s.dashboard != nil {
+		poller.observers = append(poller.observers, s.dashboard, s.stream)
+	}
+	if s.influx != nil {
+		poller.observers = append(poller.observers, s.influx)
+	}
+	if s.mqtt != nil {
+		poller.observers = append(poller.observers, s.mqtt)
+	}
+	return poller
+}
+
+// newClient creates the API client of a gateway
+func (s *Supervisor) newClient(gateway GatewayConfig) (*api.Client, error) {
+	clientConfig := api.ClientConfig{
+		BaseURL:  gateway.URL,
+		Username: gateway.Username,
+		Password: gateway.Password,
+		Logger:   s.logger(gateway),
+		Driver:   gateway.Driver,
+	}
+
+	if s.metrics != nil {
+		clientConfig.OnLogin = func() { s.metrics.ObserveLogin(gateway.Label) }
+	}
+
+	if s.config.RecordDir != "" {
+		// Each gateway records to its own subdirectory so their files do not collide
+		dir := s.config.RecordDir
+		if gateway.Label != "" {
+			dir = filepath.Join(dir, gateway.Label)
+		}
+
+		recorder, err := api.NewRecorder(dir, recordMaxBytes)
+		if err != nil {
+			return nil, fmt.Errorf("recorder initialization failed: %w", err)
+		}
+		clientConfig.Recorder = recorder
+		s.recorders = append(s.recorders, recorder)
+	}
+
+	return api.NewClientWithConfig(clientConfig, &http.Client{Timeout: gateway.RequestTimeout}), nil
+}
+
+// logger returns a logger prefixing every message with the gateway label
+func (s *Supervisor) logger(gateway GatewayConfig) *log.Logger {
+	if gateway.Label == "" {
+		return s.config.Logger
+	}
+	return log.New(s.config.Logger.Writer(), "["+gateway.Label+"] ", s.config.Logger.Flags()|log.Lmsgprefix)
+}
+
+// Run polls every gateway until the context is done or every gateway has stopped.
+// A gateway that stops with an error is logged and does not affect the others.
+func (s *Supervisor) Run(ctx context.Context) error {
+	defer s.closeRecorders()
+
+	if s.metrics != nil {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", s.metrics.Handler())
+		err := s.serve(ctx, "metrics", s.config.MetricsAddr, mux)
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.dashboard != nil {
+		err := s.serve(ctx, "dashboard", s.config.DashboardAddr, s.dashboard)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Rollups use SQLite specific queries, retention is rejected for the other backends when the config is loaded
+	if sqlite, ok := s.store.(*storage.SQLite); ok {
+		go s.runRollups(ctx, sqlite.DB)
+	} else {
+		s.config.Logger.Println("Rollups are only supported by the SQLite backend")
+	}
+
+	// The export outlives the pollers, to write their last polls once they have stopped
+	exported := make(chan struct{})
+	exportCtx, stopExport := context.WithCancel(context.Background())
+	defer func() {
+		stopExport()
+		<-exported
+	}()
+	if s.influx != nil {
+		go func() {
+			s.influx.Run(exportCtx)
+			close(exported)
+		}()
+	} else {
+		close(exported)
+	}
+
+	// The poller is marked offline once the pollers have stopped
+	if s.mqtt != nil {
+		s.mqtt.Start()
+		defer s.mqtt.Close()
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(s.pollers))
+	for i, poller := range s.pollers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := poller.Run(ctx)
+			if err != nil && ctx.Err() == nil {
+				poller.logger.Printf("Stopped polling: %v", err)
+				errs[i] = fmt.Errorf("gateway %s: %w", poller.config.Label, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return errors.Join(errs...)
+}
+
+// Replay stores every recording from the replay client, returning once all of them have been loaded
+func (s *Supervisor) Replay(ctx context.Context) error {
+	for _, poller := range s.pollers {
+		err := poller.Replay(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// closeRecorders closes the archive of every gateway, once its poller has stopped
+func (s *Supervisor) closeRecorders() {
+	for _, recorder := range s.recorders {
+		err := recorder.Close()
+		if err != nil {
+			s.config.Logger.Printf("Error closing recorder: %v", err)
+		}
+	}
+}
+
+// serve starts an HTTP listener for the handler on the address, it is shut down when the context is done
+func (s *Supervisor) serve(ctx context.Context, name, addr string, handler http.Handler) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("error listening for %s: %w", name, err)
+	}
+
+	server := &http.Server{Handler: handler}
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	go func() {
+		s.config.Logger.Printf("Serving %s on %s", name, listener.Addr())
+		err := server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			s.config.Logger.Printf("Server for %s exited with error: %v", name, err)
+		}
+	}()
+
+	return nil
+}
+
+// runRollups keeps the rollup tables up to date and applies the retention period until the context is done
+func (s *Supervisor) runRollups(ctx context.Context, sqlDB *sql.DB) {
+	job := rollup.New(sqlDB, rollup.Config{
+		RetentionDays: s.config.RetentionDays,
+		Logger:        s.config.Logger,
+	})
+
+	ticker := time.NewTicker(rollupInterval)
+	defer ticker.Stop()
+
+	for {
+		_, err := job.Run(ctx, time.Now())
+		if err != nil {
+			s.config.Logger.Printf("Rollup failed: %v", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"local/tmo/api"
+	"local/tmo/db"
+	"local/tmo/storage"
+)
+
+// pollTelemetry fetches the radio channels of the serving cells when cell telemetry is enabled, returning nil otherwise.
+// A failure is logged and the poll is saved without channels, as for the clients.
+func (p *GatewayPoller) pollTelemetry(ctx context.Context) *api.CellTelemetryResponse {
+	if !p.config.cellTelemetry() || p.telemetryUnsupported {
+		return nil
+	}
+
+	telemetry, err := p.apiClient.GetCellTelemetry(ctx)
+	if errors.Is(err, errors.ErrUnsupported) {
+		p.logger.Printf("Not fetching cell telemetry: %v", err)
+		p.telemetryUnsupported = true
+		return nil
+	}
+	if err != nil {
+		p.logger.Printf("Failed to get cell telemetry: %v", err)
+		return nil
+	}
+
+	return &telemetry
+}
+
+// loadChannel inserts the radio channel of a signal into the database, unless the gateway reported none for its generation
+func loadChannel(ctx context.Context, queries storage.Tx, signal db.Signal, channel api.CellTelemetry) error {
+	if channel.Arfcn() == 0 && channel.Pci == 0 {
+		return nil
+	}
+
+	_, err := queries.CreateSignalChannel(ctx, db.CreateSignalChannelParams{
+		Signalid:  signal.ID,
+		Pci:       int64(channel.Pci),
+		Arfcn:     int64(channel.Arfcn()),
+		Bandwidth: channel.Bandwidth,
+		Cqi:       int64(channel.Cqi),
+		Tac:       channel.Tac,
+		Plmn:      channel.Plmn,
+		Ecgi:      channel.Ecgi,
+	})
+	return err
+}